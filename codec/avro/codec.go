@@ -0,0 +1,145 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package avro provides a [github.com/aws/go-kafka-event-source/streams.Codec] for Avro records in the Confluent wire format
+(a zero magic byte, followed by a 4 byte big-endian schema id and the Avro binary encoding), for topics shared with non-GKES applications.
+
+Writer schemas are resolved by id through a [SchemaRegistry]. [RegistryClient] talks to a Confluent compatible schema registry,
+while [LocalRegistry] holds schemas in memory or in a json file, for tests and local development:
+
+	registry := avro.NewRegistryClient("https://schema-registry:8081")
+	codec, err := avro.NewCodec[Order](ctx, registry, "orders-value", orderSchema)
+	...
+	streams.RegisterEventType(eventSource, codec.DecodeRecord, handleOrder, "order")
+
+Values are en/decoded with [github.com/hamba/avro/v2], so T is typically a struct with `avro` field tags.
+Records written with a different, compatible version of the schema are decoded via Avro schema resolution,
+so fields added to the reader schema take their default values and fields removed from it are skipped.
+*/
+package avro
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams"
+	hamba "github.com/hamba/avro/v2"
+)
+
+const magicByte = 0
+const headerSize = 5
+
+// The time allowed for fetching an unknown writer schema from the SchemaRegistry while decoding.
+var SchemaFetchTimeout = 10 * time.Second
+
+// A [streams.Codec] for Confluent wire format Avro records. Create with [NewCodec].
+type Codec[T any] struct {
+	registry SchemaRegistry
+	schema   hamba.Schema
+	id       int
+	// writer schema id -> schema resolved against our reader schema
+	resolved sync.Map
+}
+
+/*
+Creates a Codec which encodes with `schema`, registering it under `subject`, and decodes records of any compatible writer schema into `schema`.
+Returns an error if `schema` is invalid or can not be registered. Subjects conventionally follow the `{topic}-value` naming strategy.
+*/
+func NewCodec[T any](ctx context.Context, registry SchemaRegistry, subject, schema string) (*Codec[T], error) {
+	parsed, err := parseSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	id, err := registry.Register(ctx, subject, schema)
+	if err != nil {
+		return nil, err
+	}
+	c := &Codec[T]{registry: registry, schema: parsed, id: id}
+	c.resolved.Store(id, parsed)
+	return c, nil
+}
+
+// Each schema is parsed with it's own cache, as hamba.Parse would otherwise share named types between versions of the same record.
+func parseSchema(schema string) (hamba.Schema, error) {
+	return hamba.ParseWithCache(schema, "", &hamba.SchemaCache{})
+}
+
+// The id of the schema used for encoding.
+func (c *Codec[T]) SchemaId() int {
+	return c.id
+}
+
+// Encodes `t` in the Confluent wire format.
+func (c *Codec[T]) Encode(b *bytes.Buffer, t T) error {
+	payload, err := hamba.Marshal(c.schema, t)
+	if err != nil {
+		return err
+	}
+	var header [headerSize]byte
+	header[0] = magicByte
+	binary.BigEndian.PutUint32(header[1:], uint32(c.id))
+	b.Write(header[:])
+	_, err = b.Write(payload)
+	return err
+}
+
+// Decodes a Confluent wire format record, fetching it's writer schema from the SchemaRegistry if it has not been seen before.
+func (c *Codec[T]) Decode(b []byte) (T, error) {
+	var t T
+	if len(b) < headerSize || b[0] != magicByte {
+		return t, fmt.Errorf("avro: invalid wire format header")
+	}
+	schema, err := c.readerSchema(int(binary.BigEndian.Uint32(b[1:])))
+	if err != nil {
+		return t, err
+	}
+	return t, hamba.Unmarshal(schema, b[headerSize:], &t)
+}
+
+// Decodes the value of an IncomingRecord. Conforms to the streams.IncomingRecordDecoder interface needed for streams.RegisterEventType
+//
+//	streams.RegisterEventType(myEventSource, codec.DecodeRecord, myHandler, "myType")
+func (c *Codec[T]) DecodeRecord(record streams.IncomingRecord) (T, error) {
+	return c.Decode(record.Value())
+}
+
+// Returns a schema for decoding records written with the schema `id` into our reader schema.
+func (c *Codec[T]) readerSchema(id int) (hamba.Schema, error) {
+	if schema, ok := c.resolved.Load(id); ok {
+		return schema.(hamba.Schema), nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), SchemaFetchTimeout)
+	defer cancel()
+	text, err := c.registry.SchemaById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := parseSchema(text)
+	if err != nil {
+		return nil, fmt.Errorf("avro: invalid writer schema %d: %w", id, err)
+	}
+	schema := writer
+	if writer.Fingerprint() == c.schema.Fingerprint() {
+		schema = c.schema
+	} else if schema, err = hamba.NewSchemaCompatibility().Resolve(c.schema, writer); err != nil {
+		return nil, fmt.Errorf("avro: writer schema %d is not compatible: %w", id, err)
+	}
+	c.resolved.Store(id, schema)
+	return schema, nil
+}
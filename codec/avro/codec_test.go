@@ -0,0 +1,109 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package avro
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/go-kafka-event-source/streams"
+)
+
+const orderV1Schema = `{
+	"type": "record",
+	"name": "Order",
+	"namespace": "test",
+	"fields": [
+		{"name": "id", "type": "string"},
+		{"name": "quantity", "type": "int"},
+		{"name": "note", "type": "string"}
+	]
+}`
+
+// adds currency and removes note
+const orderV2Schema = `{
+	"type": "record",
+	"name": "Order",
+	"namespace": "test",
+	"fields": [
+		{"name": "id", "type": "string"},
+		{"name": "quantity", "type": "long"},
+		{"name": "currency", "type": "string", "default": "USD"}
+	]
+}`
+
+type orderV1 struct {
+	Id       string `avro:"id"`
+	Quantity int32  `avro:"quantity"`
+	Note     string `avro:"note"`
+}
+
+type orderV2 struct {
+	Id       string `avro:"id"`
+	Quantity int64  `avro:"quantity"`
+	Currency string `avro:"currency"`
+}
+
+func TestCodec(t *testing.T) {
+	ctx := context.Background()
+	registry := NewMemoryRegistry()
+	v1, err := NewCodec[orderV1](ctx, registry, "orders-value", orderV1Schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := NewCodec[orderV2](ctx, registry, "orders-value", orderV2Schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v1.SchemaId() == v2.SchemaId() {
+		t.Errorf("schemas should have distinct ids: %d", v1.SchemaId())
+	}
+	var streamsCodec streams.Codec[orderV1] = v1
+
+	buf := bytes.NewBuffer(nil)
+	if err = streamsCodec.Encode(buf, orderV1{Id: "a", Quantity: 3, Note: "fragile"}); err != nil {
+		t.Fatal(err)
+	}
+	if header := buf.Bytes()[:headerSize]; !bytes.Equal(header, []byte{0, 0, 0, 0, byte(v1.SchemaId())}) {
+		t.Errorf("incorrect wire format header: %x", header)
+	}
+	same, err := v1.Decode(buf.Bytes())
+	if err != nil || same != (orderV1{Id: "a", Quantity: 3, Note: "fragile"}) {
+		t.Errorf("incorrect decoded value: %+v, %v", same, err)
+	}
+	evolved, err := v2.DecodeRecord(streams.NewRecord().WithValue(buf.Bytes()).AsIncomingRecord())
+	if err != nil || evolved != (orderV2{Id: "a", Quantity: 3, Currency: "USD"}) {
+		t.Errorf("incorrect resolved value: %+v, %v", evolved, err)
+	}
+
+	if _, err = v1.Decode([]byte{1, 0, 0, 0, 1}); err == nil {
+		t.Errorf("expected error for invalid magic byte")
+	}
+	if _, err = v1.Decode([]byte{0, 0, 0, 0, 99}); !errors.Is(err, ErrSchemaNotFound) {
+		t.Errorf("incorrect error for unknown schema. actual: %v, expected: %v", err, ErrSchemaNotFound)
+	}
+	incompatible, err := registry.Register(ctx, "other-value", `{"type": "record", "name": "Other", "fields": [{"name": "x", "type": "boolean"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = v2.Decode([]byte{0, 0, 0, 0, byte(incompatible)}); err == nil {
+		t.Errorf("expected error for incompatible schema")
+	}
+	if _, err = NewCodec[orderV1](ctx, registry, "orders-value", `{"type": "nope"}`); err == nil {
+		t.Errorf("expected error for invalid schema")
+	}
+}
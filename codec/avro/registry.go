@@ -0,0 +1,221 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package avro
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Returned by a SchemaRegistry when no schema exists for the requested id.
+var ErrSchemaNotFound = errors.New("avro: schema not found")
+
+// A source of Avro schemas, addressed by the ids embedded in Confluent wire format records.
+// Schemas are immutable once registered, so callers may cache them indefinitely. Implementations must be safe for concurrent use.
+type SchemaRegistry interface {
+	// Returns the schema registered with `id`, or an error wrapping ErrSchemaNotFound.
+	SchemaById(ctx context.Context, id int) (string, error)
+	// Registers `schema` under `subject`, returning it's id. Registering a schema which already exists returns the existing id.
+	Register(ctx context.Context, subject, schema string) (int, error)
+}
+
+// A SchemaRegistry backed by the REST API of a Confluent compatible schema registry. Schemas fetched by id are cached.
+type RegistryClient struct {
+	// The base url of the schema registry, `https://schema-registry:8081` for example.
+	URL string
+	// Used for all requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// If set, requests are made with basic authentication.
+	Username, Password string
+
+	cache sync.Map
+}
+
+// Creates a RegistryClient for the schema registry at `baseUrl`.
+func NewRegistryClient(baseUrl string) *RegistryClient {
+	return &RegistryClient{URL: baseUrl}
+}
+
+const registryContentType = "application/vnd.schemaregistry.v1+json"
+
+type registrySchema struct {
+	Schema string `json:"schema"`
+}
+
+type registryId struct {
+	Id int `json:"id"`
+}
+
+// Fetches the schema with `id` via `GET /schemas/ids/{id}`.
+func (rc *RegistryClient) SchemaById(ctx context.Context, id int) (string, error) {
+	if schema, ok := rc.cache.Load(id); ok {
+		return schema.(string), nil
+	}
+	var response registrySchema
+	if err := rc.do(ctx, http.MethodGet, "/schemas/ids/"+strconv.Itoa(id), nil, &response); err != nil {
+		return "", err
+	}
+	rc.cache.Store(id, response.Schema)
+	return response.Schema, nil
+}
+
+// Registers `schema` via `POST /subjects/{subject}/versions`.
+func (rc *RegistryClient) Register(ctx context.Context, subject, schema string) (int, error) {
+	var response registryId
+	if err := rc.do(ctx, http.MethodPost, "/subjects/"+url.PathEscape(subject)+"/versions", registrySchema{Schema: schema}, &response); err != nil {
+		return 0, err
+	}
+	rc.cache.Store(response.Id, schema)
+	return response.Id, nil
+}
+
+func (rc *RegistryClient) do(ctx context.Context, method, path string, body, response any) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(rc.URL, "/")+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", registryContentType)
+	if body != nil {
+		req.Header.Set("Content-Type", registryContentType)
+	}
+	if len(rc.Username) > 0 {
+		req.SetBasicAuth(rc.Username, rc.Password)
+	}
+	client := rc.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %s %s: %s", ErrSchemaNotFound, method, path, b)
+	case res.StatusCode >= 300:
+		return fmt.Errorf("avro: schema registry %s %s returned %d: %s", method, path, res.StatusCode, b)
+	}
+	return json.Unmarshal(b, response)
+}
+
+type localSchema struct {
+	Id      int
+	Subject string
+	Schema  string
+}
+
+/*
+A SchemaRegistry stand-in which holds schemas in memory, optionally persisting them to a json file, so that Avro codecs can be
+used in tests and local development without a running schema registry. Ids are assigned sequentially from 1.
+The file may also be written by hand to pin schema ids to those of a real registry:
+
+	[{"Id": 100, "Subject": "orders-value", "Schema": "{\"type\": \"record\", ...}"}]
+*/
+type LocalRegistry struct {
+	path    string
+	mux     sync.Mutex
+	schemas []localSchema
+}
+
+// Creates a LocalRegistry which is not persisted.
+func NewMemoryRegistry() *LocalRegistry {
+	return &LocalRegistry{}
+}
+
+// Creates a LocalRegistry persisted to the json file at `path`, loading any schemas it already contains.
+// The file is rewritten each time a new schema is registered.
+func NewFileRegistry(path string) (*LocalRegistry, error) {
+	lr := &LocalRegistry{path: path}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return lr, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &lr.schemas); err != nil {
+		return nil, fmt.Errorf("avro: invalid registry file %s: %w", path, err)
+	}
+	return lr, nil
+}
+
+func (lr *LocalRegistry) SchemaById(_ context.Context, id int) (string, error) {
+	lr.mux.Lock()
+	defer lr.mux.Unlock()
+	for _, ls := range lr.schemas {
+		if ls.Id == id {
+			return ls.Schema, nil
+		}
+	}
+	return "", fmt.Errorf("%w: id %d", ErrSchemaNotFound, id)
+}
+
+// Registers `schema` under `subject`. A schema is considered to exist if it is textually identical to a schema already registered, under any subject,
+// in which case it's id is reused.
+func (lr *LocalRegistry) Register(_ context.Context, subject, schema string) (int, error) {
+	lr.mux.Lock()
+	defer lr.mux.Unlock()
+	nextId := 1
+	var existing *localSchema
+	for i, ls := range lr.schemas {
+		if ls.Schema == schema {
+			if ls.Subject == subject {
+				return ls.Id, nil
+			}
+			existing = &lr.schemas[i]
+		}
+		nextId = max(nextId, ls.Id+1)
+	}
+	id := nextId
+	if existing != nil {
+		id = existing.Id
+	}
+	lr.schemas = append(lr.schemas, localSchema{Id: id, Subject: subject, Schema: schema})
+	return id, lr.save()
+}
+
+func (lr *LocalRegistry) save() error {
+	if len(lr.path) == 0 {
+		return nil
+	}
+	b, err := json.MarshalIndent(lr.schemas, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(lr.path, b, 0644)
+}
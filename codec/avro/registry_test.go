@@ -0,0 +1,132 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package avro
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFileRegistry(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "schemas.json")
+	registry, err := NewFileRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := registry.Register(ctx, "orders-value", orderV1Schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := registry.Register(ctx, "orders-value", orderV1Schema); again != id {
+		t.Errorf("re-registering should return the existing id. actual: %d, expected: %d", again, id)
+	}
+	if other, _ := registry.Register(ctx, "other-value", orderV1Schema); other != id {
+		t.Errorf("an identical schema should share it's id. actual: %d, expected: %d", other, id)
+	}
+	v2, err := registry.Register(ctx, "orders-value", orderV2Schema)
+	if err != nil || v2 == id {
+		t.Errorf("incorrect id for new schema: %d, %v", v2, err)
+	}
+
+	reloaded, err := NewFileRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema, err := reloaded.SchemaById(ctx, v2); err != nil || schema != orderV2Schema {
+		t.Errorf("incorrect reloaded schema: %s, %v", schema, err)
+	}
+	if _, err = reloaded.SchemaById(ctx, 42); !errors.Is(err, ErrSchemaNotFound) {
+		t.Errorf("incorrect error for unknown id. actual: %v, expected: %v", err, ErrSchemaNotFound)
+	}
+}
+
+// serves the subset of the Confluent schema registry API used by RegistryClient, backed by a LocalRegistry
+func testRegistryServer(lookups *int64) *httptest.Server {
+	registry := NewMemoryRegistry()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /schemas/ids/{id}", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(lookups, 1)
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		schema, err := registry.SchemaById(r.Context(), id)
+		if err != nil {
+			http.Error(w, `{"error_code": 40403}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(registrySchema{Schema: schema})
+	})
+	mux.HandleFunc("POST /subjects/{subject}/versions", func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "user" || pass != "pass" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var body registrySchema
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		id, _ := registry.Register(r.Context(), r.PathValue("subject"), body.Schema)
+		json.NewEncoder(w).Encode(registryId{Id: id})
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestRegistryClient(t *testing.T) {
+	ctx := context.Background()
+	var lookups int64
+	server := testRegistryServer(&lookups)
+	defer server.Close()
+
+	client := NewRegistryClient(server.URL + "/")
+	if _, err := client.Register(ctx, "orders-value", orderV1Schema); err == nil {
+		t.Errorf("expected error without credentials")
+	}
+	client.Username, client.Password = "user", "pass"
+	v1, err := NewCodec[orderV1](ctx, client, "orders-value", orderV1Schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err = v1.Encode(&buf, orderV1{Id: "b", Quantity: 1}); err != nil {
+		t.Fatal(err)
+	}
+	encoded := buf.Bytes()
+
+	// a separate client has not seen the schema, so it must be fetched once
+	reader := &RegistryClient{URL: server.URL, Username: "user", Password: "pass"}
+	v2, err := NewCodec[orderV2](ctx, reader, "orders-value", orderV2Schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if order, err := v2.Decode(encoded); err != nil || order.Id != "b" || order.Currency != "USD" {
+			t.Errorf("incorrect decoded value: %+v, %v", order, err)
+		}
+	}
+	if count := atomic.LoadInt64(&lookups); count != 1 {
+		t.Errorf("incorrect schema lookup count. actual: %d, expected: %d", count, 1)
+	}
+	if _, err = reader.SchemaById(ctx, 99); !errors.Is(err, ErrSchemaNotFound) {
+		t.Errorf("incorrect error for unknown id. actual: %v, expected: %v", err, ErrSchemaNotFound)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package protobuf provides a [github.com/aws/go-kafka-event-source/streams.Codec] and event registration helpers for protobuf generated message types.
+By convention, the record type header of a protobuf event is the full name of it's message (`orders.v1.OrderPlaced` for example),
+so events can be registered without repeating the record type:
+
+	protobuf.RegisterEventType(eventSource, func(ec *streams.EventContext[myStore], order *orderspb.OrderPlaced) streams.ExecutionState {
+		...
+		record, err := protobuf.ItemEncoder(&orderspb.OrderAccepted{OrderId: order.OrderId})
+		...
+		ec.Forward(record.WithKeyString(order.OrderId))
+		return streams.Complete
+	})
+
+Messages are unmarshalled directly from the IncomingRecord value and marshalled directly into the Record value buffer, without intermediate copies.
+Note that protobuf-go copies string and bytes fields while unmarshalling, so decoded messages never retain a reference to the IncomingRecord.
+*/
+package protobuf
+
+import (
+	"bytes"
+
+	"github.com/aws/go-kafka-event-source/streams"
+	"google.golang.org/protobuf/proto"
+)
+
+// A [streams.Codec] for a protobuf generated message type, `Codec[*orderspb.OrderPlaced]` for example.
+// The zero value uses the default proto.MarshalOptions and proto.UnmarshalOptions.
+type Codec[P proto.Message] struct {
+	MarshalOptions   proto.MarshalOptions
+	UnmarshalOptions proto.UnmarshalOptions
+}
+
+// Encodes `p`, appending directly to the available capacity of `b`.
+func (c Codec[P]) Encode(b *bytes.Buffer, p P) error {
+	out, err := c.MarshalOptions.MarshalAppend(b.AvailableBuffer(), p)
+	if err != nil {
+		return err
+	}
+	_, err = b.Write(out)
+	return err
+}
+
+// Decodes `b` into a newly allocated P.
+func (c Codec[P]) Decode(b []byte) (P, error) {
+	p := newMessage[P]()
+	return p, c.UnmarshalOptions.Unmarshal(b, p)
+}
+
+// Generated message types handle ProtoReflect() on a nil pointer, so we can allocate a P without knowing it's underlying struct type.
+func newMessage[P proto.Message]() P {
+	var zero P
+	return zero.ProtoReflect().Type().New().Interface().(P)
+}
+
+// Returns the full name of P, which is used as the record type for protobuf events.
+func RecordType[P proto.Message]() string {
+	var zero P
+	return string(zero.ProtoReflect().Descriptor().FullName())
+}
+
+// Decodes the value of an IncomingRecord into a P.
+// Conforms to the streams.IncomingRecordDecoder interface needed for streams.RegisterEventType
+//
+//	streams.RegisterEventType(myEventSource, protobuf.ItemDecoder[*orderspb.OrderPlaced], myHandler, protobuf.RecordType[*orderspb.OrderPlaced]())
+func ItemDecoder[P proto.Message](record streams.IncomingRecord) (P, error) {
+	var codec Codec[P]
+	return codec.Decode(record.Value())
+}
+
+// Encodes `item` into a Record suitable for sending to a producer, with a record type of RecordType[P]().
+// Please note that the Key on the record will be left uninitialized.
+func ItemEncoder[P proto.Message](item P) (*streams.Record, error) {
+	var codec Codec[P]
+	record := streams.NewRecord().WithRecordType(RecordType[P]())
+	if err := codec.Encode(record.ValueWriter(), item); err != nil {
+		record.Release()
+		return nil, err
+	}
+	return record, nil
+}
+
+// Encodes `item` into a ChangeLogEntry suitable for writing to a StateStore, with an entry type of RecordType[P]().
+// Please note that the Key on the entry will be left uninitialized.
+func EncodeChangeLogEntryValue[P proto.Message](item P) (streams.ChangeLogEntry, error) {
+	var codec Codec[P]
+	cle := streams.NewChangeLogEntry().WithEntryType(RecordType[P]())
+	return cle, codec.Encode(cle.ValueWriter(), item)
+}
+
+// Registers `eventProcessor` for records with a record type of RecordType[P](). Records which can not be unmarshalled are passed
+// to the DeserializationErrorHandler of the EventSource.
+func RegisterEventType[T streams.StateStore, P proto.Message](es *streams.EventSource[T], eventProcessor streams.EventProcessor[T, P]) {
+	streams.RegisterEventType(es, ItemDecoder[P], eventProcessor, RecordType[P]())
+}
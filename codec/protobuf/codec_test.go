@@ -0,0 +1,107 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protobuf
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type countStore struct {
+	counts map[string]int
+}
+
+func (countStore) ReceiveChange(streams.IncomingRecord) error {
+	return nil
+}
+
+func (countStore) Revoked() {}
+
+func TestCodec(t *testing.T) {
+	var codec streams.Codec[*timestamppb.Timestamp] = Codec[*timestamppb.Timestamp]{}
+	expected := timestamppb.New(time.Unix(1700000000, 42))
+	buf := bytes.NewBufferString("prefix")
+	if err := codec.Encode(buf, expected); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("prefix")) {
+		t.Errorf("existing buffer contents overwritten: %x", buf.Bytes())
+	}
+	actual, err := codec.Decode(buf.Bytes()[len("prefix"):])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(actual, expected) {
+		t.Errorf("incorrect decoded value. actual: %v, expected: %v", actual, expected)
+	}
+	if _, err = codec.Decode([]byte{0xff}); err == nil {
+		t.Errorf("expected error for invalid input")
+	}
+	if recordType := RecordType[*timestamppb.Timestamp](); recordType != "google.protobuf.Timestamp" {
+		t.Errorf("incorrect record type. actual: %s, expected: %s", recordType, "google.protobuf.Timestamp")
+	}
+}
+
+func TestRegisterEventType(t *testing.T) {
+	driver := streams.NewTestDriver(streams.EventSourceConfig{
+		GroupId:       "protobuf_group",
+		Topic:         "protobuf_topic",
+		NumPartitions: 1,
+	}, func(streams.TopicPartition) countStore {
+		return countStore{counts: make(map[string]int)}
+	}, func(*streams.EventContext[countStore], streams.IncomingRecord) streams.ExecutionState {
+		return streams.Complete
+	})
+	defer driver.Close()
+
+	RegisterEventType(driver.EventSource(), func(ec *streams.EventContext[countStore], event *wrapperspb.StringValue) streams.ExecutionState {
+		ec.Store().counts[event.Value]++
+		cle, err := EncodeChangeLogEntryValue(wrapperspb.Int64(int64(ec.Store().counts[event.Value])))
+		if err != nil {
+			t.Error(err)
+			return streams.Fatal
+		}
+		ec.RecordChange(cle.WithKeyString(event.Value))
+		return streams.Complete
+	})
+
+	record, err := ItemEncoder(wrapperspb.String("widget"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state := driver.Pipe(0, record.WithKeyString("widget")); state != streams.Complete {
+		t.Fatalf("incorrect execution state. actual: %v, expected: %v", state, streams.Complete)
+	}
+	if count := driver.Store(0).counts["widget"]; count != 1 {
+		t.Errorf("incorrect count. actual: %d, expected: %d", count, 1)
+	}
+	changeLog := driver.ChangeLog(0)
+	if len(changeLog) != 1 {
+		t.Fatalf("incorrect change log length. actual: %d, expected: %d", len(changeLog), 1)
+	}
+	if changeLog[0].RecordType() != "google.protobuf.Int64Value" {
+		t.Errorf("incorrect entry type. actual: %s, expected: %s", changeLog[0].RecordType(), "google.protobuf.Int64Value")
+	}
+	count, err := ItemDecoder[*wrapperspb.Int64Value](changeLog[0])
+	if err != nil || count.Value != 1 {
+		t.Errorf("incorrect change log value: %v, %v", count, err)
+	}
+}
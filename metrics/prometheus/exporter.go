@@ -0,0 +1,194 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package prometheus provides an Exporter which converts the [github.com/aws/go-kafka-event-source/streams.Metric] stream
+of an EventSource into Prometheus metrics, registered on a caller-supplied registry.
+
+	registry := prom.NewRegistry()
+	exporter, err := prometheus.NewExporter(prometheus.Config{Registerer: registry})
+	...
+	eventSource, err := streams.NewEventSource(streams.EventSourceConfig{
+		...
+		MetricsHandler: exporter.MetricsHandler(),
+	}, ...)
+
+Async scheduler queue depth is not part of the Metric stream, so schedulers must be registered with the Exporter explicitly:
+
+	exporter.WatchAsyncJobScheduler("inventory", scheduler)
+
+The following metrics are exported, prefixed with Config.Namespace. All carry `group_id` and `topic` labels:
+
+  - txn_duration_seconds: histogram of the time between the first event of a transaction and it's commit
+  - txn_linger_seconds: histogram of the time between the first event of a transaction and the start of it's commit
+  - partition_prep_duration_seconds: histogram of the time taken to prepare a partition's StateStore
+  - records_total: counter of input records committed, with a `partition` label
+  - bytes_total: counter of input bytes committed, with a `partition` label
+  - assigned_partitions: gauge of the partitions currently assigned
+  - preparing_partitions: gauge of the partitions currently being prepared by the IncrementalRebalancer
+  - eos_pending_events: gauge of events in the eos producer pool awaiting commit
+  - lag_records: gauge of the consumer lag in records, with a `partition` label. Requires EventSourceConfig.ProgressInterval
+  - lag_seconds: gauge of the estimated consumer lag in time, with a `partition` label. Requires EventSourceConfig.ProgressInterval
+  - async_scheduler_queue_depth: gauge of unprocessed jobs per AsyncJobScheduler, with a `scheduler` label (no `group_id` or `topic`)
+*/
+package prometheus
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/aws/go-kafka-event-source/streams"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Returned by NewExporter if Config.Registerer is nil.
+var ErrMissingRegisterer = errors.New("prometheus: Config.Registerer is required")
+
+// The default namespace for exported metrics.
+const DefaultNamespace = "gkes"
+
+var sourceLabels = []string{"group_id", "topic"}
+var partitionLabels = []string{"group_id", "topic", "partition"}
+
+type Config struct {
+	// The registry on which all metrics are registered. Required.
+	Registerer prom.Registerer
+	// Prefixed to all metric names. Defaults to DefaultNamespace.
+	Namespace string
+	// Histogram buckets, in seconds, for transaction and partition prep durations. Defaults to prom.DefBuckets.
+	DurationBuckets []float64
+}
+
+// Exports the metrics emitted by one or more EventSources. An Exporter may be shared by multiple EventSources,
+// as all metrics are labelled by group id and topic.
+type Exporter struct {
+	config              Config
+	txnDuration         *prom.HistogramVec
+	txnLinger           *prom.HistogramVec
+	partitionPrep       *prom.HistogramVec
+	records             *prom.CounterVec
+	bytes               *prom.CounterVec
+	assignedPartitions  *prom.GaugeVec
+	preparingPartitions *prom.GaugeVec
+	eosPending          *prom.GaugeVec
+	lagRecords          *prom.GaugeVec
+	lagSeconds          *prom.GaugeVec
+}
+
+// Creates an Exporter and registers it's metrics on config.Registerer.
+func NewExporter(config Config) (*Exporter, error) {
+	if config.Registerer == nil {
+		return nil, ErrMissingRegisterer
+	}
+	if len(config.Namespace) == 0 {
+		config.Namespace = DefaultNamespace
+	}
+	if len(config.DurationBuckets) == 0 {
+		config.DurationBuckets = prom.DefBuckets
+	}
+	histogram := func(name, help string) *prom.HistogramVec {
+		return prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: config.Namespace,
+			Name:      name,
+			Help:      help,
+			Buckets:   config.DurationBuckets,
+		}, sourceLabels)
+	}
+	counter := func(name, help string) *prom.CounterVec {
+		return prom.NewCounterVec(prom.CounterOpts{
+			Namespace: config.Namespace,
+			Name:      name,
+			Help:      help,
+		}, partitionLabels)
+	}
+	gauge := func(name, help string, labels []string) *prom.GaugeVec {
+		return prom.NewGaugeVec(prom.GaugeOpts{
+			Namespace: config.Namespace,
+			Name:      name,
+			Help:      help,
+		}, labels)
+	}
+	e := &Exporter{
+		config:              config,
+		txnDuration:         histogram("txn_duration_seconds", "Time between the first event of a transaction and it's commit."),
+		txnLinger:           histogram("txn_linger_seconds", "Time between the first event of a transaction and the start of it's commit."),
+		partitionPrep:       histogram("partition_prep_duration_seconds", "Time taken to prepare the StateStore of a partition."),
+		records:             counter("records_total", "Input records committed."),
+		bytes:               counter("bytes_total", "Input bytes committed."),
+		assignedPartitions:  gauge("assigned_partitions", "Partitions currently assigned.", sourceLabels),
+		preparingPartitions: gauge("preparing_partitions", "Partitions currently being prepared by the IncrementalRebalancer.", sourceLabels),
+		eosPending:          gauge("eos_pending_events", "Events in the eos producer pool awaiting commit.", sourceLabels),
+		lagRecords:          gauge("lag_records", "Records between the committed offset and the high watermark.", partitionLabels),
+		lagSeconds:          gauge("lag_seconds", "Time since the timestamp of the last processed record, if lagging.", partitionLabels),
+	}
+	for _, c := range []prom.Collector{
+		e.txnDuration, e.txnLinger, e.partitionPrep,
+		e.records, e.bytes,
+		e.assignedPartitions, e.preparingPartitions, e.eosPending,
+		e.lagRecords, e.lagSeconds,
+	} {
+		if err := config.Registerer.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// Returns a MetricsHandler suitable for EventSourceConfig.MetricsHandler.
+func (e *Exporter) MetricsHandler() streams.MetricsHandler {
+	return e.Handle
+}
+
+// Records `m`. Metrics with an unknown Operation, such as those emitted via EventSource.EmitMetric, are ignored.
+func (e *Exporter) Handle(m streams.Metric) {
+	switch m.Operation {
+	case streams.TxnCommitOperation:
+		e.txnDuration.WithLabelValues(m.GroupId, m.Topic).Observe(m.Duration().Seconds())
+		e.txnLinger.WithLabelValues(m.GroupId, m.Topic).Observe(m.Linger().Seconds())
+	case streams.PartitionPreppedOperation:
+		e.partitionPrep.WithLabelValues(m.GroupId, m.Topic).Observe(m.Duration().Seconds())
+	case streams.PartitionCommitOperation:
+		partition := strconv.Itoa(int(m.Partition))
+		e.records.WithLabelValues(m.GroupId, m.Topic, partition).Add(float64(m.Count))
+		e.bytes.WithLabelValues(m.GroupId, m.Topic, partition).Add(float64(m.Bytes))
+	case streams.PartitionsAssignedOperation:
+		e.assignedPartitions.WithLabelValues(m.GroupId, m.Topic).Set(float64(m.PartitionCount))
+	case streams.PartitionsPreparingOperation:
+		e.preparingPartitions.WithLabelValues(m.GroupId, m.Topic).Set(float64(m.PartitionCount))
+	case streams.EosPendingOperation:
+		e.eosPending.WithLabelValues(m.GroupId, m.Topic).Set(float64(m.Count))
+	case streams.PartitionLagOperation:
+		partition := strconv.Itoa(int(m.Partition))
+		e.lagRecords.WithLabelValues(m.GroupId, m.Topic, partition).Set(float64(m.Count))
+		e.lagSeconds.WithLabelValues(m.GroupId, m.Topic, partition).Set(m.Duration().Seconds())
+	}
+}
+
+// Implemented by [github.com/aws/go-kafka-event-source/streams.AsyncJobScheduler].
+type QueueDepthReporter interface {
+	QueueDepth() int
+}
+
+// Exports the queue depth of `scheduler` as async_scheduler_queue_depth{scheduler=`name`}. The gauge is sampled on each scrape.
+// Returns an error if a scheduler with the same name is already registered.
+func (e *Exporter) WatchAsyncJobScheduler(name string, scheduler QueueDepthReporter) error {
+	return e.config.Registerer.Register(prom.NewGaugeFunc(prom.GaugeOpts{
+		Namespace:   e.config.Namespace,
+		Name:        "async_scheduler_queue_depth",
+		Help:        "Scheduled AsyncJobs which have not yet been processed.",
+		ConstLabels: prom.Labels{"scheduler": name},
+	}, func() float64 {
+		return float64(scheduler.QueueDepth())
+	}))
+}
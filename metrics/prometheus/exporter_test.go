@@ -0,0 +1,116 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type fixedQueueDepth int
+
+func (f fixedQueueDepth) QueueDepth() int {
+	return int(f)
+}
+
+func TestExporter(t *testing.T) {
+	if _, err := NewExporter(Config{}); err != ErrMissingRegisterer {
+		t.Errorf("incorrect error. actual: %v, expected: %v", err, ErrMissingRegisterer)
+	}
+	registry := prom.NewRegistry()
+	exporter, err := NewExporter(Config{Registerer: registry, Namespace: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := exporter.MetricsHandler()
+	start := time.Now()
+	for _, partition := range []int32{0, 1, 1} {
+		handler(streams.Metric{
+			Operation: streams.PartitionCommitOperation,
+			GroupId:   "group",
+			Topic:     "topic",
+			Partition: partition,
+			Count:     2,
+			Bytes:     100,
+		})
+	}
+	handler(streams.Metric{
+		Operation:   streams.TxnCommitOperation,
+		GroupId:     "group",
+		Topic:       "topic",
+		StartTime:   start,
+		ExecuteTime: start.Add(10 * time.Millisecond),
+		EndTime:     start.Add(30 * time.Millisecond),
+	})
+	handler(streams.Metric{Operation: streams.PartitionsAssignedOperation, GroupId: "group", Topic: "topic", PartitionCount: 4})
+	handler(streams.Metric{Operation: streams.PartitionsPreparingOperation, GroupId: "group", Topic: "topic", PartitionCount: 1})
+	handler(streams.Metric{Operation: streams.EosPendingOperation, GroupId: "group", Topic: "topic", Count: 7})
+	handler(streams.Metric{
+		Operation: streams.PartitionLagOperation,
+		GroupId:   "group",
+		Topic:     "topic",
+		Partition: 2,
+		Count:     12,
+		StartTime: start,
+		EndTime:   start.Add(3 * time.Second),
+	})
+	handler(streams.Metric{Operation: "Custom", GroupId: "group", Topic: "topic"})
+
+	if err := exporter.WatchAsyncJobScheduler("jobs", fixedQueueDepth(3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := exporter.WatchAsyncJobScheduler("jobs", fixedQueueDepth(3)); err == nil {
+		t.Errorf("duplicate scheduler name should not be registered")
+	}
+
+	expected := []struct {
+		collector prom.Collector
+		value     float64
+	}{
+		{exporter.records.WithLabelValues("group", "topic", "0"), 2},
+		{exporter.records.WithLabelValues("group", "topic", "1"), 4},
+		{exporter.bytes.WithLabelValues("group", "topic", "1"), 200},
+		{exporter.assignedPartitions, 4},
+		{exporter.preparingPartitions, 1},
+		{exporter.eosPending, 7},
+		{exporter.lagRecords.WithLabelValues("group", "topic", "2"), 12},
+		{exporter.lagSeconds.WithLabelValues("group", "topic", "2"), 3},
+	}
+	for i, e := range expected {
+		if actual := testutil.ToFloat64(e.collector); actual != e.value {
+			t.Errorf("incorrect value for metric %d. actual: %v, expected: %v", i, actual, e.value)
+		}
+	}
+	if count := testutil.CollectAndCount(exporter.txnDuration); count != 1 {
+		t.Errorf("incorrect txn duration series count. actual: %d, expected: %d", count, 1)
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, family := range families {
+		if family.GetName() == "test_async_scheduler_queue_depth" {
+			found = family.GetMetric()[0].GetGauge().GetValue() == 3
+		}
+	}
+	if !found {
+		t.Errorf("async scheduler queue depth not exported")
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// The state of a partition assigned to an EventSource, as reported by the admin handler.
+type PartitionStatus struct {
+	TopicPartition TopicPartition
+	// False while the StateStore for the partition is still being bootstrapped.
+	Active bool
+	// Record batches fetched for the partition which have not yet been scheduled.
+	FetchedBatches int
+	// Events scheduled for processing which have not yet been dispatched to an EventProcessor.
+	QueuedEvents int
+	// Completed AsyncJobs waiting to be finalized.
+	QueuedAsyncJobs int
+	// Events dispatched to the eos producer pool which have not yet been committed.
+	PendingEvents int
+}
+
+// The state of the transactional producer pool, as reported by the admin handler.
+type EosPoolStatus struct {
+	PoolSize        int
+	TargetBatchSize int
+	MaxBatchSize    int
+	// Producers which are idle, awaiting their turn to accept events.
+	AvailableProducers int
+	// Producers with a full or lingering transaction, queued for commit.
+	CommittingProducers int
+	// Events waiting to be added to a transaction.
+	BufferedEvents int
+	// Events added to the pool which have not yet been committed.
+	PendingEvents int
+}
+
+// The state of an EventSource, as reported by the admin handler.
+type AdminStatus struct {
+	GroupId string
+	Topic   string
+	Healthy bool
+	// True when Healthy and every assigned partition is Active.
+	Ready      bool
+	Partitions []PartitionStatus
+	// The metadata this member shares with the consumer group. Nil unless the IncrementalRebalancer is in use.
+	Rebalancer *IncrGroupMemberMeta
+	Eos        EosPoolStatus
+}
+
+// Configures the handler returned by [NewAdminHandler].
+type AdminConfig[T StateStore] struct {
+	// Interjectors which may be triggered by name via `POST /interjections/{name}`.
+	// Each request runs the Interjector once on every active partition, as with [EventSource.InterjectAllSync].
+	Interjections map[string]Interjector[T]
+}
+
+/*
+NewAdminHandler returns an http.Handler exposing the state of `es` for operators and orchestrators. It is not started automatically,
+mount it on the server of your choice, behind whatever authentication your environment requires:
+
+	http.Handle("/admin/", http.StripPrefix("/admin", streams.NewAdminHandler(eventSource, streams.AdminConfig[myStore]{
+		Interjections: map[string]streams.Interjector[myStore]{"snapshot": takeSnapshot},
+	})))
+
+The following endpoints are served:
+
+	GET  /livez                 200 if the EventSource is Healthy, otherwise 503
+	GET  /readyz                200 once the EventSource is Healthy and every assigned partition is active, otherwise 503
+	GET  /status                the AdminStatus as json
+	GET  /assignments           the PartitionStatus of each assigned partition as json
+	GET  /rebalancer            the IncrGroupMemberMeta for this member as json, 404 if the IncrementalRebalancer is not in use
+	GET  /eos                   the EosPoolStatus as json
+	POST /stop                  invokes EventSource.Stop, 202
+	POST /interjections/{name}  runs the named Interjector on every active partition, 200 once complete, 404 if `name` is not configured
+*/
+func NewAdminHandler[T StateStore](es *EventSource[T], config AdminConfig[T]) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/livez", allowMethod(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		writeProbe(w, es.State() == Healthy)
+	}))
+	mux.HandleFunc("/readyz", allowMethod(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		writeProbe(w, es.AdminStatus().Ready)
+	}))
+	mux.HandleFunc("/status", allowMethod(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, es.AdminStatus())
+	}))
+	mux.HandleFunc("/assignments", allowMethod(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, es.consumer.partitionStatus())
+	}))
+	mux.HandleFunc("/rebalancer", allowMethod(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		meta := es.consumer.rebalancerMeta()
+		if meta == nil {
+			http.Error(w, "IncrementalRebalancer not in use", http.StatusNotFound)
+			return
+		}
+		writeJson(w, meta)
+	}))
+	mux.HandleFunc("/eos", allowMethod(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, es.consumer.producerPool.status())
+	}))
+	mux.HandleFunc("/stop", allowMethod(http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+		log.Infof("stop requested via admin handler for group: %s", es.source.GroupId())
+		es.Stop()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	mux.HandleFunc("/interjections/", allowMethod(http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/interjections/")
+		interjector, ok := config.Interjections[name]
+		if !ok {
+			http.Error(w, "unknown interjection: "+name, http.StatusNotFound)
+			return
+		}
+		log.Infof("interjection %s requested via admin handler for group: %s", name, es.source.GroupId())
+		es.InterjectAllSync(interjector)
+		w.WriteHeader(http.StatusOK)
+	}))
+	return mux
+}
+
+// responds 405 to requests with any method other than `method`
+func allowMethod(method string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// Returns the current AdminStatus of the EventSource. See [NewAdminHandler].
+func (es *EventSource[T]) AdminStatus() AdminStatus {
+	status := AdminStatus{
+		GroupId:    es.source.GroupId(),
+		Topic:      es.source.Topic(),
+		Healthy:    es.State() == Healthy,
+		Partitions: es.consumer.partitionStatus(),
+		Rebalancer: es.consumer.rebalancerMeta(),
+		Eos:        es.consumer.producerPool.status(),
+	}
+	status.Ready = status.Healthy
+	for _, ps := range status.Partitions {
+		status.Ready = status.Ready && ps.Active
+	}
+	return status
+}
+
+func (sc *eventSourceConsumer[T]) partitionStatus() []PartitionStatus {
+	sc.workerMux.Lock()
+	statuses := make([]PartitionStatus, 0, len(sc.workers))
+	for _, worker := range sc.workers {
+		statuses = append(statuses, worker.status())
+	}
+	sc.workerMux.Unlock()
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].TopicPartition.Partition < statuses[j].TopicPartition.Partition
+	})
+	return statuses
+}
+
+func (sc *eventSourceConsumer[T]) rebalancerMeta() *IncrGroupMemberMeta {
+	if ir, ok := sc.incrBalancer.(*incrementalRebalancer); ok {
+		meta := ir.memberMeta()
+		return &meta
+	}
+	return nil
+}
+
+func (pw *partitionWorker[T]) status() PartitionStatus {
+	return PartitionStatus{
+		TopicPartition:  pw.topicPartition,
+		Active:          pw.canInterject(),
+		FetchedBatches:  len(pw.partitionInput),
+		QueuedEvents:    len(pw.eventInput),
+		QueuedAsyncJobs: len(pw.asyncCompleter.asyncJobs),
+		PendingEvents:   len(pw.maxPending),
+	}
+}
+
+func (pp *eosProducerPool[T]) status() EosPoolStatus {
+	return EosPoolStatus{
+		PoolSize:            pp.cfg.PoolSize,
+		TargetBatchSize:     pp.cfg.TargetBatchSize,
+		MaxBatchSize:        pp.cfg.MaxBatchSize,
+		AvailableProducers:  len(pp.producerNodeQueue),
+		CommittingProducers: len(pp.commitQueue),
+		BufferedEvents:      len(pp.buffer),
+		PendingEvents:       int(atomic.LoadInt64(&pp.pending)),
+	}
+}
+
+func writeProbe(w http.ResponseWriter, ok bool) {
+	if ok {
+		w.Write([]byte("ok"))
+		return
+	}
+	http.Error(w, "unavailable", http.StatusServiceUnavailable)
+}
+
+func writeJson(w http.ResponseWriter, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
@@ -0,0 +1,111 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams/sak"
+)
+
+func adminRequest(handler http.Handler, method, path string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+	return recorder
+}
+
+func TestAdminHandler(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+		return
+	}
+	cfg := testTopicConfig()
+	es := sak.Must(NewEventSource(cfg, NewIntStore, defaultTestHandler))
+	defer DeleteSource(cfg)
+	var interjected int64
+	handler := NewAdminHandler(es, AdminConfig[intStore]{
+		Interjections: map[string]Interjector[intStore]{
+			"count": func(ec *EventContext[intStore], _ time.Time) ExecutionState {
+				atomic.AddInt64(&interjected, 1)
+				return Complete
+			},
+		},
+	})
+
+	if code := adminRequest(handler, http.MethodGet, "/livez").Code; code != http.StatusOK {
+		t.Errorf("incorrect liveness status. actual: %d, expected: %d", code, http.StatusOK)
+	}
+	es.ConsumeEvents()
+	defer es.StopNow()
+
+	deadline := time.Now().Add(defaultTestTimeout)
+	for adminRequest(handler, http.MethodGet, "/readyz").Code != http.StatusOK || len(es.AdminStatus().Partitions) != cfg.NumPartitions {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for readiness, status: %+v", es.AdminStatus())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	var assignments []PartitionStatus
+	response := adminRequest(handler, http.MethodGet, "/assignments")
+	if err := json.Unmarshal(response.Body.Bytes(), &assignments); err != nil {
+		t.Fatal(err)
+	}
+	if len(assignments) != cfg.NumPartitions || assignments[0].TopicPartition != ntp(0, cfg.Topic) || !assignments[0].Active {
+		t.Errorf("incorrect assignments: %+v", assignments)
+	}
+
+	var eos EosPoolStatus
+	if err := json.Unmarshal(adminRequest(handler, http.MethodGet, "/eos").Body.Bytes(), &eos); err != nil {
+		t.Fatal(err)
+	}
+	if eos.PoolSize != DefaultEosConfig.PoolSize || eos.PendingEvents != 0 {
+		t.Errorf("incorrect eos status: %+v", eos)
+	}
+
+	var meta IncrGroupMemberMeta
+	response = adminRequest(handler, http.MethodGet, "/rebalancer")
+	if response.Code != http.StatusOK {
+		t.Errorf("incorrect rebalancer status. actual: %d, expected: %d", response.Code, http.StatusOK)
+	} else if err := json.Unmarshal(response.Body.Bytes(), &meta); err != nil || meta.Status != ActiveMember {
+		t.Errorf("incorrect rebalancer meta: %+v, %v", meta, err)
+	}
+
+	if code := adminRequest(handler, http.MethodPost, "/interjections/unknown").Code; code != http.StatusNotFound {
+		t.Errorf("incorrect status for unknown interjection. actual: %d, expected: %d", code, http.StatusNotFound)
+	}
+	if code := adminRequest(handler, http.MethodGet, "/interjections/count").Code; code != http.StatusMethodNotAllowed {
+		t.Errorf("incorrect status for GET interjection. actual: %d, expected: %d", code, http.StatusMethodNotAllowed)
+	}
+	if code := adminRequest(handler, http.MethodPost, "/interjections/count").Code; code != http.StatusOK {
+		t.Errorf("incorrect interjection status. actual: %d, expected: %d", code, http.StatusOK)
+	}
+	if count := atomic.LoadInt64(&interjected); count != int64(cfg.NumPartitions) {
+		t.Errorf("incorrect interjection count. actual: %d, expected: %d", count, cfg.NumPartitions)
+	}
+
+	if code := adminRequest(handler, http.MethodPost, "/stop").Code; code != http.StatusAccepted {
+		t.Errorf("incorrect stop status. actual: %d, expected: %d", code, http.StatusAccepted)
+	}
+	select {
+	case <-es.Done():
+	case <-time.After(defaultTestTimeout):
+		t.Fatal("EventSource did not stop")
+	}
+}
@@ -41,9 +41,11 @@ type worker[S any, K comparable, V any] struct {
 	workQueue *asyncItemQueue[asyncJobContainer[S, K, V]]
 	processor AsyncJobProcessor[K, V]
 	depth     int64
-	ctx       context.Context
-	key       K
-	no_key    K
+	// shared by all workers of an AsyncJobScheduler
+	pendingJobs *int64
+	ctx         context.Context
+	key         K
+	no_key      K
 }
 
 func (w *worker[S, K, V]) reset() {
@@ -58,11 +60,13 @@ func (w *worker[S, K, V]) tryAddItem(item asyncJobContainer[S, K, V]) bool {
 	return false
 }
 
-func (w *worker[S, K, V]) blockingAddItem(item asyncJobContainer[S, K, V]) {
+func (w *worker[S, K, V]) blockingAddItem(item asyncJobContainer[S, K, V]) bool {
 	select {
 	case w.workQueue.enqueueChannel() <- item:
 		atomic.AddInt64(&w.depth, 1)
+		return true
 	case <-w.ctx.Done():
+		return false
 	}
 }
 
@@ -87,6 +91,7 @@ func (w *worker[S, K, V]) process() {
 	}
 	item.err = w.processor(item.key, item.value)
 	w.advance()
+	atomic.AddInt64(w.pendingJobs, -1)
 	item.eventContext.AsyncJobComplete(item.invokeFinalizer)
 }
 
@@ -111,6 +116,7 @@ type AsyncJobScheduler[S StateStore, K comparable, V any] struct {
 	workerMap         map[K]*worker[S, K, V]
 	workerChannel     chan *worker[S, K, V] // functions as a blocking queue
 	workerQueueDepth  int64
+	pendingJobs       int64
 	maxConcurrentKeys int
 	mux               sync.Mutex
 	updateRWLock      sync.RWMutex
@@ -220,10 +226,11 @@ func (ap *AsyncJobScheduler[S, K, V]) queueDepth() int64 {
 func (ap *AsyncJobScheduler[S, K, V]) newQueue() interface{} {
 	qd := int(ap.queueDepth())
 	return &worker[S, K, V]{
-		capacity:  qd,
-		workQueue: newAsyncItemQueue[asyncJobContainer[S, K, V]](qd),
-		processor: ap.processor,
-		ctx:       ap.runStatus.Ctx(),
+		capacity:    qd,
+		workQueue:   newAsyncItemQueue[asyncJobContainer[S, K, V]](qd),
+		processor:   ap.processor,
+		pendingJobs: &ap.pendingJobs,
+		ctx:         ap.runStatus.Ctx(),
 	}
 }
 
@@ -268,11 +275,15 @@ func (ap *AsyncJobScheduler[S, K, V]) scheduleItem(item asyncJobContainer[S, K,
 		so tell the worker to stay alive until an item has been added
 
 	*/
+	// count the job before it is visible to the worker, so QueueDepth never goes negative
+	atomic.AddInt64(&ap.pendingJobs, 1)
 	added = w.tryAddItem(item)
 	ap.mux.Unlock()
 
 	if !added {
-		w.blockingAddItem(item)
+		if !w.blockingAddItem(item) {
+			atomic.AddInt64(&ap.pendingJobs, -1)
+		}
 	} else if created {
 		ap.enqueueWorker(w)
 	}
@@ -300,6 +311,11 @@ func (ap *AsyncJobScheduler[S, K, V]) work() {
 	}
 }
 
+// The number of scheduled jobs which have not yet been processed, across all keys.
+func (ap *AsyncJobScheduler[S, K, V]) QueueDepth() int {
+	return int(atomic.LoadInt64(&ap.pendingJobs))
+}
+
 func (ap *AsyncJobScheduler[S, K, V]) SetWorkerQueueDepth(size int) {
 	atomic.StoreInt64(&ap.workerQueueDepth, int64(size))
 }
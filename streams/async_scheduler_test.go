@@ -85,6 +85,53 @@ func TestAsyncJobSchedulerOrdering(t *testing.T) {
 
 }
 
+func TestAsyncJobSchedulerQueueDepth(t *testing.T) {
+	runStatus := sak.NewRunStatus(context.Background())
+	defer runStatus.Halt()
+	done := make(chan struct{}, 100)
+	release := make(chan struct{})
+
+	scheduler, err := NewAsyncJobScheduler(runStatus, func(int, int) error {
+		<-release
+		return nil
+	}, func(ec *EventContext[intStore], key int, value int, err error) ExecutionState {
+		return Complete
+	}, WideNetworkConfig)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	testSize := 10
+	store := NewIntStore(TopicPartition{})
+	for i := 0; i < testSize; i++ {
+		event := MockEventContext[intStore](runStatus.Ctx(), NewRecord(), "", store, mockAsyncCompleter{
+			done:          done,
+			expectedState: Complete,
+			t:             t,
+		}, nil)
+		scheduler.Schedule(event, i%2, i)
+	}
+	if depth := scheduler.QueueDepth(); depth != testSize {
+		t.Errorf("incorrect queue depth. actual: %d, expected: %d", depth, testSize)
+	}
+	close(release)
+
+	timer := time.NewTimer(defaultTestTimeout)
+	defer timer.Stop()
+	for i := 0; i < testSize; i++ {
+		select {
+		case <-done:
+		case <-timer.C:
+			t.Fatalf("execution timed out")
+		}
+	}
+	if depth := scheduler.QueueDepth(); depth != 0 {
+		t.Errorf("incorrect queue depth. actual: %d, expected: %d", depth, 0)
+	}
+}
+
 // We need to avoid deadlocks between the async process and the event source.
 // An async processor should never accept more items than eosProducerPool.maxPendingItems(),
 // otherwise we will deadlock as the async process signals the partionWorker that processing is complete
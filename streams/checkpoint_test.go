@@ -0,0 +1,127 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams"
+	"github.com/aws/go-kafka-event-source/streams/sak"
+	"github.com/aws/go-kafka-event-source/streams/stores"
+	"github.com/google/uuid"
+)
+
+type diskCounterStore = *checkpointTracker
+
+// records whether the store was resumed from a checkpoint
+type checkpointTracker struct {
+	*stores.DiskStore[counter]
+	resumed *atomic.Bool
+}
+
+func (ct *checkpointTracker) ReadCheckpoint() (int64, bool) {
+	offset, ok := ct.DiskStore.ReadCheckpoint()
+	if ok {
+		ct.resumed.Store(true)
+	}
+	return offset, ok
+}
+
+func runDiskCounter(t *testing.T, cluster streams.Cluster, topic string, dir string, resumed *atomic.Bool, counted chan counter) *streams.EventSource[diskCounterStore] {
+	factory := stores.NewJsonDiskStoreFactory[counter](dir)
+	es, err := streams.NewEventSource(streams.EventSourceConfig{
+		GroupId:       topic + "_group",
+		Topic:         topic,
+		NumPartitions: 1,
+		SourceCluster: cluster,
+	}, func(tp streams.TopicPartition) diskCounterStore {
+		return &checkpointTracker{factory(tp), resumed}
+	}, func(ec *streams.EventContext[diskCounterStore], _ streams.IncomingRecord) streams.ExecutionState {
+		return streams.Complete
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	streams.RegisterEventType(es, func(ir streams.IncomingRecord) (string, error) {
+		return string(ir.Key()), nil
+	}, func(ec *streams.EventContext[diskCounterStore], id string) streams.ExecutionState {
+		c, _, _ := ec.Store().Get(id)
+		c.Id = id
+		c.Count++
+		ec.RecordChange(sak.Must(ec.Store().Put(c)))
+		counted <- c
+		return streams.Complete
+	}, "increment")
+	es.ConsumeEvents()
+	return es
+}
+
+func awaitCount(t *testing.T, counted chan counter, expected int) {
+	timer := time.NewTimer(30 * time.Second)
+	defer timer.Stop()
+	for {
+		select {
+		case c := <-counted:
+			if c.Count == expected {
+				return
+			}
+		case <-timer.C:
+			t.Fatalf("timed out waiting for count: %d", expected)
+		}
+	}
+}
+
+func TestDiskStoreResumesFromCheckpoint(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+		return
+	}
+	cluster := streams.SharedTestCluster()
+	topic := uuid.NewString()
+
+	dir := t.TempDir()
+	counted := make(chan counter, 10)
+	resumed := new(atomic.Bool)
+	es := runDiskCounter(t, cluster, topic, dir, resumed, counted)
+	producer := streams.NewProducer(es.Source().AsDestination())
+	defer producer.Close()
+	increment := func() {
+		if err := producer.Produce(context.Background(), streams.NewRecord().
+			WithRecordType("increment").
+			WithKeyString("a")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	increment()
+	increment()
+	awaitCount(t, counted, 2)
+	es.Stop()
+	<-es.Done()
+	if resumed.Load() {
+		t.Errorf("first assignment should not resume from a checkpoint")
+	}
+
+	es = runDiskCounter(t, cluster, topic, dir, resumed, counted)
+	defer es.StopNow()
+	increment()
+	awaitCount(t, counted, 3)
+	if !resumed.Load() {
+		t.Errorf("second assignment should resume from a checkpoint")
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/aws/go-kafka-event-source/streams"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+)
+
+type saslConfig struct {
+	// One of PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512.
+	Mechanism string
+	User      string
+	Password  string
+}
+
+// A streams.Cluster read from a config file.
+type clusterConfig struct {
+	SeedBrokers []string
+	TLS         bool
+	SASL        *saslConfig
+}
+
+func (cc clusterConfig) Config() ([]kgo.Opt, error) {
+	if len(cc.SeedBrokers) == 0 {
+		return nil, fmt.Errorf("no SeedBrokers configured")
+	}
+	opts := []kgo.Opt{kgo.SeedBrokers(cc.SeedBrokers...)}
+	if cc.TLS {
+		opts = append(opts, kgo.DialTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12}))
+	}
+	if cc.SASL != nil {
+		switch cc.SASL.Mechanism {
+		case "PLAIN":
+			opts = append(opts, kgo.SASL(plain.Auth{User: cc.SASL.User, Pass: cc.SASL.Password}.AsMechanism()))
+		case "SCRAM-SHA-256":
+			opts = append(opts, kgo.SASL(scram.Auth{User: cc.SASL.User, Pass: cc.SASL.Password}.AsSha256Mechanism()))
+		case "SCRAM-SHA-512":
+			opts = append(opts, kgo.SASL(scram.Auth{User: cc.SASL.User, Pass: cc.SASL.Password}.AsSha512Mechanism()))
+		default:
+			return nil, fmt.Errorf("unsupported SASL mechanism: %s", cc.SASL.Mechanism)
+		}
+	}
+	return opts, nil
+}
+
+/*
+The config file passed via -config. The top level cluster is the SourceCluster of the EventSource.
+StateCluster need only be set if the EventSource uses a separate StateCluster, as that is where the commit log lives.
+
+	{
+		"SeedBrokers": ["broker-1:9096", "broker-2:9096"],
+		"TLS": true,
+		"SASL": {"Mechanism": "SCRAM-SHA-512", "User": "gkes", "Password": "secret"}
+	}
+*/
+type configFile struct {
+	clusterConfig
+	StateCluster *clusterConfig
+}
+
+func readConfigFile(path string) (configFile, error) {
+	var cfg configFile
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err = json.Unmarshal(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Returns an EventSourceConfig suitable for the commit log functions of the streams package.
+func (cfg configFile) eventSourceConfig(groupId, topic string) streams.EventSourceConfig {
+	config := streams.EventSourceConfig{
+		GroupId:       groupId,
+		Topic:         topic,
+		SourceCluster: cfg.clusterConfig,
+	}
+	if cfg.StateCluster != nil {
+		config.StateCluster = *cfg.StateCluster
+	}
+	return config
+}
@@ -0,0 +1,308 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Command gkes inspects and edits the commit log topic of a GKES consumer group. The commit log is encoded in a format
+that standard Kafka tooling can not read, so gkes is the supported way to view or modify it.
+
+	gkes <command> -config <file> -group <groupId> [flags]
+
+The following commands are supported:
+
+	dump        prints every record of the commit log: commit log partition, offset and timestamp, followed by the source topic, partition and offset
+	watermarks  prints the latest committed offset of each source partition
+	reset       rewinds one or all partitions of -topic to -offset, -timestamp (RFC3339) or the start of the topic,
+	            optionally deleting every key in the matching state store partitions with -truncate-state
+	export      copies the commit log offsets to the standard Kafka consumer group offsets of -group
+	import      copies the standard Kafka consumer group offsets of -group to the commit log
+	migrate     rewrites legacy commit log records as streams.CommitLogFormatV1 (see streams.MigrateCommitLog)
+
+All offsets are the next offset to be consumed. reset, import and migrate modify the commit log, which is only read when a partition is assigned,
+so they fail if the consumer group has any active members (see streams.ResetSource). reset and import write records in the format given by -format,
+legacy or v1. If -format is not set, the format of the most recent commit log record is used, or legacy if the commit log is empty.
+The format of the -config file is:
+
+	{
+		"SeedBrokers": ["broker-1:9096", "broker-2:9096"],
+		"TLS": true,
+		"SASL": {"Mechanism": "SCRAM-SHA-512", "User": "gkes", "Password": "secret"},
+		"StateCluster": {"SeedBrokers": ["state-broker-1:9092"]}
+	}
+
+SASL and StateCluster are optional. StateCluster is only required if the EventSource uses a separate EventSourceConfig.StateCluster.
+*/
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams"
+	"github.com/aws/go-kafka-event-source/streams/sak"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+const usage = `usage: gkes <command> -config <file> -group <groupId> [flags]
+
+commands:
+  dump        print every record of the commit log
+  watermarks  print the latest committed offset of each partition
+  reset       rewind -topic to -offset, -timestamp or the start of the topic
+  export      copy commit log offsets to the consumer group offsets
+  import      copy consumer group offsets to the commit log
+  migrate     rewrite legacy commit log records in the v1 format
+`
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if err != errUsage {
+			fmt.Fprintln(os.Stderr, "gkes:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+type command struct {
+	flags         *flag.FlagSet
+	config        *string
+	group         *string
+	topic         *string
+	partition     *int
+	offset        *int64
+	timestamp     *string
+	truncateState *bool
+	stateTopic    *string
+	format        *string
+}
+
+func newCommand(name string, stderr io.Writer) *command {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return &command{
+		flags:         fs,
+		config:        fs.String("config", "", "path to the cluster config file (required)"),
+		group:         fs.String("group", "", "the GroupId of the EventSource (required)"),
+		topic:         fs.String("topic", "", "the source topic to reset (reset only)"),
+		partition:     fs.Int("partition", -1, "the partition to reset, all partitions of -topic if negative (reset only)"),
+		offset:        fs.Int64("offset", -1, "the next offset to consume (reset only)"),
+		timestamp:     fs.String("timestamp", "", "reset to the first record at or after this RFC3339 time (reset only)"),
+		truncateState: fs.Bool("truncate-state", false, "delete every key in the state store partitions being reset (reset only)"),
+		stateTopic:    fs.String("state-topic", "", "the EventSourceConfig.StateStoreTopic, if not the default (reset only)"),
+		format:        fs.String("format", "", "the EventSourceConfig.CommitLogFormat of the group, legacy or v1, detected from the commit log if not set (reset and import only)"),
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return errUsage
+	}
+	cmd := newCommand(args[0], stderr)
+	if err := cmd.flags.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+	if len(*cmd.config) == 0 || len(*cmd.group) == 0 {
+		fmt.Fprintln(stderr, "-config and -group are required")
+		return errUsage
+	}
+	cfg, err := readConfigFile(*cmd.config)
+	if err != nil {
+		return err
+	}
+	config := cfg.eventSourceConfig(*cmd.group, *cmd.topic)
+	config.StateStoreTopic = *cmd.stateTopic
+	switch *cmd.format {
+	case "":
+		if args[0] == "reset" || args[0] == "import" {
+			if config.CommitLogFormat, err = detectFormat(ctx, config); err != nil {
+				return err
+			}
+			fmt.Fprintf(stderr, "writing %s commit log records\n", formatName(config.CommitLogFormat))
+		}
+	case "legacy":
+		config.CommitLogFormat = streams.CommitLogFormatLegacy
+	case "v1":
+		config.CommitLogFormat = streams.CommitLogFormatV1
+	default:
+		fmt.Fprintf(stderr, "unknown -format: %s\n", *cmd.format)
+		return errUsage
+	}
+	switch args[0] {
+	case "dump":
+		return dump(ctx, config, stdout)
+	case "watermarks":
+		offsets, err := streams.CommitLogOffsets(ctx, config)
+		if err != nil {
+			return err
+		}
+		return printOffsets(stdout, offsets)
+	case "reset":
+		return reset(ctx, cmd, config, stdout, stderr)
+	case "export":
+		offsets, err := streams.CommitLogOffsets(ctx, config)
+		if err != nil {
+			return err
+		}
+		if err = streams.CommitGroupOffsets(ctx, config, offsets); err != nil {
+			return err
+		}
+		return printOffsets(stdout, offsets)
+	case "migrate":
+		migrated, err := streams.MigrateCommitLog(ctx, config)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "migrated %d partitions\n", migrated)
+		return nil
+	case "import":
+		offsets, err := streams.GroupOffsets(ctx, config)
+		if err != nil {
+			return err
+		}
+		if offsets, err = streams.ResetSource(ctx, config, streams.ResetConfig{Offsets: offsets}); err != nil {
+			return err
+		}
+		return printOffsets(stdout, offsets)
+	}
+	fmt.Fprintf(stderr, "unknown command: %s\n%s", args[0], usage)
+	return errUsage
+}
+
+func dump(ctx context.Context, config streams.EventSourceConfig, stdout io.Writer) error {
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "LOG PARTITION\tLOG OFFSET\tTIMESTAMP\tFORMAT\tTOPIC\tPARTITION\tOFFSET\tLEADER EPOCH")
+	err := streams.ReadCommitLog(ctx, config, func(entry streams.CommitLogEntry) {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%d\t%d\t%d\n", entry.CommitLogPartition, entry.CommitLogOffset,
+			entry.Timestamp.Format(time.RFC3339Nano), formatName(entry.Format), entry.TopicPartition.Topic, entry.TopicPartition.Partition, entry.Offset, entry.LeaderEpoch)
+	})
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func formatName(format streams.CommitLogFormat) string {
+	if format == streams.CommitLogFormatV1 {
+		return "v1"
+	}
+	return "legacy"
+}
+
+// Returns the format of the most recent commit log record, so that records we write are readable by the running version of the EventSource.
+// An empty commit log defaults to legacy, as does EventSourceConfig.CommitLogFormat.
+func detectFormat(ctx context.Context, config streams.EventSourceConfig) (streams.CommitLogFormat, error) {
+	format := streams.CommitLogFormatLegacy
+	var latest time.Time
+	err := streams.ReadCommitLog(ctx, config, func(entry streams.CommitLogEntry) {
+		// record timestamps have millisecond precision, so prefer v1 when they are equal
+		if entry.Timestamp.After(latest) || (entry.Timestamp.Equal(latest) && entry.Format == streams.CommitLogFormatV1) {
+			latest = entry.Timestamp
+			format = entry.Format
+		}
+	})
+	return format, err
+}
+
+func reset(ctx context.Context, cmd *command, config streams.EventSourceConfig, stdout, stderr io.Writer) error {
+	if len(config.Topic) == 0 || (*cmd.offset >= 0 && len(*cmd.timestamp) > 0) {
+		fmt.Fprintln(stderr, "reset requires -topic and at most one of -offset or -timestamp")
+		return errUsage
+	}
+	var partitions []int32
+	if *cmd.partition >= 0 {
+		partitions = []int32{int32(*cmd.partition)}
+	}
+	offsets, err := resetOffsets(ctx, cmd, config, partitions)
+	if err != nil {
+		return err
+	}
+	if offsets, err = streams.ResetSource(ctx, config, streams.ResetConfig{Offsets: offsets, TruncateStateStore: *cmd.truncateState}); err != nil {
+		return err
+	}
+	return printOffsets(stdout, offsets)
+}
+
+// Returns the offsets to reset each of `partitions` of config.Topic to, or every partition if `partitions` is empty:
+// -offset, the first offset at or after -timestamp, or the start of the partition.
+func resetOffsets(ctx context.Context, cmd *command, config streams.EventSourceConfig, partitions []int32) (map[streams.TopicPartition]int64, error) {
+	var listed map[streams.TopicPartition]int64
+	if len(*cmd.timestamp) > 0 {
+		t, err := time.Parse(time.RFC3339, *cmd.timestamp)
+		if err != nil {
+			return nil, err
+		}
+		if listed, err = streams.OffsetsForTime(ctx, config, t); err != nil {
+			return nil, err
+		}
+	} else {
+		// also resolves the partitions of config.Topic when -offset is used
+		client, err := streams.NewClient(config.SourceCluster)
+		if err != nil {
+			return nil, err
+		}
+		defer client.Close()
+		starts, err := kadm.NewClient(client).ListStartOffsets(ctx, config.Topic)
+		if err != nil {
+			return nil, err
+		}
+		if err = starts.Error(); err != nil {
+			return nil, err
+		}
+		listed = make(map[streams.TopicPartition]int64)
+		starts.Each(func(lo kadm.ListedOffset) {
+			listed[streams.TopicPartition{Topic: lo.Topic, Partition: lo.Partition}] = lo.Offset
+		})
+	}
+	offsets := make(map[streams.TopicPartition]int64)
+	for tp, offset := range listed {
+		if len(partitions) == 0 || sak.Contains(partitions, tp.Partition) {
+			if *cmd.offset >= 0 {
+				offset = *cmd.offset
+			}
+			offsets[tp] = offset
+		}
+	}
+	if len(offsets) == 0 {
+		return nil, fmt.Errorf("partitions %v not found for topic %s", partitions, config.Topic)
+	}
+	return offsets, nil
+}
+
+func printOffsets(stdout io.Writer, offsets map[streams.TopicPartition]int64) error {
+	tps := make([]streams.TopicPartition, 0, len(offsets))
+	for tp := range offsets {
+		tps = append(tps, tp)
+	}
+	sort.Slice(tps, func(i, j int) bool {
+		if tps[i].Topic == tps[j].Topic {
+			return tps[i].Partition < tps[j].Partition
+		}
+		return tps[i].Topic < tps[j].Topic
+	})
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TOPIC\tPARTITION\tOFFSET")
+	for _, tp := range tps {
+		fmt.Fprintf(w, "%s\t%d\t%d\n", tp.Topic, tp.Partition, offsets[tp])
+	}
+	return w.Flush()
+}
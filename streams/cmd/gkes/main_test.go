@@ -0,0 +1,117 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/go-kafka-event-source/streams"
+	"github.com/aws/go-kafka-event-source/streams/internal/testbroker"
+)
+
+func TestClusterConfig(t *testing.T) {
+	if _, err := (clusterConfig{}).Config(); err == nil {
+		t.Errorf("expected error for missing SeedBrokers")
+	}
+	cc := clusterConfig{SeedBrokers: []string{"127.0.0.1:9092"}, TLS: true, SASL: &saslConfig{Mechanism: "SCRAM-SHA-512"}}
+	if opts, err := cc.Config(); err != nil || len(opts) != 3 {
+		t.Errorf("incorrect options: %v, %v", opts, err)
+	}
+	cc.SASL.Mechanism = "GSSAPI"
+	if _, err := cc.Config(); err == nil {
+		t.Errorf("expected error for unsupported SASL mechanism")
+	}
+}
+
+func TestRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+		return
+	}
+	broker, err := testbroker.Start("../../streamstest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer broker.Close()
+	cluster := streams.SimpleCluster(broker.Addrs())
+	configPath := filepath.Join(t.TempDir(), "cluster.json")
+	b, _ := json.Marshal(configFile{clusterConfig: clusterConfig{SeedBrokers: broker.Addrs()}})
+	if err = os.WriteFile(configPath, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = streams.CreateSource(streams.EventSourceConfig{
+		GroupId:           "gkes_cli_group",
+		Topic:             "gkes_cli",
+		NumPartitions:     4,
+		ReplicationFactor: 1,
+		MinInSync:         1,
+		SourceCluster:     cluster,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	gkes := func(args ...string) (string, error) {
+		var stdout, stderr bytes.Buffer
+		err := run(context.Background(), append(args, "-config", configPath, "-group", "gkes_cli_group"), &stdout, &stderr)
+		return stdout.String() + stderr.String(), err
+	}
+	if _, err = gkes("reset", "-topic", "gkes_cli", "-offset", "1", "-timestamp", "2022-01-01T00:00:00Z"); err != errUsage {
+		t.Errorf("expected usage error for reset with -offset and -timestamp: %v", err)
+	}
+	if out, err := gkes("reset", "-topic", "gkes_cli", "-offset", "5"); err != nil || strings.Count(out, "gkes_cli") != 4 {
+		t.Errorf("incorrect reset output: %s, %v", out, err)
+	}
+	if out, err := gkes("reset", "-topic", "gkes_cli", "-partition", "2", "-timestamp", "2022-01-01T00:00:00Z"); err != nil || !strings.Contains(out, "gkes_cli  2          0") {
+		t.Errorf("incorrect reset output: %s, %v", out, err)
+	}
+	if out, err := gkes("reset", "-topic", "gkes_cli", "-partition", "3"); err != nil || !strings.Contains(out, "gkes_cli  3          0") {
+		t.Errorf("incorrect reset output: %s, %v", out, err)
+	}
+	out, err := gkes("watermarks")
+	if err != nil || !strings.Contains(out, "gkes_cli  1          5") || !strings.Contains(out, "gkes_cli  2          0") {
+		t.Errorf("incorrect watermarks output: %s, %v", out, err)
+	}
+	if out, err := gkes("dump"); err != nil || strings.Count(out, "\n") != 7 {
+		t.Errorf("incorrect dump output: %s, %v", out, err)
+	}
+	if out, err := gkes("export"); err != nil || strings.Count(out, "gkes_cli") != 4 {
+		t.Errorf("incorrect export output: %s, %v", out, err)
+	}
+	if out, err := gkes("import"); err != nil || !strings.Contains(out, "gkes_cli  1          5") {
+		t.Errorf("incorrect import output: %s, %v", out, err)
+	}
+	if out, err := gkes("migrate"); err != nil || !strings.Contains(out, "migrated 4 partitions") {
+		t.Errorf("incorrect migrate output: %s, %v", out, err)
+	}
+	if out, err := gkes("dump"); err != nil || strings.Count(out, " v1 ") != 4 {
+		t.Errorf("incorrect dump output after migration: %s, %v", out, err)
+	}
+	// the format of a migrated group is detected
+	if out, err := gkes("reset", "-topic", "gkes_cli", "-partition", "0", "-offset", "7"); err != nil || !strings.Contains(out, "writing v1 commit log records") {
+		t.Errorf("incorrect reset output after migration: %s, %v", out, err)
+	}
+	if out, err := gkes("dump"); err != nil || strings.Count(out, " v1 ") != 5 {
+		t.Errorf("incorrect dump output after reset: %s, %v", out, err)
+	}
+	if _, err = gkes("unknown"); err != errUsage {
+		t.Errorf("expected usage error for unknown command: %v", err)
+	}
+}
@@ -16,7 +16,10 @@ package streams
 
 import (
 	"bytes"
+	"encoding/binary"
+	"fmt"
 	"sync"
+	"time"
 	"unsafe"
 
 	"github.com/aws/go-kafka-event-source/streams/sak"
@@ -25,16 +28,49 @@ import (
 
 type eosCommitLog struct {
 	pendingSyncs  map[string]*sync.WaitGroup
-	watermarks    map[int32]int64
+	watermarks    map[TopicPartition]int64
 	mux           sync.Mutex
 	syncMux       sync.Mutex
 	numPartitions int32
 	topic         string
+	format        CommitLogFormat
 	changeLog     GlobalChangeLog[*eosCommitLog]
 }
 
+/*
+The encoding of commit log records, see EventSourceConfig.CommitLogFormat.
+
+CommitLogFormatLegacy records are written in host byte order, so a commit log can not be shared by hosts with different architectures,
+and carry only the offset. CommitLogFormatV1 records are big-endian and versioned:
+
+	key:   version (1 byte) | partition (int32) | topic
+	value: version (1 byte) | offset (int64) | leader epoch (int32) | commit time in unix milliseconds (int64)
+
+Both formats are always readable, but GKES versions prior to the introduction of CommitLogFormatV1 can only read legacy records.
+To upgrade, first deploy a version of your application which can read both formats, then set CommitLogFormat to CommitLogFormatV1.
+Existing legacy records may then be rewritten with [MigrateCommitLog], allowing compaction to remove them.
+*/
+type CommitLogFormat uint8
+
+const (
+	CommitLogFormatLegacy CommitLogFormat = iota
+	CommitLogFormatV1
+)
+
 const intByteSize = int(unsafe.Sizeof(uintptr(1)))
 
+const commitLogKeyV1Size = 1 + 4
+const commitLogValueV1Size = 1 + 8 + 4 + 8
+
+// A decoded commit log record.
+type commitLogRecord struct {
+	tp          TopicPartition
+	offset      int64
+	leaderEpoch int32
+	committed   time.Time
+	format      CommitLogFormat
+}
+
 func writeTopicPartitionToBytes(tp TopicPartition, b *bytes.Buffer) {
 	var arr [intByteSize]byte
 	*(*int64)(unsafe.Pointer(&arr[0])) = int64(tp.Partition)
@@ -58,12 +94,54 @@ func topicPartitionFromBytes(b []byte) (tp TopicPartition) {
 	return
 }
 
+func writeCommitLogKeyV1(tp TopicPartition, b *bytes.Buffer) {
+	var arr [commitLogKeyV1Size]byte
+	arr[0] = byte(CommitLogFormatV1)
+	binary.BigEndian.PutUint32(arr[1:], uint32(tp.Partition))
+	b.Write(arr[:])
+	b.WriteString(tp.Topic)
+}
+
+func writeCommitLogValueV1(offset int64, leaderEpoch int32, committed time.Time, b *bytes.Buffer) {
+	var arr [commitLogValueV1Size]byte
+	arr[0] = byte(CommitLogFormatV1)
+	binary.BigEndian.PutUint64(arr[1:], uint64(offset))
+	binary.BigEndian.PutUint32(arr[9:], uint32(leaderEpoch))
+	binary.BigEndian.PutUint64(arr[13:], uint64(committed.UnixMilli()))
+	b.Write(arr[:])
+}
+
+// Decodes a commit log record of either format. Legacy values are exactly intByteSize bytes, versioned values are always longer
+// and begin with their version, which also determines the encoding of the key.
+func decodeCommitLogRecord(key, value []byte) (clr commitLogRecord, err error) {
+	if len(value) == intByteSize && len(key) >= intByteSize {
+		clr.tp = topicPartitionFromBytes(key)
+		clr.offset = readIntegerFromByteArray[int64](value)
+		clr.leaderEpoch = -1
+		return
+	}
+	if len(value) == 0 || CommitLogFormat(value[0]) != CommitLogFormatV1 {
+		return clr, fmt.Errorf("unsupported commit log record, key: %x, value: %x", key, value)
+	}
+	if len(value) < commitLogValueV1Size || len(key) < commitLogKeyV1Size || CommitLogFormat(key[0]) != CommitLogFormatV1 {
+		return clr, fmt.Errorf("malformed commit log record, key: %x, value: %x", key, value)
+	}
+	clr.format = CommitLogFormatV1
+	clr.tp.Partition = int32(binary.BigEndian.Uint32(key[1:]))
+	clr.tp.Topic = string(key[commitLogKeyV1Size:])
+	clr.offset = int64(binary.BigEndian.Uint64(value[1:]))
+	clr.leaderEpoch = int32(binary.BigEndian.Uint32(value[9:]))
+	clr.committed = time.UnixMilli(int64(binary.BigEndian.Uint64(value[13:])))
+	return
+}
+
 func newEosCommitLog(runStatus sak.RunStatus, source *Source, numPartitions int) *eosCommitLog {
 	cl := &eosCommitLog{
-		watermarks:    make(map[int32]int64),
+		watermarks:    make(map[TopicPartition]int64),
 		pendingSyncs:  make(map[string]*sync.WaitGroup),
 		numPartitions: int32(numPartitions),
 		topic:         source.CommitLogTopicNameForGroupId(),
+		format:        source.config.CommitLogFormat,
 	}
 	cl.changeLog = NewGlobalChangeLogWithRunStatus(runStatus, source.stateCluster(), cl, numPartitions, cl.topic, CompactCleanupPolicy)
 	return cl
@@ -73,22 +151,31 @@ func (cl *eosCommitLog) commitRecordPartition(tp TopicPartition) int32 {
 	return tp.Partition % cl.numPartitions
 }
 
-func (cl *eosCommitLog) commitRecord(tp TopicPartition, offset int64) *Record {
+func (cl *eosCommitLog) commitRecord(tp TopicPartition, offset int64, leaderEpoch int32) *Record {
 	record := NewRecord().WithTopic(cl.topic).WithPartition(cl.commitRecordPartition(tp))
-	writeTopicPartitionToBytes(tp, record.KeyWriter())
 	// increment so we start consuming at the next offset
-	writeSignedIntToByteArray(offset+1, record.ValueWriter())
+	if cl.format == CommitLogFormatV1 {
+		writeCommitLogKeyV1(tp, record.KeyWriter())
+		writeCommitLogValueV1(offset+1, leaderEpoch, time.Now(), record.ValueWriter())
+	} else {
+		writeTopicPartitionToBytes(tp, record.KeyWriter())
+		writeSignedIntToByteArray(offset+1, record.ValueWriter())
+	}
 	return record
 }
 
 func (cl *eosCommitLog) ReceiveChange(record IncomingRecord) error {
 	if record.isMarkerRecord() {
 		cl.closeSyncRequest(string(record.Value()))
-	} else {
-		tp := topicPartitionFromBytes(record.Key())
-		offset := readIntegerFromByteArray[int64](record.Value())
+	} else if len(record.Value()) > 0 {
+		// tombstones are only written by MigrateCommitLog, for legacy keys which have already been rewritten
+		clr, err := decodeCommitLogRecord(record.Key(), record.Value())
+		if err != nil {
+			log.Errorf("ignoring commit log record %+v offset: %d, err: %v", record.TopicPartition(), record.Offset(), err)
+			return nil
+		}
 		cl.mux.Lock()
-		cl.watermarks[tp.Partition] = offset
+		cl.watermarks[clr.tp] = clr.offset
 		cl.mux.Unlock()
 	}
 	return nil
@@ -137,7 +224,7 @@ func (cl *eosCommitLog) syncCommitLogPartition(tp TopicPartition) {
 func (cl *eosCommitLog) Watermark(tp TopicPartition) int64 {
 	cl.mux.Lock()
 	defer cl.mux.Unlock()
-	if offset, ok := cl.watermarks[tp.Partition]; ok {
+	if offset, ok := cl.watermarks[tp]; ok {
 		return offset
 	}
 	return -1
@@ -0,0 +1,335 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams/sak"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// A single record of the commit log for a consumer group. See [ReadCommitLog].
+type CommitLogEntry struct {
+	// The source TopicPartition the offset was committed for.
+	TopicPartition TopicPartition
+	// The next offset of TopicPartition to be consumed.
+	Offset int64
+	// The leader epoch of the last record processed, -1 if unknown. Only recorded by CommitLogFormatV1.
+	LeaderEpoch int32
+	// The time the offset was committed. Only recorded by CommitLogFormatV1, the record Timestamp is a close approximation for legacy records.
+	CommitTime         time.Time
+	Format             CommitLogFormat
+	CommitLogPartition int32
+	CommitLogOffset    int64
+	Timestamp          time.Time
+}
+
+// Reads the commit log for config.GroupId, from the beginning up to it's current end, invoking `fn` with each entry in offset order (per commit log partition).
+// Only config.GroupId and config.SourceCluster/StateCluster are required. Records of both CommitLogFormats are read.
+func ReadCommitLog(ctx context.Context, config EventSourceConfig, fn func(CommitLogEntry)) error {
+	source := newSource(config)
+	return readTopicToEnd(ctx, source.stateCluster(), source.CommitLogTopicNameForGroupId(), nil, func(r *kgo.Record) {
+		if len(r.Value) == 0 {
+			// a legacy record removed by MigrateCommitLog
+			return
+		}
+		clr, err := decodeCommitLogRecord(r.Key, r.Value)
+		if err != nil {
+			log.Warnf("skipping commit log record %s/%d offset: %d, err: %v", r.Topic, r.Partition, r.Offset, err)
+			return
+		}
+		fn(CommitLogEntry{
+			TopicPartition:     clr.tp,
+			Offset:             clr.offset,
+			LeaderEpoch:        clr.leaderEpoch,
+			CommitTime:         clr.committed,
+			Format:             clr.format,
+			CommitLogPartition: r.Partition,
+			CommitLogOffset:    r.Offset,
+			Timestamp:          r.Timestamp,
+		})
+	})
+}
+
+// Consumes `topic` from the beginning up to it's current end, invoking `fn` with each committed data record.
+// If `partitions` is not empty, only those partitions are consumed.
+func readTopicToEnd(ctx context.Context, cluster Cluster, topic string, partitions []int32, fn func(*kgo.Record)) error {
+	targets, err := globalTableTargets(cluster, topic)
+	if err != nil {
+		return err
+	}
+	assignments := make(map[int32]kgo.Offset)
+	for p, target := range targets {
+		if target <= 0 || (len(partitions) > 0 && !sak.Contains(partitions, p)) {
+			delete(targets, p)
+			continue
+		}
+		assignments[p] = kgo.NewOffset().AtStart()
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	consumer, err := NewClient(cluster,
+		kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{topic: assignments}),
+		kgo.FetchIsolationLevel(kgo.ReadCommitted()),
+		// control records let us know when we have reached the last stable offset of a transactionally written topic
+		kgo.KeepControlRecords())
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+	for len(targets) > 0 {
+		fetches := consumer.PollFetches(ctx)
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		for _, fetchErr := range fetches.Errors() {
+			return fetchErr.Err
+		}
+		fetches.EachRecord(func(r *kgo.Record) {
+			target, ok := targets[r.Partition]
+			if !ok || r.Offset >= target {
+				return
+			}
+			if r.Offset+1 >= target {
+				delete(targets, r.Partition)
+			}
+			if r.Attrs.IsControl() || isMarkerRecord(r) {
+				return
+			}
+			fn(r)
+		})
+	}
+	return nil
+}
+
+// Returns the latest committed offset (the next offset to be consumed) for each TopicPartition in the commit log for config.GroupId.
+func CommitLogOffsets(ctx context.Context, config EventSourceConfig) (map[TopicPartition]int64, error) {
+	offsets := make(map[TopicPartition]int64)
+	err := ReadCommitLog(ctx, config, func(entry CommitLogEntry) {
+		offsets[entry.TopicPartition] = entry.Offset
+	})
+	return offsets, err
+}
+
+/*
+Writes `offsets` to the commit log for config.GroupId, encoded with config.CommitLogFormat. Each offset is the next offset to be consumed for it's TopicPartition.
+The commit log topic must already exist. Offsets are read by a consumer when a partition is assigned, so the group should be stopped while writing,
+otherwise active members may overwrite them. [ResetSource] verifies the group has no members before writing.
+*/
+func WriteCommitLogOffsets(ctx context.Context, config EventSourceConfig, offsets map[TopicPartition]int64) error {
+	client, cl, err := commitLogWriter(ctx, newSource(config))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	commits := make([]*Record, 0, len(offsets))
+	for tp, offset := range offsets {
+		// commitRecord records the offset following the last processed offset
+		commits = append(commits, cl.commitRecord(tp, offset-1, -1))
+	}
+	return produceCommitRecords(ctx, client, commits)
+}
+
+/*
+MigrateCommitLog rewrites every legacy entry of the commit log for config.GroupId as CommitLogFormatV1, and writes a tombstone for each legacy key
+so that compaction eventually removes them. Returns the number of TopicPartitions rewritten. As with [ResetSource], the consumer group must
+have no members, and EventSourceConfig.CommitLogFormat should be set to CommitLogFormatV1 before the group is restarted.
+
+All records are written in a single transaction, so if the migration fails the commit log is left as it was. Once the error is resolved,
+MigrateCommitLog may simply be run again. Running it again after a successful migration has no effect.
+*/
+func MigrateCommitLog(ctx context.Context, config EventSourceConfig) (int, error) {
+	source := newSource(config)
+	if err := ensureGroupInactive(ctx, source); err != nil {
+		return 0, err
+	}
+	latest := make(map[TopicPartition]CommitLogEntry)
+	legacy := NewTopicPartitionSet()
+	err := ReadCommitLog(ctx, config, func(entry CommitLogEntry) {
+		latest[entry.TopicPartition] = entry
+		if entry.Format == CommitLogFormatLegacy {
+			legacy.Insert(entry.TopicPartition)
+		}
+	})
+	if err != nil || len(legacy.Items()) == 0 {
+		return 0, err
+	}
+	client, cl, err := commitLogWriter(ctx, source)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+	var commits []*Record
+	migrated := 0
+	for _, tp := range legacy.Items() {
+		partition := cl.commitRecordPartition(tp)
+		if entry := latest[tp]; entry.Format == CommitLogFormatLegacy {
+			record := NewRecord().WithTopic(cl.topic).WithPartition(partition)
+			writeCommitLogKeyV1(tp, record.KeyWriter())
+			writeCommitLogValueV1(entry.Offset, entry.LeaderEpoch, entry.Timestamp, record.ValueWriter())
+			commits = append(commits, record)
+			migrated++
+		}
+		tombstone := NewRecord().WithTopic(cl.topic).WithPartition(partition)
+		writeTopicPartitionToBytes(tp, tombstone.KeyWriter())
+		commits = append(commits, tombstone)
+	}
+	log.Infof("migrating commit log for group: %s, partitions: %d", source.GroupId(), migrated)
+	return migrated, produceCommitRecords(ctx, client, commits)
+}
+
+// Returns a client for producing to the commit log of `source`, along with an eosCommitLog for encoding commit records.
+// The transactional id is fixed for the group, so any transaction left open by a failed write is aborted by the next.
+func commitLogWriter(ctx context.Context, source *Source) (*kgo.Client, *eosCommitLog, error) {
+	topic := source.CommitLogTopicNameForGroupId()
+	client, err := NewClient(source.stateCluster(),
+		kgo.RecordPartitioner(kgo.ManualPartitioner()),
+		kgo.TransactionalID(fmt.Sprintf("gkes_commit_log_admin_%s", source.GroupId())))
+	if err != nil {
+		return nil, nil, err
+	}
+	topics, err := kadm.NewClient(client).ListTopics(ctx, topic)
+	if err != nil {
+		client.Close()
+		return nil, nil, err
+	}
+	detail, ok := topics[topic]
+	if !ok || detail.Err != nil || len(detail.Partitions) == 0 {
+		client.Close()
+		return nil, nil, fmt.Errorf("commit log topic %s not found: %v", topic, detail.Err)
+	}
+	return client, &eosCommitLog{
+		topic:         topic,
+		numPartitions: int32(len(detail.Partitions)),
+		format:        source.config.CommitLogFormat,
+	}, nil
+}
+
+// Produces `commits` in a single transaction, so that either all or none of them are read by the group.
+func produceCommitRecords(ctx context.Context, client *kgo.Client, commits []*Record) error {
+	records := make([]*kgo.Record, len(commits))
+	for i, record := range commits {
+		records[i] = record.toKafkaRecord()
+	}
+	defer func() {
+		for _, record := range commits {
+			record.Release()
+		}
+	}()
+	if err := client.BeginTransaction(); err != nil {
+		return err
+	}
+	if err := client.ProduceSync(ctx, records...).FirstErr(); err != nil {
+		if abortErr := client.EndTransaction(ctx, kgo.TryAbort); abortErr != nil {
+			log.Errorf("could not abort commit log transaction: %v", abortErr)
+		}
+		return err
+	}
+	return client.EndTransaction(ctx, kgo.TryCommit)
+}
+
+// Returns, for each partition of config.Topics and any retry topics, the offset of the first record with a timestamp at or after `t`,
+// or the end offset if there is no such record.
+func OffsetsForTime(ctx context.Context, config EventSourceConfig, t time.Time) (map[TopicPartition]int64, error) {
+	source := newSource(config)
+	client, err := NewClient(source.config.SourceCluster)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	listed, err := listOffsetsAfterMilli(ctx, kadm.NewClient(client), t.UnixMilli(), source.consumedTopics()...)
+	if err != nil {
+		return nil, err
+	}
+	offsets := make(map[TopicPartition]int64)
+	listed.Each(func(lo kadm.ListedOffset) {
+		offsets[ntp(lo.Partition, lo.Topic)] = lo.Offset
+	})
+	return offsets, nil
+}
+
+// As kadm.Client.ListOffsetsAfterMilli, but partitions with no record at or after `millis` are listed at their end offset, rather than -1.
+func listOffsetsAfterMilli(ctx context.Context, admin *kadm.Client, millis int64, topics ...string) (kadm.ListedOffsets, error) {
+	listed, err := admin.ListOffsetsAfterMilli(ctx, millis, topics...)
+	if err != nil {
+		return nil, err
+	}
+	if err = listed.Error(); err != nil {
+		return nil, err
+	}
+	var ends kadm.ListedOffsets
+	for topic, partitions := range listed {
+		for p, lo := range partitions {
+			if lo.Offset >= 0 {
+				continue
+			}
+			if ends == nil {
+				if ends, err = admin.ListEndOffsets(ctx, topics...); err != nil {
+					return nil, err
+				}
+				if err = ends.Error(); err != nil {
+					return nil, err
+				}
+			}
+			if end, ok := ends.Lookup(topic, p); ok {
+				lo.Offset = end.Offset
+				partitions[p] = lo
+			}
+		}
+	}
+	return listed, nil
+}
+
+// Returns the offsets committed to the standard Kafka consumer group offsets for config.GroupId,
+// as written when EventSourceConfig.CommitOffsets is true, or by a non-GKES consumer.
+func GroupOffsets(ctx context.Context, config EventSourceConfig) (map[TopicPartition]int64, error) {
+	client, err := NewClient(config.SourceCluster)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	fetched, err := kadm.NewClient(client).FetchOffsets(ctx, config.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	if err = fetched.Error(); err != nil {
+		return nil, err
+	}
+	offsets := make(map[TopicPartition]int64)
+	fetched.Each(func(or kadm.OffsetResponse) {
+		offsets[ntp(or.Partition, or.Topic)] = or.At
+	})
+	return offsets, nil
+}
+
+// Commits `offsets` to the standard Kafka consumer group offsets for config.GroupId, so that a non-GKES consumer may resume where an EventSource left off.
+// Kafka rejects the commit if the group has active members.
+func CommitGroupOffsets(ctx context.Context, config EventSourceConfig, offsets map[TopicPartition]int64) error {
+	client, err := NewClient(config.SourceCluster)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	toCommit := make(kadm.Offsets)
+	for tp, offset := range offsets {
+		toCommit.AddOffset(tp.Topic, tp.Partition, offset, -1)
+	}
+	return kadm.NewClient(client).CommitAllOffsets(ctx, config.GroupId, toCommit)
+}
@@ -0,0 +1,107 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/go-kafka-event-source/streams/sak"
+)
+
+func TestCommitLogAdmin(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+		return
+	}
+	cfg := testTopicConfig()
+	sak.Must(CreateSource(cfg))
+	defer DeleteSource(cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTestTimeout)
+	defer cancel()
+
+	offsets, err := CommitLogOffsets(ctx, cfg)
+	if err != nil || len(offsets) != 0 {
+		t.Fatalf("expected empty commit log: %v, %v", offsets, err)
+	}
+	expected := map[TopicPartition]int64{
+		ntp(0, cfg.Topic): 10,
+		ntp(1, cfg.Topic): 0,
+		ntp(7, cfg.Topic): 42,
+	}
+	if err = WriteCommitLogOffsets(ctx, cfg, expected); err != nil {
+		t.Fatal(err)
+	}
+	if err = WriteCommitLogOffsets(ctx, cfg, map[TopicPartition]int64{ntp(0, cfg.Topic): 12}); err != nil {
+		t.Fatal(err)
+	}
+	entries := 0
+	if err = ReadCommitLog(ctx, cfg, func(CommitLogEntry) { entries++ }); err != nil {
+		t.Fatal(err)
+	}
+	if entries != 4 {
+		t.Errorf("incorrect number of commit log entries. actual: %d, expected: %d", entries, 4)
+	}
+	expected[ntp(0, cfg.Topic)] = 12
+	if offsets, err = CommitLogOffsets(ctx, cfg); err != nil || !reflect.DeepEqual(offsets, expected) {
+		t.Errorf("incorrect commit log offsets. actual: %v, expected: %v, err: %v", offsets, expected, err)
+	}
+
+	// entries after the last legacy entry of each commit log partition were written by the migration
+	lastLegacy := make(map[int32]int64)
+	if err = ReadCommitLog(ctx, cfg, func(entry CommitLogEntry) { lastLegacy[entry.CommitLogPartition] = entry.CommitLogOffset }); err != nil {
+		t.Fatal(err)
+	}
+	migrated, err := MigrateCommitLog(ctx, cfg)
+	if err != nil || migrated != len(expected) {
+		t.Fatalf("incorrect migration. actual: %d, expected: %d, err: %v", migrated, len(expected), err)
+	}
+	v1Cfg := cfg
+	v1Cfg.CommitLogFormat = CommitLogFormatV1
+	if err = WriteCommitLogOffsets(ctx, v1Cfg, map[TopicPartition]int64{ntp(1, cfg.Topic): 3}); err != nil {
+		t.Fatal(err)
+	}
+	expected[ntp(1, cfg.Topic)] = 3
+	if offsets, err = CommitLogOffsets(ctx, cfg); err != nil || !reflect.DeepEqual(offsets, expected) {
+		t.Errorf("incorrect commit log offsets after migration. actual: %v, expected: %v, err: %v", offsets, expected, err)
+	}
+	written := 0
+	err = ReadCommitLog(ctx, cfg, func(entry CommitLogEntry) {
+		if last, ok := lastLegacy[entry.CommitLogPartition]; !ok || entry.CommitLogOffset > last {
+			written++
+			if entry.Format != CommitLogFormatV1 {
+				t.Errorf("incorrect format for migrated entry: %+v", entry)
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the migrated entries and the v1 entry written after migration
+	if written != migrated+1 {
+		t.Errorf("incorrect number of entries written after migration. actual: %d, expected: %d", written, migrated+1)
+	}
+	if migrated, err = MigrateCommitLog(ctx, cfg); err != nil || migrated != 0 {
+		t.Errorf("incorrect second migration. actual: %d, expected: %d, err: %v", migrated, 0, err)
+	}
+
+	if err = CommitGroupOffsets(ctx, cfg, expected); err != nil {
+		t.Fatal(err)
+	}
+	if offsets, err = GroupOffsets(ctx, cfg); err != nil || !reflect.DeepEqual(offsets, expected) {
+		t.Errorf("incorrect group offsets. actual: %v, expected: %v, err: %v", offsets, expected, err)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestCommitLogFormats(t *testing.T) {
+	tp := ntp(258, "topic")
+	for _, format := range []CommitLogFormat{CommitLogFormatLegacy, CommitLogFormatV1} {
+		cl := &eosCommitLog{
+			topic:         "commit_log",
+			numPartitions: 5,
+			format:        format,
+			watermarks:    make(map[TopicPartition]int64),
+		}
+		record := cl.commitRecord(tp, 41, 7)
+		kRecord := record.toKafkaRecord()
+		if kRecord.Partition != 258%5 {
+			t.Errorf("incorrect commit log partition. actual: %d, expected: %d", kRecord.Partition, 258%5)
+		}
+		clr, err := decodeCommitLogRecord(kRecord.Key, kRecord.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if clr.tp != tp || clr.offset != 42 || clr.format != format {
+			t.Errorf("incorrect commit log record: %+v", clr)
+		}
+		if format == CommitLogFormatV1 && (clr.leaderEpoch != 7 || clr.committed.IsZero()) {
+			t.Errorf("incorrect v1 metadata: %+v", clr)
+		}
+		cl.ReceiveChange(newIncomingRecord(&kgo.Record{Key: kRecord.Key, Value: kRecord.Value}))
+		if watermark := cl.Watermark(tp); watermark != 42 {
+			t.Errorf("incorrect watermark. actual: %d, expected: %d", watermark, 42)
+		}
+		// tombstones are ignored
+		cl.ReceiveChange(newIncomingRecord(&kgo.Record{Key: kRecord.Key}))
+		if watermark := cl.Watermark(tp); watermark != 42 {
+			t.Errorf("incorrect watermark after tombstone. actual: %d, expected: %d", watermark, 42)
+		}
+		record.Release()
+	}
+	if _, err := decodeCommitLogRecord([]byte{2, 0, 0, 0, 1}, make([]byte, commitLogValueV1Size)); err == nil {
+		t.Errorf("expected error for unsupported version")
+	}
+	if _, err := decodeCommitLogRecord([]byte{1}, []byte{1, 0, 0}); err == nil {
+		t.Errorf("expected error for malformed record")
+	}
+}
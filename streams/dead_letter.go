@@ -0,0 +1,235 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Headers added to records forwarded to a dead letter topic, describing why and where the original record failed.
+const (
+	DeadLetterEventTypeHeader = "gkes_dlq_event_type"
+	DeadLetterTopicHeader     = "gkes_dlq_topic"
+	DeadLetterPartitionHeader = "gkes_dlq_partition"
+	DeadLetterOffsetHeader    = "gkes_dlq_offset"
+	DeadLetterErrorHeader     = "gkes_dlq_error"
+	// The number of times the record has been dead lettered. Retained when a record is replayed, so repeated failures can be identified.
+	DeadLetterAttemptHeader = "gkes_dlq_attempt"
+)
+
+const deadLetterHeaderPrefix = "gkes_dlq_"
+
+// implemented by *EventContext[T], allows dead lettering from non-generic error handlers
+type forwarder interface {
+	Forward(...*Record)
+}
+
+/*
+A DeadLetterQueue forwards records which could not be deserialized or processed to a dead letter topic. Dead letter records are
+forwarded via the transactional producer of the EventSource, so they are committed in the same transaction as the offset of the failed record.
+The dead letter record contains the original key, value and headers of the failed record, with additional headers
+describing the failure (see [DeadLetterErrorHeader] and friends).
+
+If [EventSourceConfig].DeadLetterDestination is set, the EventSource creates the dead letter topic and uses [DeadLetterQueue.DeserializationErrorHandler]
+unless another DeserializationErrorHandler is configured. Processing failures may be dead lettered from an EventProcessor:
+
+	func processOrder(ec *streams.EventContext[myStore], order Order) streams.ExecutionState {
+		if err := validate(order); err != nil {
+			return eventSource.DeadLetterQueue().Forward(ec, "Order", err)
+		}
+		...
+	}
+
+Dead letter records may be re-injected into the topic they were consumed from with [ReplayDeadLetters].
+*/
+type DeadLetterQueue struct {
+	destination Destination
+}
+
+// Creates a DeadLetterQueue which forwards to `destination.DefaultTopic`. Since records are forwarded by the transactional producer
+// of the EventSource, the topic must reside on the EventSourceConfig.StateCluster. `destination.Cluster` is only used by [ReplayDeadLetters].
+func NewDeadLetterQueue(destination Destination) DeadLetterQueue {
+	return DeadLetterQueue{destination: destination}
+}
+
+// The destination dead letter records are forwarded to.
+func (dlq DeadLetterQueue) Destination() Destination {
+	return dlq.destination
+}
+
+// A DeserializationErrorHandler which forwards the record to the dead letter topic and returns [Continue].
+func (dlq DeadLetterQueue) DeserializationErrorHandler(ec ErrorContext, eventType string, err error) ErrorResponse {
+	dlq.Forward(ec, eventType, err)
+	return Continue
+}
+
+// Forwards the input record of `ec` to the dead letter topic. `ec` must be an *EventContext, otherwise the failure is only logged.
+// Returns [Complete], so Forward may be returned directly from an EventProcessor. Interjections have no input record and are ignored.
+func (dlq DeadLetterQueue) Forward(ec ErrorContext, eventType string, err error) ExecutionState {
+	input, ok := ec.Input()
+	if !ok {
+		return Complete
+	}
+	fw, ok := ec.(forwarder)
+	if !ok {
+		log.Errorf("could not dead letter record for %+v, offset: %d, eventType: %s, error: %v", ec.TopicPartition(), ec.Offset(), eventType, err)
+		return Complete
+	}
+	log.Warnf("dead lettering record for %+v, offset: %d, eventType: %s, error: %v", ec.TopicPartition(), ec.Offset(), eventType, err)
+	fw.Forward(dlq.deadLetterRecord(input, eventType, err))
+	return Complete
+}
+
+func (dlq DeadLetterQueue) deadLetterRecord(input IncomingRecord, eventType string, err error) *Record {
+	attempt := 1
+	if previous, err := strconv.Atoi(string(input.HeaderValue(DeadLetterAttemptHeader))); err == nil {
+		attempt = previous + 1
+	}
+	tp := input.TopicPartition()
+	record := NewRecord().
+		WithTopic(dlq.destination.DefaultTopic).
+		WithKey(input.Key()).
+		WithValue(input.Value())
+	for _, header := range input.Headers() {
+		if !strings.HasPrefix(header.Key, deadLetterHeaderPrefix) {
+			record.WithHeader(header.Key, header.Value)
+		}
+	}
+	errString := "<nil>"
+	if err != nil {
+		errString = err.Error()
+	}
+	return record.
+		WithHeader(DeadLetterEventTypeHeader, []byte(eventType)).
+		WithHeader(DeadLetterTopicHeader, []byte(tp.Topic)).
+		WithHeader(DeadLetterPartitionHeader, []byte(strconv.FormatInt(int64(tp.Partition), 10))).
+		WithHeader(DeadLetterOffsetHeader, []byte(strconv.FormatInt(input.Offset(), 10))).
+		WithHeader(DeadLetterErrorHeader, []byte(errString)).
+		WithHeader(DeadLetterAttemptHeader, []byte(strconv.Itoa(attempt)))
+}
+
+// Converts a dead letter record back into the record it was created from. The record is addressed to the topic and partition it was originally consumed from.
+// Only the DeadLetterAttemptHeader is retained.
+func replayRecord(deadLetter *kgo.Record) (*kgo.Record, error) {
+	record := &kgo.Record{
+		Key:   deadLetter.Key,
+		Value: deadLetter.Value,
+	}
+	var partition string
+	for _, header := range deadLetter.Headers {
+		switch header.Key {
+		case DeadLetterTopicHeader:
+			record.Topic = string(header.Value)
+		case DeadLetterPartitionHeader:
+			partition = string(header.Value)
+		case DeadLetterAttemptHeader:
+			record.Headers = append(record.Headers, header)
+		default:
+			if !strings.HasPrefix(header.Key, deadLetterHeaderPrefix) {
+				record.Headers = append(record.Headers, header)
+			}
+		}
+	}
+	p, err := strconv.ParseInt(partition, 10, 32)
+	if len(record.Topic) == 0 || err != nil {
+		return nil, fmt.Errorf("invalid dead letter record %s/%d offset: %d, missing source topic or partition",
+			deadLetter.Topic, deadLetter.Partition, deadLetter.Offset)
+	}
+	record.Partition = int32(p)
+	return record, nil
+}
+
+/*
+ReplayDeadLetters re-injects the records of the dead letter topic `deadLetters.DefaultTopic`, residing on `deadLetters.Cluster`,
+into the topic and partition they were originally consumed from, on `sourceCluster`. Only records present when ReplayDeadLetters is invoked are replayed.
+If `filter` is non-nil, only dead letter records for which it returns true are replayed. Returns the number of records replayed.
+
+Records are not removed from the dead letter topic, so invoking ReplayDeadLetters twice will replay records twice.
+Replayed records retain the DeadLetterAttemptHeader, which is incremented if the record is dead lettered again.
+*/
+func ReplayDeadLetters(ctx context.Context, deadLetters Destination, sourceCluster Cluster, filter func(IncomingRecord) bool) (int, error) {
+	topic := deadLetters.DefaultTopic
+	targets, err := globalTableTargets(deadLetters.Cluster, topic)
+	if err != nil {
+		return 0, err
+	}
+	for p, target := range targets {
+		if target <= 0 {
+			delete(targets, p)
+		}
+	}
+	if len(targets) == 0 {
+		return 0, nil
+	}
+	consumer, err := NewClient(deadLetters.Cluster,
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+		// control records let us know when we have reached the last stable offset, even if the topic is written transactionally
+		kgo.KeepControlRecords())
+	if err != nil {
+		return 0, err
+	}
+	defer consumer.Close()
+	producer, err := NewClient(sourceCluster)
+	if err != nil {
+		return 0, err
+	}
+	defer producer.Close()
+
+	replayed := 0
+	for len(targets) > 0 {
+		fetches := consumer.PollFetches(ctx)
+		if err = ctx.Err(); err != nil {
+			return replayed, err
+		}
+		for _, fetchErr := range fetches.Errors() {
+			return replayed, fetchErr.Err
+		}
+		var records []*kgo.Record
+		fetches.EachRecord(func(r *kgo.Record) {
+			target, ok := targets[r.Partition]
+			if !ok || r.Offset >= target {
+				return
+			}
+			if r.Offset+1 >= target {
+				delete(targets, r.Partition)
+			}
+			if r.Attrs.IsControl() {
+				return
+			}
+			if filter != nil && !filter(newIncomingRecord(r)) {
+				return
+			}
+			if record, err := replayRecord(r); err != nil {
+				log.Warnf("%v", err)
+			} else {
+				records = append(records, record)
+			}
+		})
+		if len(records) == 0 {
+			continue
+		}
+		if err = producer.ProduceSync(ctx, records...).FirstErr(); err != nil {
+			return replayed, err
+		}
+		replayed += len(records)
+	}
+	return replayed, nil
+}
@@ -0,0 +1,191 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+var errBadItem = errors.New("bad item")
+
+func failingDecoder(IncomingRecord) (intStoreItem, error) {
+	return intStoreItem{}, errBadItem
+}
+
+func verifyDeadLetter(t *testing.T, dl IncomingRecord, eventType, errString, attempt string, offset string) {
+	t.Helper()
+	expected := map[string]string{
+		DeadLetterEventTypeHeader: eventType,
+		DeadLetterTopicHeader:     "driver_topic",
+		DeadLetterPartitionHeader: "1",
+		DeadLetterOffsetHeader:    offset,
+		DeadLetterErrorHeader:     errString,
+		DeadLetterAttemptHeader:   attempt,
+	}
+	for header, value := range expected {
+		if actual := string(dl.HeaderValue(header)); actual != value {
+			t.Errorf("incorrect %s header. actual: %s, expected: %s", header, actual, value)
+		}
+	}
+	if dl.RecordType() != eventType {
+		t.Errorf("incorrect record type. actual: %s, expected: %s", dl.RecordType(), eventType)
+	}
+}
+
+func TestDeadLetterQueue(t *testing.T) {
+	driver := NewTestDriver(EventSourceConfig{
+		GroupId:               "driver_group",
+		Topic:                 "driver_topic",
+		NumPartitions:         4,
+		DeadLetterDestination: Destination{DefaultTopic: "driver_dlq"},
+	}, NewIntStore, defaultTestHandler)
+	defer driver.Close()
+	es := driver.EventSource()
+
+	RegisterEventType(es, failingDecoder, func(ec *EventContext[intStore], item intStoreItem) ExecutionState {
+		return Complete
+	}, "undecodable")
+	RegisterEventType(es, decodeIntStoreItem, func(ec *EventContext[intStore], item intStoreItem) ExecutionState {
+		if item.Value < 0 {
+			return es.DeadLetterQueue().Forward(ec, "unprocessable", errBadItem)
+		}
+		ec.Store().add(item)
+		return Complete
+	}, "unprocessable")
+
+	driver.Pipe(1,
+		intRecord("undecodable", 1, 10),
+		intRecord("unprocessable", 2, -20),
+		intRecord("unprocessable", 3, 30))
+
+	if l := driver.Store(1).tree.Len(); l != 1 {
+		t.Errorf("incorrect store size. actual: %d, expected: %d", l, 1)
+	}
+	deadLetters := driver.Output("driver_dlq")
+	if len(deadLetters) != 2 {
+		t.Fatalf("incorrect dead letter count. actual: %d, expected: %d", len(deadLetters), 2)
+	}
+	verifyDeadLetter(t, deadLetters[0], "undecodable", errBadItem.Error(), "1", "0")
+	verifyDeadLetter(t, deadLetters[1], "unprocessable", errBadItem.Error(), "1", "1")
+	if key, _ := IntCodec.Decode(deadLetters[1].Key()); key != 2 {
+		t.Errorf("incorrect key. actual: %d, expected: %d", key, 2)
+	}
+	if value, _ := IntCodec.Decode(deadLetters[1].Value()); value != -20 {
+		t.Errorf("incorrect value. actual: %d, expected: %d", value, -20)
+	}
+
+	// a replayed record which fails again increments the attempt count
+	replayed, err := replayRecord(&deadLetters[1].kRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.Topic != "driver_topic" || replayed.Partition != 1 {
+		t.Errorf("incorrect replay destination: %s/%d", replayed.Topic, replayed.Partition)
+	}
+	replay := NewRecord().WithKey(replayed.Key).WithValue(replayed.Value)
+	for _, header := range replayed.Headers {
+		replay.WithHeader(header.Key, header.Value)
+	}
+	driver.ClearOutput()
+	driver.Pipe(1, replay)
+	deadLetters = driver.Output("driver_dlq")
+	if len(deadLetters) != 1 {
+		t.Fatalf("incorrect dead letter count. actual: %d, expected: %d", len(deadLetters), 1)
+	}
+	verifyDeadLetter(t, deadLetters[0], "unprocessable", errBadItem.Error(), "2", "3")
+}
+
+func TestReplayDeadLetters(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+		return
+	}
+	config := testTopicConfig()
+	config.DeadLetterDestination = Destination{DefaultTopic: config.Topic + "_dlq"}
+	source, err := CreateSource(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DeleteSource(config)
+
+	client, err := NewClient(testCluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	topics, err := kadm.NewClient(client).ListTopics(context.Background(), config.DeadLetterDestination.DefaultTopic)
+	if err != nil || topics[config.DeadLetterDestination.DefaultTopic].Err != nil {
+		t.Fatalf("dead letter topic was not created: %v, %v", err, topics[config.DeadLetterDestination.DefaultTopic].Err)
+	}
+
+	dlq := source.DeadLetterQueue()
+	var deadLetters []*kgo.Record
+	for i := 0; i < 10; i++ {
+		input := intRecord("replay", i, i).toKafkaRecord()
+		input.Topic, input.Partition, input.Offset = config.Topic, int32(i%2), int64(i)
+		deadLetter := *dlq.deadLetterRecord(newIncomingRecord(input), "replay", errBadItem).toKafkaRecord()
+		deadLetters = append(deadLetters, &deadLetter)
+	}
+	if err = client.ProduceSync(context.Background(), deadLetters...).FirstErr(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTestTimeout)
+	defer cancel()
+	count, err := ReplayDeadLetters(ctx, dlq.Destination(), testCluster, func(ir IncomingRecord) bool {
+		key, _ := IntCodec.Decode(ir.Key())
+		return key != 0
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 9 {
+		t.Errorf("incorrect replay count. actual: %d, expected: %d", count, 9)
+	}
+
+	consumer, err := NewClient(testCluster,
+		kgo.ConsumeTopics(config.Topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer consumer.Close()
+	received := 0
+	for received < count {
+		fetches := consumer.PollFetches(ctx)
+		if ctx.Err() != nil {
+			t.Fatalf("timed out waiting for replayed records, received: %d", received)
+		}
+		fetches.EachRecord(func(r *kgo.Record) {
+			received++
+			ir := newIncomingRecord(r)
+			key, _ := IntCodec.Decode(ir.Key())
+			if r.Partition != int32(key%2) {
+				t.Errorf("incorrect partition for %d. actual: %d, expected: %d", key, r.Partition, key%2)
+			}
+			if ir.RecordType() != "replay" || string(ir.HeaderValue(DeadLetterAttemptHeader)) != "1" {
+				t.Errorf("incorrect replayed headers: %+v", r.Headers)
+			}
+			if len(ir.HeaderValue(DeadLetterErrorHeader)) > 0 {
+				t.Errorf("dead letter headers not removed: %+v", r.Headers)
+			}
+		})
+	}
+}
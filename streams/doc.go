@@ -35,13 +35,60 @@ convenient implementations provided. However, to achieve optimum performance, yo
 but will also need to understand what the proper data structures are for your use case (trees, heaps, maps, disk-based LSM trees or combinations thereof).
 You can use the provided [github.com/aws/go-kafka-event-source/streams/stores.SimpleStore] as a starting point.
 
+By default, a StateStore is rebuilt from the beginning of the state store topic every time a partition is assigned. For large partitions,
+this can take a considerable amount of time. A StateStore which persists it's contents locally can implement [CheckpointStateStore],
+in which case only the tail of the change log (records produced since the partition was last cleanly revoked on this host) is replayed.
+See [github.com/aws/go-kafka-event-source/streams/stores.DiskStore] for a disk-backed implementation.
+
+Alternatively, for stores which do not compact well, a StateStore which can serialize it's contents can implement [SnapshotStateStore].
+When [EventSourceConfig].SnapshotInterval is set, each active partition is periodically snapshotted to a compacted snapshot topic,
+and newly assigned partitions restore the latest snapshot before replaying the change log records produced after it.
+
+The [IncrementalRebalancer] prepares partitions before moving them, but if a consumer crashes, it's partitions must be reassigned immediately.
+To avoid a cold replay in this case, set [EventSourceConfig].NumStandbyReplicas. Each consumer will then keep warm standby copies of
+other members' StateStores, and partitions of a failed consumer are preferably reassigned to a member holding a standby copy.
+
+# Multiple Source Topics
+
+An EventSource may consume more than one topic by setting [EventSourceConfig].Topics. All topics must have the same number of partitions,
+and the same partition number of every topic is processed by a single partition worker against a single StateStore. This allows related topics
+to be processed with the same state, without bridging multiple EventSources via EventContext.Forward. Offsets are recorded in the commit log per TopicPartition.
+Interjections, partition event handlers and [EventSource.Query] are partitioned by the first topic. The [IncrementalRebalancer] is required,
+as it assigns the same partition of every topic to the same consumer.
+
+# Routing
+
+Records are routed to the EventProcessor registered for their record type via [RegisterEventType] with a single map lookup,
+so the number of registered event types does not affect dispatch cost. Records may also be routed by arbitrary headers, key prefixes
+or any predicate over the IncomingRecord via [RegisterRoute]. Routing rules are evaluated before the record type, in registration order,
+and records which match no route are passed to the default processor. [EventSource.Routes] lists the registered routes in the order they are evaluated.
+
+# Stream DSL
+
+Rather than composing nested EventProcessor closures by hand, processing steps may be declared as a typed [Stream]:
+
+	orders := streams.From(eventSource, streams.JsonItemDecoder[Order], "order").Filter(isPaid)
+	streams.Map(orders, toInvoice).To(invoices, streams.EncodeWith("invoice", streams.JsonCodec[Invoice]{}))
+
+A Stream compiles down to a single registered EventProcessor. Steps receive the EventContext, so they may read and update the StateStore,
+and output is produced via EventContext.Forward, retaining Exactly Once Semantics.
+
+# Event Versioning
+
+Event payloads evolve. Rather than registering `orderCreated.v1` and `orderCreated.v2` as separate record types, a producer may send the payload
+version alongside the record type via [Record.WithRecordVersion], and register every version with a single EventProcessor via [RegisterVersionedEventType].
+Older versions are transformed into the current type by upcasters registered with [EventVersions]. Records of a version newer than the application
+are passed to the default processor, or to the DeserializationErrorHandler when [EventVersions.WithUnknownVersionError] is used.
+
 # Vending State
 
 GKES purposefully does not provide a pre-canned way for exposing StateStore data, other than a producing to another Kafka topic.
 There are as many ways to vend data as there are web applications. Rather than putting effort into inventing yet another one,
-GKES provides the mechanisms to query StateStores via Interjections. This mechanism can be plugged into whatever request/response mechanism that suits your use-case
+GKES provides the mechanisms to query StateStores via [EventSource.Query]. This mechanism can be plugged into whatever request/response mechanism that suits your use-case
 (gRPC, RESTful HTTP service...any number of web frameworks already in the Go ecosystem).
-[TODO: provide a simple http example]
+Query routes a key to it's partition and runs a read-only function against the local StateStore, without occupying a transaction in the eos producer pool
+like an Interjection would. If the partition is owned by another consumer, a [PartitionNotAssignedError] containing the owner's
+EventSourceConfig.AdvertisedAddr is returned, so the request can be forwarded.
 
 # Interjections
 
@@ -51,6 +98,38 @@ or at any time via [streams.EventSource.Interject]. This is useful for bookeepin
 even error handling. Interjections have full access to the StateStore associated with an EventSource and can interact with output topics
 like any other EventProcessor.
 
+# Error Handling
+
+An EventProcessor which can not process an event may return [Fatal] (see [EventContext.Fail]), or register a [FallibleEventProcessor]
+via [RegisterFallibleEventType]. The failure is passed to [EventSourceConfig].ProcessingErrorHandler, which either skips the event
+or stops the consumer, leaving the event uncommitted. By default, the consumer is stopped.
+
+By default, records which can not be deserialized are logged and dropped. Set [EventSourceConfig].DeadLetterDestination to instead forward them,
+along with headers describing the failure, to a dead letter topic within the same transaction as the failed record's offset.
+EventProcessors may dead letter records they can not process via [EventSource.DeadLetterQueue], and [ReplayDeadLetters]
+re-injects dead letter records into the topic they were consumed from once the underlying problem is fixed.
+
+For transient failures, set [EventSourceConfig].RetryDelays and return [RetryQueue.Retry] from your EventProcessor. The record is forwarded to a tiered,
+co-partitioned retry topic and redelivered to the same partition once it's delay has elapsed, without blocking the partition in the meantime.
+Once all delays are exhausted, the record falls through to the dead letter topic.
+
+# Windowing
+
+For time based aggregations (rolling counts per key for example), [github.com/aws/go-kafka-event-source/streams/windowing.Aggregation]
+groups records into tumbling, hopping or session windows by record timestamp. Window state is kept in a
+[github.com/aws/go-kafka-event-source/streams/windowing.WindowStore] and recorded to the change log. Late records are accepted up to a grace period,
+and a callback is invoked when each window closes, which may Forward the final result.
+
+To correlate events from two co-partitioned topics (orders and payments for example), list both in [EventSourceConfig].Topics and use a
+[github.com/aws/go-kafka-event-source/streams/windowing.Join]. Each side is buffered in a change log backed
+[github.com/aws/go-kafka-event-source/streams/windowing.JoinStore], and records with matching keys are joined when their timestamps fall
+within the join window. Inner, left and outer joins are supported.
+
+# Global Tables
+
+Reference data which is small enough to be held by every host can be consumed into a [GlobalTable], a typed lookup table built on [GlobalChangeLog].
+[Enrich] joins incoming events against a GlobalTable by a key extracted from each event.
+
 # Incremental Consumer Rebalancing
 
 One issue that Kafka conumer applications have long suffered from are latency spikes during a consumer rebalance. The cooperative sticky rebalancing introduced by Kafka and implemented
@@ -86,6 +165,31 @@ producer in pool. Since trasnaction produce in sequence, there is no danger of c
 To ensure EOS, your [EventSource] must use either the [IncrementalRebalancer], or [kgo]s cooperative sticky implementation. Though if you're using a StateStore, [IncrementalRebalancer]
 should be used to avoid lengthy periods of inactivity during application deployments.
 
+Consumer offsets are committed to a GKES specific commit log topic rather than the standard consumer group offsets. [ReadCommitLog], [WriteCommitLogOffsets] and friends
+provide programmatic access to the commit log, and the gkes command ([github.com/aws/go-kafka-event-source/streams/cmd/gkes]) can dump it, reset offsets by offset or timestamp,
+and copy offsets to and from the standard consumer group offsets. To reprocess events after a bug fix without deleting topics, stop the EventSource and use [ResetSource]
+to rewind the commit log to an offset or timestamp, optionally truncating the StateStore so it is rebuilt from the reprocessed events.
+The commit log is written in host byte order by default. Set [EventSourceConfig].CommitLogFormat to [CommitLogFormatV1] for a portable, versioned encoding,
+and use [MigrateCommitLog] to rewrite existing entries.
+
+# Metrics
+
+When EventSourceConfig.MetricsHandler is set, the EventSource emits a [Metric] for each transaction commit, partition commit, partition preparation
+and change in assigned/preparing partitions. The [github.com/aws/go-kafka-event-source/metrics/prometheus] module provides a ready-made MetricsHandler
+which exports these as Prometheus histograms, counters and gauges.
+
+[EventSource.Progress] reports the committed, processed and broker high watermark offsets of each assigned partition, along with
+the bootstrap progress of StateStores being prepared. Set EventSourceConfig.ProgressInterval to emit consumer lag as a Metric.
+
+[NewAdminHandler] returns an optional http.Handler serving liveness/readiness probes, current assignments, rebalancer and eos producer pool state,
+as well as endpoints to stop the EventSource or run a named Interjector on every active partition.
+
+# Tracing
+
+When EventSourceConfig.Tracer is set, W3C trace context is extracted from incoming record headers, a span is opened around each EventProcessor and Interjector invocation,
+and the current span is injected into every Record passed to EventContext.Forward or EventContext.RecordChange. See [Tracer].
+The [github.com/aws/go-kafka-event-source/tracing/otel] module provides an OpenTelemetry implementation.
+
 # Kafka Client Library
 
 Rather than create yet another Kafka driver, GKES is built on top of [kgo]. This Kafka client was chosen
@@ -0,0 +1,118 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams"
+	"github.com/aws/go-kafka-event-source/streams/stores"
+	"github.com/google/uuid"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+type counter struct {
+	Id    string
+	Count int
+}
+
+func (c counter) Key() string {
+	return c.Id
+}
+
+type counterStore = *stores.SimpleStore[counter]
+
+func TestEventSourceEndToEnd(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+		return
+	}
+	cluster := streams.SharedTestCluster()
+	outputTopic := uuid.NewString()
+	admin, err := streams.NewClient(cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+	created, err := kadm.NewClient(admin).CreateTopics(context.Background(), 4, 1, nil, outputTopic)
+	if err == nil {
+		err = created[outputTopic].Err
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	es, err := streams.NewEventSource(streams.EventSourceConfig{
+		GroupId:       outputTopic + "_group",
+		Topic:         outputTopic + "_increments",
+		NumPartitions: 4,
+		SourceCluster: cluster,
+	}, stores.NewJsonSimpleStore[counter], func(ec *streams.EventContext[counterStore], _ streams.IncomingRecord) streams.ExecutionState {
+		return streams.Complete
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decodeId := func(ir streams.IncomingRecord) (string, error) {
+		return string(ir.Key()), nil
+	}
+	streams.RegisterEventType(es, decodeId, func(ec *streams.EventContext[counterStore], id string) streams.ExecutionState {
+		c, _ := ec.Store().Get(id)
+		c.Id = id
+		c.Count++
+		ec.RecordChange(ec.Store().Put(c))
+		ec.Forward(streams.JsonItemEncoder("count", c).WithTopic(outputTopic).WithKeyString(id))
+		return streams.Complete
+	}, "increment")
+
+	es.ConsumeEvents()
+	defer es.StopNow()
+
+	producer := streams.NewProducer(es.Source().AsDestination())
+	defer producer.Close()
+	for i := 0; i < 3; i++ {
+		if err := producer.Produce(context.Background(), streams.NewRecord().
+			WithRecordType("increment").
+			WithKeyString("a")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client, err := streams.NewClient(cluster, kgo.ConsumeTopics(outputTopic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var last counter
+	for received := 0; received < 3; {
+		fetches := client.PollFetches(ctx)
+		if err := ctx.Err(); err != nil {
+			t.Fatalf("deadline exceeded, received %d records", received)
+		}
+		fetches.EachRecord(func(r *kgo.Record) {
+			received++
+			last, _ = streams.JsonCodec[counter]{}.Decode(r.Value)
+		})
+	}
+	if last.Count != 3 {
+		t.Errorf("incorrect count. actual: %d, expected: %d", last.Count, 3)
+	}
+}
@@ -92,6 +92,7 @@ type eosProducerPool[T StateStore] struct {
 	startTime         time.Time
 	errorChannel      chan error
 	commitClient      *kgo.Client
+	pending           int64 // events forwarded to the pool which have not yet been committed
 }
 
 func newEOSProducerPool[T StateStore](source *Source, commitLog *eosCommitLog, cfg EosConfig, commitClient *kgo.Client, metrics chan Metric) *eosProducerPool[T] {
@@ -114,7 +115,7 @@ func newEOSProducerPool[T StateStore](source *Source, commitLog *eosCommitLog, c
 	var prev *producerNode[T]
 	var last *producerNode[T]
 	for i := 0; i < cfg.PoolSize; i++ {
-		p := newProducerNode(i, source, commitLog, pp.partitionOwners, commitClient, metrics, pp.errorChannel)
+		p := newProducerNode(i, source, commitLog, pp.partitionOwners, commitClient, metrics, pp.errorChannel, &pp.pending)
 		pp.producerNodes = append(pp.producerNodes, p)
 		if first == nil {
 			first = p
@@ -134,6 +135,7 @@ func newEOSProducerPool[T StateStore](source *Source, commitLog *eosCommitLog, c
 
 // buffer the event context until a producer node is available
 func (pp *eosProducerPool[T]) addEventContext(ec *EventContext[T]) {
+	atomic.AddInt64(&pp.pending, 1)
 	pp.buffer <- ec
 }
 
@@ -146,6 +148,7 @@ func (pp *eosProducerPool[T]) doForwardExecutionContexts(ec *EventContext[T]) {
 		// if we're revoked, don't even add this to the onDeck producer
 		ec.producerChan <- nil
 		ec.revocationWaiter.Done()
+		atomic.AddInt64(&pp.pending, -1)
 		return
 	}
 	txnStarted := pp.onDeck.addEventContext(ec)
@@ -266,10 +269,11 @@ type producerNode[T any] struct {
 	id                int
 	txnErrorHandler   TxnErrorHandler
 	errorChannel      chan error
+	poolPending       *int64
 	// errs                  []error
 }
 
-func newProducerNode[T StateStore](id int, source *Source, commitLog *eosCommitLog, partitionOwners partitionOwners[T], commitClient *kgo.Client, metrics chan Metric, errorChannel chan error) *producerNode[T] {
+func newProducerNode[T StateStore](id int, source *Source, commitLog *eosCommitLog, partitionOwners partitionOwners[T], commitClient *kgo.Client, metrics chan Metric, errorChannel chan error, poolPending *int64) *producerNode[T] {
 	client := sak.Must(NewClient(
 		source.stateCluster(),
 		kgo.RecordPartitioner(NewOptionalPartitioner(kgo.StickyKeyPartitioner(nil))),
@@ -290,6 +294,7 @@ func newProducerNode[T StateStore](id int, source *Source, commitLog *eosCommitL
 		partitionOwners:   partitionOwners,
 		txnErrorHandler:   source.eosErrorHandler(),
 		recordsToProduce:  pendingRecordPool.Borrow(),
+		poolPending:       poolPending,
 	}
 }
 
@@ -334,7 +339,8 @@ func (p *producerNode[T]) beginTransaction() {
 }
 
 func (p *producerNode[T]) finalizeEventContexts(first, last *EventContext[T]) error {
-	commitRecordProduced := false
+	// a partition may contain events from more than one co-partitioned topic, each of which needs a commit record
+	var committedTopics []string
 	// we'll now iterate in reverse order - committing the largest offset
 	for ec := last; ec != nil; ec = ec.prev {
 		select {
@@ -344,11 +350,11 @@ func (p *producerNode[T]) finalizeEventContexts(first, last *EventContext[T]) er
 		}
 		offset := ec.Offset()
 		// if less than 0, this is an interjection, no record to commit
-		if !commitRecordProduced && offset >= 0 {
+		if tp := ec.TopicPartition(); offset >= 0 && !sak.Contains(committedTopics, tp.Topic) {
 			// we only want to produce the highest offset, since these are in reverse order
-			// produce a commit record for the first real offset we see
-			commitRecordProduced = true
-			crd := p.commitLog.commitRecord(ec.TopicPartition(), offset)
+			// produce a commit record for the first real offset we see for each topic
+			committedTopics = append(committedTopics, tp.Topic)
+			crd := p.commitLog.commitRecord(tp, offset, ec.input.LeaderEpoch())
 			p.ProduceRecord(ec, crd, nil)
 		}
 		ec.revocationWaiter.Done()
@@ -379,7 +385,7 @@ func (p *producerNode[T]) commit() error {
 		return err
 	}
 	action := kgo.TryCommit
-	if p.produceCnt == 0 {
+	if atomic.LoadInt64(&p.produceCnt) == 0 {
 		action = kgo.TryAbort
 	}
 	err = p.client.EndTransaction(p.txnContext, action)
@@ -391,6 +397,53 @@ func (p *producerNode[T]) commit() error {
 	return nil
 }
 
+// emits a PartitionCommitOperation for each topic partition in the transaction
+func (p *producerNode[T]) emitPartitionMetrics(executionTime time.Time) {
+	if p.metrics == nil {
+		return
+	}
+	type inputSize struct {
+		count, bytes int
+	}
+	endTime := time.Now()
+	for partition, ecs := range p.currentPartitions {
+		// a partition may contain events from each co-partitioned topic and retry topic
+		sizes := make(map[string]*inputSize)
+		for ec := ecs.root; ec != nil; ec = ec.next {
+			if !ec.IsInterjection() {
+				size := sizes[ec.input.kRecord.Topic]
+				if size == nil {
+					size = new(inputSize)
+					sizes[ec.input.kRecord.Topic] = size
+				}
+				size.count++
+				size.bytes += recordSize(ec.input.kRecord)
+			}
+			if ec == ecs.tail {
+				break
+			}
+		}
+		if len(sizes) == 0 {
+			// only interjections were committed
+			sizes[p.source.Topic()] = new(inputSize)
+		}
+		for topic, size := range sizes {
+			emitMetric(p.metrics, Metric{
+				Operation:      PartitionCommitOperation,
+				Topic:          topic,
+				GroupId:        p.source.GroupId(),
+				StartTime:      p.firstEvent,
+				ExecuteTime:    executionTime,
+				EndTime:        endTime,
+				Count:          size.count,
+				Bytes:          size.bytes,
+				PartitionCount: 1,
+				Partition:      partition,
+			})
+		}
+	}
+}
+
 func (p *producerNode[T]) clearState(executionTime time.Time) {
 	p.txnContextCancel()
 	p.txnContext = nil
@@ -406,8 +459,10 @@ func (p *producerNode[T]) clearState(executionTime time.Time) {
 	}
 
 	partitionCount := len(p.currentPartitions)
+	produceCnt := atomic.LoadInt64(&p.produceCnt)
+	p.emitPartitionMetrics(executionTime)
 	p.relinquishOwnership()
-	if p.metrics != nil && p.produceCnt > 0 {
+	if p.metrics != nil && produceCnt > 0 {
 		p.metrics <- Metric{
 			Operation:      TxnCommitOperation,
 			Topic:          p.source.Topic(),
@@ -415,13 +470,26 @@ func (p *producerNode[T]) clearState(executionTime time.Time) {
 			StartTime:      p.firstEvent,
 			ExecuteTime:    executionTime,
 			EndTime:        time.Now(),
-			Count:          int(p.produceCnt),
+			Count:          int(produceCnt),
 			Bytes:          int(p.byteCount),
 			PartitionCount: partitionCount,
 			Partition:      -1,
 		}
 	}
-	p.produceCnt = 0
+	pending := atomic.AddInt64(p.poolPending, -p.eventContextCnt)
+	if p.metrics != nil {
+		now := time.Now()
+		emitMetric(p.metrics, Metric{
+			Operation: EosPendingOperation,
+			Topic:     p.source.Topic(),
+			GroupId:   p.source.GroupId(),
+			StartTime: now,
+			EndTime:   now,
+			Count:     int(pending),
+			Partition: -1,
+		})
+	}
+	atomic.StoreInt64(&p.produceCnt, 0)
 	p.eventContextCnt = 0
 	p.byteCount = 0
 
@@ -493,7 +561,8 @@ func (p *producerNode[T]) flushRemaining() {
 
 func (p *producerNode[T]) ProduceRecord(ec *EventContext[T], record *Record, cb func(*Record, error)) {
 	p.produceLock.Lock()
-	p.produceCnt++
+	// read atomically by the pool while deciding whether to flush
+	atomic.AddInt64(&p.produceCnt, 1)
 	// set the timestamp if not set
 	// we want to capture any time that this record spends in the recordsToProduce buffer
 	if record.kRecord.Timestamp.IsZero() {
@@ -14,6 +14,8 @@
 
 package streams
 
+import "errors"
+
 // in structs GKES and how to proceed when an error is encountered.
 type ErrorResponse int
 
@@ -34,18 +36,35 @@ const (
 	FatallyExit
 )
 
+// Passed to the ProcessingErrorHandler when processing returns Fatal without supplying an error via EventContext.Fail.
+var ErrFatalExecutionState = errors.New("event processing returned Fatal")
+
+// implemented by *EventContext[T], allows non-generic handling of failed events
+type failedContext interface {
+	ErrorContext
+	failure() error
+}
+
 type ErrorContext interface {
 	TopicPartition() TopicPartition
 	Offset() int64
 	Input() (IncomingRecord, bool)
 }
 
-// The default DeserializationErrorHandler. Simply logs the error and returns [Continue].
+// The default DeserializationErrorHandler. Simply logs the error and returns [Continue], so the record is dropped.
+// To retain undecodable records, set EventSourceConfig.DeadLetterDestination (see [DeadLetterQueue]).
 func DefaultDeserializationErrorHandler(ec ErrorContext, eventType string, err error) ErrorResponse {
 	log.Errorf("failed to deserialize record for %+v, offset: %d, eventType: %s,error: %v", ec.TopicPartition(), ec.Offset(), eventType, err)
 	return Continue
 }
 
+// The default ProcessingErrorHandler. Logs the error and returns [FailConsumer], so the failed event is reprocessed once it's partition is reassigned.
+// Prior to v1.1.0, Fatal was not passed to a ProcessingErrorHandler, the event was left incomplete until the transaction timed out and the TxnErrorHandler was invoked.
+func DefaultProcessingErrorHandler(ec ErrorContext, eventType string, err error) ErrorResponse {
+	log.Errorf("failing consumer due to processing error for %+v, offset: %d, eventType: %s, error: %v", ec.TopicPartition(), ec.Offset(), eventType, err)
+	return FailConsumer
+}
+
 // The default and recommended TxnErrorHandler. Returns [FailConsumer] on txn errors.
 func DefaultTxnErrorHandler(err error) ErrorResponse {
 	log.Errorf("failing consumer due to eos txn error: %v", err)
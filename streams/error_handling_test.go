@@ -0,0 +1,138 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams/sak"
+)
+
+type processingFailure struct {
+	tp        TopicPartition
+	offset    int64
+	eventType string
+	err       error
+}
+
+func TestProcessingErrorHandler(t *testing.T) {
+	failures := []processingFailure{}
+	driver := NewTestDriver(EventSourceConfig{
+		GroupId:       "driver_group",
+		Topic:         "driver_topic",
+		NumPartitions: 4,
+		ProcessingErrorHandler: func(ec ErrorContext, eventType string, err error) ErrorResponse {
+			failures = append(failures, processingFailure{ec.TopicPartition(), ec.Offset(), eventType, err})
+			if eventType == "skippable" {
+				return Continue
+			}
+			return FailConsumer
+		},
+	}, NewIntStore, defaultTestHandler)
+	defer driver.Close()
+	es := driver.EventSource()
+
+	RegisterEventType(es, decodeIntStoreItem, func(ec *EventContext[intStore], item intStoreItem) ExecutionState {
+		return ec.Fail(errBadItem)
+	}, "skippable")
+	RegisterFallibleEventType(es, decodeIntStoreItem, func(ec *EventContext[intStore], item intStoreItem) (ExecutionState, error) {
+		if item.Value < 0 {
+			return Complete, errBadItem
+		}
+		ec.Store().add(item)
+		return Complete, nil
+	}, "fallible")
+
+	driver.Pipe(2,
+		intRecord("skippable", 1, 10),
+		intRecord("fallible", 2, 20))
+	if driver.Pending() != 0 || driver.Failure() != nil {
+		t.Errorf("incorrect state. pending: %d, failure: %v", driver.Pending(), driver.Failure())
+	}
+	driver.Pipe(2, intRecord("fallible", 3, -30))
+	if driver.Pending() != 1 {
+		t.Errorf("failed event should remain pending. actual: %d, expected: %d", driver.Pending(), 1)
+	}
+	if driver.Failure() != errBadItem {
+		t.Errorf("incorrect failure. actual: %v, expected: %v", driver.Failure(), errBadItem)
+	}
+	// interjections have no event type, and Fatal without an error is reported as ErrFatalExecutionState
+	driver.Interject(2, func(ec *EventContext[intStore], _ time.Time) ExecutionState {
+		return Fatal
+	})
+
+	expected := []processingFailure{
+		{ntp(2, "driver_topic"), 0, "skippable", errBadItem},
+		{ntp(2, "driver_topic"), 2, "fallible", errBadItem},
+		{ntp(2, "driver_topic"), -1, "", ErrFatalExecutionState},
+	}
+	if len(failures) != len(expected) {
+		t.Fatalf("incorrect failure count. actual: %d, expected: %d", len(failures), len(expected))
+	}
+	for i, failure := range failures {
+		if failure != expected[i] {
+			t.Errorf("incorrect failure. actual: %+v, expected: %+v", failure, expected[i])
+		}
+	}
+	if l := driver.Store(2).tree.Len(); l != 1 {
+		t.Errorf("incorrect store size. actual: %d, expected: %d", l, 1)
+	}
+}
+
+func TestEventSourceFailsOnProcessingError(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+		return
+	}
+	failures := make(chan processingFailure, 10)
+	cfg := testTopicConfig()
+	cfg.ProcessingErrorHandler = func(ec ErrorContext, eventType string, err error) ErrorResponse {
+		failures <- processingFailure{ec.TopicPartition(), ec.Offset(), eventType, err}
+		return FailConsumer
+	}
+	es := sak.Must(NewEventSource(cfg, NewIntStore, defaultTestHandler))
+	defer DeleteSource(cfg)
+	RegisterFallibleEventType(es, decodeIntStoreItem, func(ec *EventContext[intStore], item intStoreItem) (ExecutionState, error) {
+		return Complete, errBadItem
+	}, "fallible")
+
+	producer := testProducer{NewProducer(es.source.AsDestination())}
+	es.ConsumeEvents()
+	defer es.StopNow()
+	producer.produce(t, "fallible", 4, 40)
+
+	select {
+	case failure := <-failures:
+		expected := processingFailure{ntp(4, cfg.Topic), 0, "fallible", errBadItem}
+		if failure != expected {
+			t.Errorf("incorrect failure. actual: %+v, expected: %+v", failure, expected)
+		}
+	case <-time.After(defaultTestTimeout):
+		t.Fatal("timed out waiting for processing error")
+	}
+	select {
+	case <-es.Done():
+	case <-time.After(defaultTestTimeout):
+		t.Fatal("EventSource did not stop")
+	}
+	if es.State() != Unhealthy {
+		t.Errorf("incorrect state. actual: %v, expected: %v", es.State(), Unhealthy)
+	}
+	// the failed offset must not have been committed
+	if watermark := es.consumer.commitLog.Watermark(ntp(4, cfg.Topic)); watermark > 0 {
+		t.Errorf("failed event was committed, watermark: %d", watermark)
+	}
+}
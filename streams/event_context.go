@@ -47,6 +47,9 @@ type EventContext[T any] struct {
 	done             chan struct{}
 	topicPartition   TopicPartition
 	interjection     *interjection[T]
+	err              error
+	tracer           Tracer
+	traceCtx         context.Context
 }
 
 // A convenience function for creating unit tests for an EventContext from an incoming Kafka Record. All arguments other than `ctx`
@@ -135,6 +138,7 @@ func (ec *EventContext[T]) partition() int32 {
 // Your application should not hold on to references to the Record(s) after Forward has been invoked.
 func (ec *EventContext[T]) Forward(records ...*Record) {
 	for _, record := range records {
+		ec.injectTrace(record)
 		ec.producer.ProduceRecord(ec, record, nil)
 	}
 }
@@ -154,8 +158,12 @@ func (ec *EventContext[T]) RecordChange(entries ...ChangeLogEntry) {
 			record := entry.record.
 				WithTopic(ec.changeLog.topic).
 				WithPartition(ec.topicPartition.Partition)
-
-			ec.producer.ProduceRecord(ec, record, nil)
+			ec.injectTrace(record)
+			var cb func(*Record, error)
+			if ec.changeLog.producedOffset != nil {
+				cb = ec.changeLog.recordProduced
+			}
+			ec.producer.ProduceRecord(ec, record, cb)
 		} else {
 			log.Warnf("EventContext.RecordChange was called but consumer is not stateful")
 		}
@@ -173,6 +181,24 @@ func (ec *EventContext[T]) AsyncJobComplete(finalize func() ExecutionState) {
 	})
 }
 
+// Returns the context for this event. If EventSourceConfig.Tracer is set, it contains the span for the current EventProcessor or Interjector invocation,
+// and may be used to start child spans. The context is cancelled if the TopicPartition is revoked.
+func (ec *EventContext[T]) Context() context.Context {
+	if ec.traceCtx != nil {
+		return ec.traceCtx
+	}
+	if ec.ctx != nil {
+		return ec.ctx
+	}
+	return context.Background()
+}
+
+func (ec *EventContext[T]) injectTrace(record *Record) {
+	if ec.tracer != nil {
+		ec.tracer.Inject(ec.traceCtx, recordCarrier{record})
+	}
+}
+
 // Return the raw input record for this event or an uninitialized record and false if the EventContect represents an Interjections
 func (ec *EventContext[T]) Input() (IncomingRecord, bool) {
 	return ec.input, !ec.IsInterjection()
@@ -183,6 +209,23 @@ func (ec *EventContext[T]) Store() T {
 	return ec.changeLog.store
 }
 
+// Records `err` as the cause of failure and returns [Fatal], so the failure is passed to the EventSourceConfig.ProcessingErrorHandler.
+//
+//	if err := validate(order); err != nil {
+//		return ec.Fail(err)
+//	}
+func (ec *EventContext[T]) Fail(err error) ExecutionState {
+	ec.err = err
+	return Fatal
+}
+
+func (ec *EventContext[T]) failure() error {
+	if ec.err == nil {
+		return ErrFatalExecutionState
+	}
+	return ec.err
+}
+
 func (ec *EventContext[T]) complete() {
 	close(ec.done)
 }
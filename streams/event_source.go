@@ -33,8 +33,8 @@ var ErrPartitionNotReady = errors.New("partition is not ready")
 // EventSource provides an abstraction over raw kgo.Record/streams.IncomingRecord consumption, allowing the use of strongly typed event handlers.
 // One of the key features of the EventSource is to allow for the routing of events based off of a type header. See RegisterEventType for details.
 type EventSource[T StateStore] struct {
-	rootProcessor     *eventProcessorWrapper[T]
-	tailProcessor     *eventProcessorWrapper[T]
+	processors        map[string]eventExecutor[T]
+	rules             []routingRule[T]
 	stateStoreFactory StateStoreFactory[T]
 	defaultProcessor  EventProcessor[T, IncomingRecord]
 	consumer          *eventSourceConsumer[T]
@@ -43,6 +43,7 @@ type EventSource[T StateStore] struct {
 	runStatus         sak.RunStatus
 	done              chan struct{}
 	metrics           chan Metric
+	tracer            Tracer
 	stopOnce          sync.Once
 }
 
@@ -82,8 +83,12 @@ func NewEventSource[T StateStore](sourceConfig EventSourceConfig, stateStoreFact
 		runStatus:         sak.NewRunStatus(context.Background()),
 		done:              make(chan struct{}, 1),
 		metrics:           metrics,
+		tracer:            source.config.Tracer,
 	}
 	es.consumer, err = newEventSourceConsumer(es, additionalClientOptions...)
+	if interval := source.config.SnapshotInterval; interval > 0 {
+		es.ScheduleInterjection(es.snapshot, interval, interval/10)
+	}
 	return es, err
 }
 
@@ -92,6 +97,9 @@ func NewEventSource[T StateStore](sourceConfig EventSourceConfig, stateStoreFact
 // See [streams.EventSource.WaitForSignals] for an example.
 func (es *EventSource[T]) ConsumeEvents() {
 	go es.emitMetrics()
+	if es.metrics != nil && es.source.config.ProgressInterval > 0 {
+		go es.emitProgress()
+	}
 	go es.consumer.start()
 	go es.closeOnFail()
 }
@@ -108,6 +116,16 @@ func (es *EventSource[T]) Source() *Source {
 	return es.source
 }
 
+// The [DeadLetterQueue] for EventSourceConfig.DeadLetterDestination. Use it to dead letter records which fail processing.
+func (es *EventSource[T]) DeadLetterQueue() DeadLetterQueue {
+	return es.source.DeadLetterQueue()
+}
+
+// The [RetryQueue] for EventSourceConfig.RetryDelays. Use it to redeliver records which fail processing due to a transient error.
+func (es *EventSource[T]) RetryQueue() RetryQueue {
+	return es.source.RetryQueue()
+}
+
 func (es *EventSource[T]) closeOnFail() {
 	err := <-es.source.failure
 	log.Errorf("closing consumer due to failure: %v", err)
@@ -117,13 +135,7 @@ func (es *EventSource[T]) closeOnFail() {
 }
 
 func (es *EventSource[T]) EmitMetric(m Metric) {
-	if es.metrics != nil {
-		select {
-		case es.metrics <- m:
-		default:
-			log.Warnf("metrics channel full, unable to emit metrics: %+v", m)
-		}
-	}
+	emitMetric(es.metrics, m)
 }
 
 func (es *EventSource[T]) emitMetrics() {
@@ -139,7 +151,7 @@ func (es *EventSource[T]) emitMetrics() {
 			}
 			handler(m)
 		case <-es.runStatus.Done():
-			close(es.metrics)
+			// not closed, partitions may be revoked, emitting metrics, after the EventSource has stopped
 			return
 		}
 	}
@@ -355,11 +367,23 @@ func (ec *EventSource[T]) createChangeLogReceiver(tp TopicPartition) T {
 	return ec.stateStoreFactory(tp)
 }
 
-// Starts the event processing by invoking registered processors. If no processors exist for record.recordTpe, the defaultProcessor will be invoked.
+// Starts the event processing by invoking registered processors, within a span if a Tracer is configured.
 func (es *EventSource[T]) handleEvent(ctx *EventContext[T], record IncomingRecord) ExecutionState {
+	if es.tracer == nil {
+		return es.dispatchEvent(ctx, record)
+	}
+	span := es.startSpan(ctx, record.RecordType())
+	state := es.dispatchEvent(ctx, record)
+	endSpan(ctx, span, state)
+	return state
+}
+
+// Invokes the processor routed to by `record`. If no processor exists for the record, the defaultProcessor will be invoked.
+// See EventSource.Routes for the order in which routes are evaluated.
+func (es *EventSource[T]) dispatchEvent(ctx *EventContext[T], record IncomingRecord) ExecutionState {
 	state := unknownType
-	if es.rootProcessor != nil {
-		state = es.rootProcessor.process(ctx, record)
+	if executor := es.route(record); executor != nil {
+		state = executor.Exec(ctx, record)
 	}
 	if state == unknownType {
 		state = es.defaultProcessor(ctx, record)
@@ -367,16 +391,44 @@ func (es *EventSource[T]) handleEvent(ctx *EventContext[T], record IncomingRecor
 	return state
 }
 
+func (es *EventSource[T]) route(record IncomingRecord) eventExecutor[T] {
+	for _, rule := range es.rules {
+		if rule.match(record) {
+			return rule.executor
+		}
+	}
+	return es.processors[record.RecordType()]
+}
+
 // Registers eventType with a transformer (usuall a codec.Codec) with the supplied EventProcessor.
+// Records are routed to `eventProcessor` when their RecordType() equals eventType, unless a routing rule (see [RegisterRoute]) matches first.
+// If eventType is registered more than once, the first registration is used.
 // Must not be called after `EventSource.ConsumeEvents()`
 func RegisterEventType[T StateStore, V any](es *EventSource[T], transformer IncomingRecordDecoder[V], eventProcessor EventProcessor[T, V], eventType string) {
-	ep := newEventProcessorWrapper(eventType, transformer, eventProcessor, es.source.deserializationErrorHandler())
-	if es.rootProcessor == nil {
-		es.rootProcessor, es.tailProcessor = ep, ep
-	} else {
-		es.tailProcessor.next = ep
-		es.tailProcessor = ep
+	es.addProcessor(eventType, newEventProcessorExecutor(transformer, eventProcessor, es.source.deserializationErrorHandler()))
+}
+
+func (es *EventSource[T]) addProcessor(eventType string, executor eventExecutor[T]) {
+	if es.processors == nil {
+		es.processors = make(map[string]eventExecutor[T])
+	}
+	if _, ok := es.processors[eventType]; ok {
+		log.Warnf("eventType %s is already registered, ignoring", eventType)
+		return
 	}
+	es.processors[eventType] = executor
+}
+
+// As RegisterEventType, but for an EventProcessor which may return an error. A returned error is passed to the
+// EventSourceConfig.ProcessingErrorHandler. Must not be called after `EventSource.ConsumeEvents()`
+func RegisterFallibleEventType[T StateStore, V any](es *EventSource[T], transformer IncomingRecordDecoder[V], eventProcessor FallibleEventProcessor[T, V], eventType string) {
+	RegisterEventType(es, transformer, func(ec *EventContext[T], event V) ExecutionState {
+		state, err := eventProcessor(ec, event)
+		if err != nil {
+			return ec.Fail(err)
+		}
+		return state
+	}, eventType)
 }
 
 // A convenience method to avoid chick-egg scenarios when initializing an EventSource.
@@ -385,19 +437,13 @@ func RegisterDefaultHandler[T StateStore](es *EventSource[T], recordProcessor Ev
 	es.defaultProcessor = recordProcessor
 }
 
-type eventExecutor[T any] interface {
-	Exec(*EventContext[T], IncomingRecord) ExecutionState
-}
-
-// Wraps an EventProcessor with a function that decodes the record before invoking eventProcessor.
+// Decodes a record before invoking an EventProcessor.
 // Doing some type gymnastics here.
-// We have 2 generic types declared here, but to have a eventProcessorWrapper[T,V], *next[T,X] would not work.
+// We have 2 generic types declared here, but EventSource[T] can not hold an eventProcessorExecutor[T,V] for every V.
 // Golang generics do no yet allow for defining new type in struct method declarations, so we have a private interface
 // wrapped by a generic.
-type eventProcessorWrapper[T any] struct {
-	eventType     string
-	eventExecutor eventExecutor[T]
-	next          *eventProcessorWrapper[T]
+type eventExecutor[T any] interface {
+	Exec(*EventContext[T], IncomingRecord) ExecutionState
 }
 
 type eventProcessorExecutor[T any, V any] struct {
@@ -417,29 +463,11 @@ func (epe *eventProcessorExecutor[T, V]) Exec(ec *EventContext[T], record Incomi
 	}
 }
 
-func newEventProcessorWrapper[T any, V any](eventType string, decoder IncomingRecordDecoder[V],
-	eventProcessor EventProcessor[T, V], deserializationErrorHandler DeserializationErrorHandler) *eventProcessorWrapper[T] {
-	return &eventProcessorWrapper[T]{
-		eventType: eventType,
-		eventExecutor: &eventProcessorExecutor[T, V]{
-			process:                    eventProcessor,
-			decode:                     decoder,
-			handleDeserializationError: deserializationErrorHandler,
-		},
-	}
-}
-
-func (ep *eventProcessorWrapper[T]) exec(ec *EventContext[T], record IncomingRecord) ExecutionState {
-	return ep.eventExecutor.Exec(ec, record)
-}
-
-// process the record if records.recordType == eventProcessorWrapper.eventType, otherwise forward this record to the next processor.
-func (ep *eventProcessorWrapper[T]) process(ctx *EventContext[T], record IncomingRecord) ExecutionState {
-	if record.RecordType() == ep.eventType {
-		return ep.exec(ctx, record)
-	}
-	if ep.next != nil {
-		return ep.next.process(ctx, record)
+func newEventProcessorExecutor[T any, V any](decoder IncomingRecordDecoder[V],
+	eventProcessor EventProcessor[T, V], deserializationErrorHandler DeserializationErrorHandler) *eventProcessorExecutor[T, V] {
+	return &eventProcessorExecutor[T, V]{
+		process:                    eventProcessor,
+		decode:                     decoder,
+		handleDeserializationError: deserializationErrorHandler,
 	}
-	return unknownType
 }
@@ -15,10 +15,14 @@
 package streams
 
 import (
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/aws/go-kafka-event-source/streams/sak"
 	"github.com/google/btree"
+	"github.com/google/uuid"
+	"github.com/twmb/franz-go/pkg/kadm"
 )
 
 func TestEventSourceInsert(t *testing.T) {
@@ -115,3 +119,110 @@ func TestEventSourceDelete(t *testing.T) {
 		t.Errorf("incorrect number of stores. actual: %d, expected: %d", len(trees), es.consumer.source.Config().NumPartitions)
 	}
 }
+
+func TestEventSourceMultipleTopics(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+		return
+	}
+
+	itemCount := 1000 //must be multiple of 10 for this to work
+
+	c := make(chan string)
+	cfg := testTopicConfig()
+	secondTopic := cfg.Topic + "_second"
+	cfg.Topics = []string{secondTopic}
+	committedMux := sync.Mutex{}
+	committed := make(map[string]int)
+	cfg.MetricsHandler = func(m Metric) {
+		if m.Operation == PartitionCommitOperation {
+			committedMux.Lock()
+			committed[m.Topic] += m.Count
+			committedMux.Unlock()
+		}
+	}
+	es := sak.Must(NewEventSource(cfg, NewIntStore, defaultTestHandler))
+	RegisterEventType(es, func(ir IncomingRecord) (string, error) {
+		return string(ir.Value()), nil
+	}, func(ec *EventContext[intStore], v string) ExecutionState {
+		c <- v
+		return Complete
+	}, "verify")
+	topics := es.source.Topics()
+	if len(topics) != 2 || topics[0] != cfg.Topic || topics[1] != secondTopic {
+		t.Fatalf("incorrect topics: %v", topics)
+	}
+
+	first := testProducer{NewProducer(es.source.AsDestination())}
+	second := testProducer{NewProducer(Destination{
+		DefaultTopic:  secondTopic,
+		NumPartitions: es.source.NumPartitions(),
+		Cluster:       testCluster,
+	})}
+	// keys are partitioned by k % 10, so each partition receives items from both topics
+	for i := 0; i < itemCount; i++ {
+		first.produce(t, "int", i, i)
+		second.produce(t, "int", i+itemCount, i+itemCount)
+	}
+
+	es.ConsumeEvents()
+	defer es.StopNow()
+	first.waitForAllPartitions(t, c, defaultTestTimeout)
+	second.waitForAllPartitions(t, c, defaultTestTimeout)
+
+	stores := 0
+	es.InterjectAllSync(func(ec *EventContext[intStore], _ time.Time) ExecutionState {
+		stores++
+		if l := ec.Store().tree.Len(); l != 2*itemCount/10 {
+			t.Errorf("incorrect number of items in partition. actual: %d, expected: %d", l, 2*itemCount/10)
+		}
+		return Complete
+	})
+	if stores != es.source.NumPartitions() {
+		t.Errorf("incorrect number of stores. actual: %d, expected: %d", stores, es.source.NumPartitions())
+	}
+
+	// each partition of each topic received itemCount/10 items and a verification signal
+	expectedWatermark := int64(itemCount/10 + 1)
+	deadline := time.Now().Add(defaultTestTimeout)
+	for _, topic := range topics {
+		for p := int32(0); p < int32(es.source.NumPartitions()); p++ {
+			tp := ntp(p, topic)
+			for es.consumer.commitLog.Watermark(tp) < expectedWatermark {
+				if time.Now().After(deadline) {
+					t.Fatalf("incorrect commit log watermark for %+v. actual: %d, expected: %d",
+						tp, es.consumer.commitLog.Watermark(tp), expectedWatermark)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+	}
+
+	// commit metrics are labelled with the topic of the input records
+	for _, topic := range topics {
+		for {
+			committedMux.Lock()
+			count := committed[topic]
+			committedMux.Unlock()
+			if count >= itemCount {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("incorrect committed record count for %s. actual: %d, expected at least: %d", topic, count, itemCount)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// additional topics must be co-partitioned
+	mismatched := uuid.NewString()
+	client := sak.Must(NewClient(testCluster))
+	defer client.Close()
+	if err := createTopic(kadm.NewClient(client), 3, 1, 1, DeleteCleanupPolicy, 1, mismatched); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Topics = []string{mismatched}
+	if _, err := CreateSource(cfg); err == nil {
+		t.Errorf("expected partition count mismatch error")
+	}
+}
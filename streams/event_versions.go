@@ -0,0 +1,121 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Passed to the DeserializationErrorHandler for records with a version which has no decoder, when EventVersions.WithUnknownVersionError is used.
+var ErrUnknownRecordVersion = errors.New("unknown record version")
+
+/*
+EventVersions decodes every version of an evolving event payload into the current type V, so that a single EventProcessor
+handles all of them. The version of an IncomingRecord is read from the RecordVersionHeaderKey header (see [Record.WithRecordVersion]).
+Records without a version header are version 0.
+
+Older versions are registered with an upcaster, which decodes the old payload and transforms it into a V.
+[Upcast] builds an upcaster from a decoder of the old type, and upcasters may be chained to avoid rewriting old transformations
+each time a new version is introduced:
+
+	versions := streams.NewEventVersions(3, codecV3.DecodeRecord).
+		WithUpcaster(2, streams.Upcast(codecV2.DecodeRecord, orderV2ToV3)).
+		WithUpcaster(1, streams.Upcast(streams.Upcast(codecV1.DecodeRecord, orderV1ToV2), orderV2ToV3))
+	streams.RegisterVersionedEventType(eventSource, versions, handleOrderCreated, "orderCreated")
+
+By default, records with a version which has no decoder (typically a version newer than the application) are passed to the default processor
+of the EventSource, as is done for records of an unregistered type. Use WithUnknownVersionError to pass them to the DeserializationErrorHandler instead.
+*/
+type EventVersions[V any] struct {
+	version             int
+	decoders            map[int]IncomingRecordDecoder[V]
+	unknownVersionError bool
+}
+
+// Creates EventVersions where `version` is the current version of the event, decoded with `decoder`.
+func NewEventVersions[V any](version int, decoder IncomingRecordDecoder[V]) *EventVersions[V] {
+	return &EventVersions[V]{
+		version:  version,
+		decoders: map[int]IncomingRecordDecoder[V]{version: decoder},
+	}
+}
+
+// Registers `upcaster` to decode records of an older `version` into the current type.
+// Must not be called after the EventVersions have been registered.
+func (ev *EventVersions[V]) WithUpcaster(version int, upcaster IncomingRecordDecoder[V]) *EventVersions[V] {
+	ev.decoders[version] = upcaster
+	return ev
+}
+
+// Records with a version which has no decoder are passed to the DeserializationErrorHandler with an error wrapping ErrUnknownRecordVersion,
+// rather than to the default processor. Useful in combination with [EventSourceConfig].DeadLetterDestination, so that newer events
+// can be replayed once the application has been upgraded.
+func (ev *EventVersions[V]) WithUnknownVersionError() *EventVersions[V] {
+	ev.unknownVersionError = true
+	return ev
+}
+
+// The current version of the event.
+func (ev *EventVersions[V]) Version() int {
+	return ev.version
+}
+
+// Decodes `record` with the decoder registered for it's version. Returns an error wrapping ErrUnknownRecordVersion if there is none.
+// Conforms to the IncomingRecordDecoder interface, should you wish to use EventVersions outside of an EventSource.
+func (ev *EventVersions[V]) Decode(record IncomingRecord) (V, error) {
+	if decode, ok := ev.decoders[record.RecordVersion()]; ok {
+		return decode(record)
+	}
+	var v V
+	return v, ev.unknownVersion(record)
+}
+
+func (ev *EventVersions[V]) unknownVersion(record IncomingRecord) error {
+	return fmt.Errorf("%w: %d for %s, current version: %d", ErrUnknownRecordVersion, record.RecordVersion(), record.RecordType(), ev.version)
+}
+
+// Creates an upcaster which decodes a record with `decoder` and transforms the result with `upcast`.
+func Upcast[O any, V any](decoder IncomingRecordDecoder[O], upcast func(O) V) IncomingRecordDecoder[V] {
+	return func(record IncomingRecord) (V, error) {
+		o, err := decoder(record)
+		if err != nil {
+			var v V
+			return v, err
+		}
+		return upcast(o), nil
+	}
+}
+
+// As RegisterEventType, but every version of eventType is decoded into V via `versions` before `eventProcessor` is invoked.
+// Must not be called after `EventSource.ConsumeEvents()`
+func RegisterVersionedEventType[T StateStore, V any](es *EventSource[T], versions *EventVersions[V], eventProcessor EventProcessor[T, V], eventType string) {
+	es.addProcessor(eventType, &versionedEventExecutor[T, V]{
+		eventProcessorExecutor: *newEventProcessorExecutor(versions.Decode, eventProcessor, es.source.deserializationErrorHandler()),
+		versions:               versions,
+	})
+}
+
+type versionedEventExecutor[T any, V any] struct {
+	eventProcessorExecutor[T, V]
+	versions *EventVersions[V]
+}
+
+func (vee *versionedEventExecutor[T, V]) Exec(ec *EventContext[T], record IncomingRecord) ExecutionState {
+	if _, ok := vee.versions.decoders[record.RecordVersion()]; !ok && !vee.versions.unknownVersionError {
+		return unknownType
+	}
+	return vee.eventProcessorExecutor.Exec(ec, record)
+}
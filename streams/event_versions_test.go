@@ -0,0 +1,112 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"errors"
+	"testing"
+)
+
+// version 0 values were recorded in tens, version 1 in units
+func scaleIntStoreItem(item intStoreItem) intStoreItem {
+	item.Value *= 10
+	return item
+}
+
+func TestEventVersions(t *testing.T) {
+	var deserializationErr error
+	driver := NewTestDriver(EventSourceConfig{
+		GroupId:       "versions_group",
+		Topic:         "versions_topic",
+		NumPartitions: 1,
+		DeserializationErrorHandler: func(ec ErrorContext, eventType string, err error) ErrorResponse {
+			deserializationErr = err
+			return Continue
+		},
+	}, NewIntStore, defaultTestHandler)
+	defer driver.Close()
+
+	var versions []int
+	RegisterVersionedEventType(driver.EventSource(),
+		NewEventVersions(2, decodeIntStoreItem).
+			WithUpcaster(1, decodeIntStoreItem).
+			WithUpcaster(0, Upcast(decodeIntStoreItem, scaleIntStoreItem)),
+		func(ec *EventContext[intStore], item intStoreItem) ExecutionState {
+			input, _ := ec.Input()
+			versions = append(versions, input.RecordVersion())
+			ec.Store().add(item)
+			return Complete
+		}, "versioned")
+	RegisterVersionedEventType(driver.EventSource(),
+		NewEventVersions(1, decodeIntStoreItem).WithUnknownVersionError(),
+		func(ec *EventContext[intStore], item intStoreItem) ExecutionState {
+			t.Errorf("unexpected event: %+v", item)
+			return Complete
+		}, "strict")
+
+	driver.Pipe(0,
+		intRecord("versioned", 1, 1),
+		intRecord("versioned", 2, 2).WithRecordVersion(1),
+		intRecord("versioned", 3, 3).WithRecordVersion(2))
+
+	if len(versions) != 3 || versions[0] != 0 || versions[1] != 1 || versions[2] != 2 {
+		t.Errorf("incorrect versions processed: %v", versions)
+	}
+	for key, expected := range map[int]int{1: 10, 2: 2, 3: 3} {
+		item, ok := driver.Store(0).tree.Get(intStoreItem{Key: key})
+		if !ok || item.Value != expected {
+			t.Errorf("incorrect value for key %d. actual: %+v, expected: %d", key, item, expected)
+		}
+	}
+
+	driver.Pipe(0, intRecord("versioned", 4, 4).WithRecordVersion(3))
+	if len(versions) != 3 {
+		t.Errorf("unknown version was processed: %v", versions)
+	}
+	if changeLog := driver.ChangeLog(0); len(changeLog) != 1 || changeLog[0].RecordType() != "defaultHandler" {
+		t.Errorf("unknown version not passed to default handler: %+v", changeLog)
+	}
+
+	if state := driver.Pipe(0, intRecord("strict", 5, 5).WithRecordVersion(2)); state != Complete {
+		t.Errorf("incorrect execution state. actual: %v, expected: %v", state, Complete)
+	}
+	if !errors.Is(deserializationErr, ErrUnknownRecordVersion) {
+		t.Errorf("incorrect deserialization error. actual: %v, expected: %v", deserializationErr, ErrUnknownRecordVersion)
+	}
+	if len(driver.ChangeLog(0)) != 1 {
+		t.Errorf("unknown strict version passed to default handler")
+	}
+}
+
+func TestRecordVersionHeader(t *testing.T) {
+	record := NewRecord().WithRecordType("versioned").WithRecordVersion(12)
+	defer record.Release()
+	if version := record.AsIncomingRecord().RecordVersion(); version != 12 {
+		t.Errorf("incorrect version. actual: %d, expected: %d", version, 12)
+	}
+	incoming := newIncomingRecord(record.toKafkaRecord())
+	if incoming.RecordType() != "versioned" || incoming.RecordVersion() != 12 {
+		t.Errorf("incorrect incoming record. type: %s, version: %d", incoming.RecordType(), incoming.RecordVersion())
+	}
+	kRecord := NewRecord().ToKafkaRecord()
+	SetRecordVersion(kRecord, 3)
+	if version := newIncomingRecord(kRecord).RecordVersion(); version != 3 {
+		t.Errorf("incorrect version. actual: %d, expected: %d", version, 3)
+	}
+	kRecord.Headers[0].Value = []byte("v3")
+	if version := newIncomingRecord(kRecord).RecordVersion(); version != -1 {
+		t.Errorf("incorrect version for invalid header. actual: %d, expected: %d", version, -1)
+	}
+}
@@ -28,6 +28,10 @@ type IncomingRecordDecoder[V any] func(IncomingRecord) (V, error)
 // A callback invoked when a new record has been received from the EventSource, after it has been transformed via IncomingRecordTransformer.
 type EventProcessor[T any, V any] func(*EventContext[T], V) ExecutionState
 
+// An EventProcessor which may fail. If a non-nil error is returned, the ExecutionState is ignored and the error is passed to
+// the EventSourceConfig.ProcessingErrorHandler. See RegisterFallibleEventType.
+type FallibleEventProcessor[T any, V any] func(*EventContext[T], V) (ExecutionState, error)
+
 type SourcePartitionEventHandler func(*Source, int32)
 
 type MetricsHandler func(Metric)
@@ -36,6 +40,9 @@ type DeserializationErrorHandler func(ec ErrorContext, eventType string, err err
 
 type TxnErrorHandler func(err error) ErrorResponse
 
+// Invoked with the failing event when processing returns [Fatal]. `eventType` is empty for Interjections.
+type ProcessingErrorHandler func(ec ErrorContext, eventType string, err error) ErrorResponse
+
 // A handler invoked when a previously scheduled AsyncJob should be performed.
 type AsyncJobProcessor[K comparable, V any] func(K, V) error
 
@@ -51,17 +51,21 @@ func NewGlobalChangeLog[T ChangeLogReceiver](cluster Cluster, receiver T, numPar
 
 // Creates a NewGlobalChangeLog consumer and forward all records to `receiver`.
 func NewGlobalChangeLogWithRunStatus[T ChangeLogReceiver](runStatus sak.RunStatus, cluster Cluster, receiver T, numPartitions int, topic string, cleanupPolicy CleanupPolicy) GlobalChangeLog[T] {
+	return newGlobalChangeLog(runStatus, cluster, receiver, numPartitions, topic, cleanupPolicy)
+}
+
+func newGlobalChangeLog[T ChangeLogReceiver](runStatus sak.RunStatus, cluster Cluster, receiver T, numPartitions int, topic string, cleanupPolicy CleanupPolicy, options ...kgo.Opt) GlobalChangeLog[T] {
 	assignments := make(map[int32]kgo.Offset)
 	for i := 0; i < numPartitions; i++ {
 		assignments[int32(i)] = kgo.NewOffset().AtStart()
 	}
-	client, err := NewClient(
-		cluster,
+	options = append([]kgo.Opt{
 		kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{
 			topic: assignments,
 		}),
 		kgo.RecordPartitioner(kgo.ManualPartitioner()),
-	)
+	}, options...)
+	client, err := NewClient(cluster, options...)
 
 	if err != nil {
 		panic(err)
@@ -128,7 +132,19 @@ func (cl GlobalChangeLog[T]) consume() {
 	cl.client.Close()
 }
 
+// An optional extension of ChangeLogReceiver which is notified of the offset of every record consumed, including control records
+// if the client was created with kgo.KeepControlRecords().
+type offsetReceiver interface {
+	receivedOffset(partition int32, offset int64)
+}
+
 func (cl GlobalChangeLog[T]) forwardChange(r *kgo.Record) {
+	if or, ok := any(cl.receiver).(offsetReceiver); ok {
+		defer or.receivedOffset(r.Partition, r.Offset)
+	}
+	if r.Attrs.IsControl() {
+		return
+	}
 	ir := newIncomingRecord(r)
 	if err := cl.receiver.ReceiveChange(ir); err != nil {
 		log.Errorf("GlobalChangeLog error for %+v, offset: %d, recordType: %s, error: %v", ir.TopicPartition(), ir.Offset(), ir.RecordType(), err)
@@ -0,0 +1,211 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams/sak"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+/*
+A GlobalTable is a typed, read-only lookup table which continuously consumes every partition of a (usually compacted) topic.
+Record keys are decoded with a Codec[K] and values with a Codec[V]. A record with an empty value deletes the key.
+GlobalTables are useful for joining a stream against reference data which is small enough to fit in memory on every host.
+
+Get may be invoked concurrently from any go-routine, including EventProcessors for any partition.
+Since the GlobalTable is not part of a transaction, there is no guarantee as to which version of a value a processor will see.
+
+	table, err := streams.NewGlobalTable(cluster, "customers", streams.StringCodec, streams.JsonCodec[Customer]{})
+	if err != nil {
+		...
+	}
+	table.Start()
+	<-table.Ready()
+	eventSource.ConsumeEvents()
+*/
+type GlobalTable[K comparable, V any] struct {
+	changeLog  GlobalChangeLog[*GlobalTable[K, V]]
+	data       map[K]V
+	keyCodec   Codec[K]
+	valueCodec Codec[V]
+	// the last stable offset for each partition at the time the table was created
+	targets   map[int32]int64
+	ready     chan struct{}
+	mux       sync.RWMutex
+	targetMux sync.Mutex
+}
+
+// Creates a GlobalTable for `topic`. The topic must already exist.
+func NewGlobalTable[K comparable, V any](cluster Cluster, topic string, keyCodec Codec[K], valueCodec Codec[V]) (*GlobalTable[K, V], error) {
+	targets, err := globalTableTargets(cluster, topic)
+	if err != nil {
+		return nil, err
+	}
+	gt := &GlobalTable[K, V]{
+		data:       make(map[K]V),
+		keyCodec:   keyCodec,
+		valueCodec: valueCodec,
+		targets:    make(map[int32]int64, len(targets)),
+		ready:      make(chan struct{}),
+	}
+	for p, target := range targets {
+		if target > 0 {
+			gt.targets[p] = target
+		}
+	}
+	// control records let us know when we have reached the last stable offset, even if the topic is written transactionally
+	gt.changeLog = newGlobalChangeLog(sak.NewRunStatus(context.Background()), cluster, gt, len(targets), topic, CompactCleanupPolicy,
+		kgo.KeepControlRecords())
+	if len(gt.targets) == 0 {
+		close(gt.ready)
+	}
+	return gt, nil
+}
+
+// Returns the offset each partition of `topic` must reach for a GlobalTable to be ready.
+// Partitions which contain no records have a target of 0.
+func globalTableTargets(cluster Cluster, topic string) (map[int32]int64, error) {
+	client, err := NewClient(cluster)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	adminClient := kadm.NewClient(client)
+	starts, err := adminClient.ListStartOffsets(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	ends, err := adminClient.ListCommittedOffsets(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	if err = ends.Error(); err != nil {
+		return nil, err
+	}
+	targets := make(map[int32]int64)
+	ends.Each(func(end kadm.ListedOffset) {
+		target := end.Offset
+		if start, ok := starts.Lookup(topic, end.Partition); ok && start.Offset >= end.Offset {
+			target = 0
+		}
+		targets[end.Partition] = target
+	})
+	return targets, nil
+}
+
+// Starts consuming the table topic.
+func (gt *GlobalTable[K, V]) Start() {
+	gt.changeLog.Start()
+}
+
+// Stops consuming the table topic. The contents of the table remain available.
+func (gt *GlobalTable[K, V]) Stop() {
+	gt.changeLog.Stop()
+}
+
+// Returns a channel which is closed once every partition of the table topic has been consumed up to the last stable offset
+// it had when the GlobalTable was created.
+func (gt *GlobalTable[K, V]) Ready() <-chan struct{} {
+	return gt.ready
+}
+
+// Returns the value for `key`.
+func (gt *GlobalTable[K, V]) Get(key K) (val V, ok bool) {
+	gt.mux.RLock()
+	defer gt.mux.RUnlock()
+	val, ok = gt.data[key]
+	return
+}
+
+// Returns the number of keys in the table.
+func (gt *GlobalTable[K, V]) Len() int {
+	gt.mux.RLock()
+	defer gt.mux.RUnlock()
+	return len(gt.data)
+}
+
+// Needed to fulfill the ChangeLogReceiver interface. Should NOT be invoked directly.
+func (gt *GlobalTable[K, V]) ReceiveChange(record IncomingRecord) error {
+	key, err := gt.keyCodec.Decode(record.Key())
+	if err != nil {
+		return err
+	}
+	if len(record.Value()) == 0 {
+		gt.mux.Lock()
+		delete(gt.data, key)
+		gt.mux.Unlock()
+		return nil
+	}
+	val, err := gt.valueCodec.Decode(record.Value())
+	if err != nil {
+		return err
+	}
+	gt.mux.Lock()
+	gt.data[key] = val
+	gt.mux.Unlock()
+	return nil
+}
+
+func (gt *GlobalTable[K, V]) receivedOffset(partition int32, offset int64) {
+	gt.targetMux.Lock()
+	defer gt.targetMux.Unlock()
+	target, ok := gt.targets[partition]
+	if !ok || offset+1 < target {
+		return
+	}
+	delete(gt.targets, partition)
+	if len(gt.targets) == 0 {
+		close(gt.ready)
+	}
+}
+
+// The input to an EventProcessor created by [Enrich]. Found is false if the table did not contain the joined key, in which case Value is the zero value.
+type Enriched[E any, V any] struct {
+	Event E
+	Value V
+	Found bool
+}
+
+/*
+Enrich returns an EventProcessor which joins each event against `table`, using the key returned by `key`,
+and passes the result to `processor`. Enrich can be used with RegisterEventType or as the default processor of an EventSource.
+
+	streams.RegisterEventType(eventSource, decodeOrder, streams.Enrich(customers, func(o Order) string {
+		return o.CustomerId
+	}, func(ec *streams.EventContext[myStore], enriched streams.Enriched[Order, Customer]) streams.ExecutionState {
+		if !enriched.Found {
+			// inner join semantics, drop the order
+			return streams.Complete
+		}
+		...
+	}), "Order")
+*/
+func Enrich[T any, E any, K comparable, V any](table *GlobalTable[K, V], key func(E) K, processor EventProcessor[T, Enriched[E, V]]) EventProcessor[T, E] {
+	return func(ec *EventContext[T], event E) ExecutionState {
+		val, found := table.Get(key(event))
+		return processor(ec, Enriched[E, V]{
+			Event: event,
+			Value: val,
+			Found: found,
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams/sak"
+	"github.com/google/uuid"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func produceTableRecords(t *testing.T, client *kgo.Client, topic string, partitions int32, values map[string]int) {
+	for k, v := range values {
+		record := kgo.KeyStringRecord(k, "")
+		record.Topic = topic
+		record.Partition = int32(len(k)) % partitions
+		// a negative value produces a tombstone
+		if v >= 0 {
+			buf := bytes.NewBuffer(nil)
+			IntCodec.Encode(buf, v)
+			record.Value = buf.Bytes()
+		}
+		if err := client.ProduceSync(context.Background(), record).FirstErr(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGlobalTable(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+		return
+	}
+	topic := "global_table_" + uuid.NewString()
+	// partitioned by key length, so partition 3 remains empty
+	partitions := int32(4)
+	client := sak.Must(NewClient(testCluster, kgo.RecordPartitioner(kgo.ManualPartitioner())))
+	defer client.Close()
+	if err := createTopic(kadm.NewClient(client), int(partitions), 1, 1, CompactCleanupPolicy, 0.5, topic); err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]int{}
+	for i := 0; i < 30; i++ {
+		values[fmt.Sprintf("k%d", i)] = i
+	}
+	produceTableRecords(t, client, topic, partitions, values)
+	produceTableRecords(t, client, topic, partitions, map[string]int{"k0": -1})
+
+	table := sak.Must(NewGlobalTable[string, int](testCluster, topic, StringCodec, IntCodec))
+	table.Start()
+	defer table.Stop()
+	select {
+	case <-table.Ready():
+	case <-time.After(defaultTestTimeout):
+		t.Fatalf("table did not become ready")
+	}
+	if l := table.Len(); l != 29 {
+		t.Errorf("incorrect table size. actual: %d, expected: %d", l, 29)
+	}
+	if v, ok := table.Get("k5"); !ok || v != 5 {
+		t.Errorf("incorrect value. actual: %d, %v, expected: %d", v, ok, 5)
+	}
+	if _, ok := table.Get("k0"); ok {
+		t.Errorf("deleted key should not be present")
+	}
+
+	produceTableRecords(t, client, topic, partitions, map[string]int{"k1": 100})
+	deadline := time.Now().Add(defaultTestTimeout)
+	for v, _ := table.Get("k1"); v != 100; v, _ = table.Get("k1") {
+		if time.Now().After(deadline) {
+			t.Fatalf("update not received. actual: %d, expected: %d", v, 100)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	processor := Enrich(table, func(key string) string {
+		return key
+	}, func(_ *EventContext[intStore], enriched Enriched[string, int]) ExecutionState {
+		expected, ok := map[string]int{"k2": 2}[enriched.Event]
+		if enriched.Found != ok || enriched.Value != expected {
+			t.Errorf("incorrect join for %s: %+v", enriched.Event, enriched)
+		}
+		return Complete
+	})
+	for _, key := range []string{"k2", "k0", "missing"} {
+		processor(nil, key)
+	}
+}
@@ -10,6 +10,7 @@ require (
 	github.com/twmb/franz-go v1.8.0 // direct
 	github.com/twmb/franz-go/pkg/kadm v1.2.1 // direct
 	github.com/twmb/franz-go/pkg/kmsg v1.2.0 // direct
+	go.etcd.io/bbolt v1.3.9 // direct
 )
 
 require (
@@ -17,7 +18,8 @@ require (
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/pierrec/lz4/v4 v4.1.17 // indirect
-	github.com/stretchr/testify v1.8.0 // indirect
+	golang.org/x/crypto v0.0.0-20221005025214-4161e89ecf1b // indirect
+	golang.org/x/sys v0.10.0 // indirect
 )
 
 retract [v0.0.0-00000000000000-000000000000, v1.0.2]
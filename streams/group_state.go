@@ -16,7 +16,9 @@ package streams
 
 import (
 	"math/rand"
+	"sort"
 
+	"github.com/aws/go-kafka-event-source/streams/sak"
 	"github.com/google/btree"
 	"github.com/twmb/franz-go/pkg/kgo"
 	"github.com/twmb/franz-go/pkg/kmsg"
@@ -188,11 +190,11 @@ func (gs groupState) initReceivership(incrMem *incrGroupMember, member *kmsg.Joi
 }
 
 func (gs groupState) printGroupState(label string) {
-	log.Debugf(label)
+	log.Debugf("%s", label)
 	min, _ := gs.activeMembers.Min()
 	max, _ := gs.activeMembers.Max()
-	log.Debugf("min: ", min.member.MemberID)
-	log.Debugf("max: ", max.member.MemberID)
+	log.Debugf("min: %s", min.member.MemberID)
+	log.Debugf("max: %s", max.member.MemberID)
 	gs.activeMembers.Ascend(func(incrMem *incrGroupMember) bool {
 		log.Debugf("%v", incrMem)
 		return true
@@ -201,7 +203,7 @@ func (gs groupState) printGroupState(label string) {
 		log.Debugf("%v", incrMem)
 		return true
 	})
-	log.Debugf(label)
+	log.Debugf("%s", label)
 }
 
 func (gs groupState) balance(shifts int) bool {
@@ -270,19 +272,70 @@ func (gs groupState) distributeUnassigned() int {
 		// start here. If there was an abnormal shutdown, nobidy may be ready for this partition
 		// in this case, we're sending the partition to the consumer with the least #partitions
 		recipient, _ := gs.activeMembers.Min()
+		var standbyHolder *incrGroupMember
+		prepared := false
 		// first check to see if there is any member who is ready, or getting ready for this partition
 		gs.activeMembers.Ascend(func(candidate *incrGroupMember) bool {
 			if candidate.meta.isPreparingOrReadyFor(p, gs.topic) {
 				recipient = candidate
+				prepared = true
 				return false
 			}
+			if standbyHolder == nil && candidate.meta.isStandbyFor(p, gs.topic) {
+				standbyHolder = candidate
+			}
 			return true
 		})
+		// failing that, a member with a warm standby copy is the next best thing
+		if !prepared && standbyHolder != nil {
+			recipient = standbyHolder
+		}
 		gs.assignToActiveMember(p, recipient)
 	}
 	return len(gs.unassigned)
 }
 
+// chooses `replicas` active members, other than the owner, to maintain a standby copy of each partition
+// members which already hold a standby copy are preferred, otherwise standbys are spread evenly
+func (gs groupState) assignStandbys(replicas int) {
+	members := make([]*incrGroupMember, 0, gs.activeMembers.Len())
+	owners := make(map[int32]*incrGroupMember, gs.partitionCount)
+	gs.activeMembers.Ascend(func(mem *incrGroupMember) bool {
+		members = append(members, mem)
+		mem.assignments.Ascend(func(p int32) bool {
+			owners[p] = mem
+			return true
+		})
+		return true
+	})
+	replicas = sak.Min(replicas, len(members)-1)
+	if replicas <= 0 {
+		return
+	}
+	standbyCounts := make(map[*incrGroupMember]int, len(members))
+	candidates := make([]*incrGroupMember, 0, len(members))
+	for p := int32(0); p < gs.partitionCount; p++ {
+		candidates = candidates[:0]
+		for _, mem := range members {
+			if mem != owners[p] {
+				candidates = append(candidates, mem)
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			a, b := candidates[i], candidates[j]
+			aHolds, bHolds := a.meta.isStandbyFor(p, gs.topic), b.meta.isStandbyFor(p, gs.topic)
+			if aHolds != bHolds {
+				return aHolds
+			}
+			return standbyCounts[a] < standbyCounts[b]
+		})
+		for _, mem := range candidates[:sak.Min(replicas, len(candidates))] {
+			mem.instructions.Standby = append(mem.instructions.Standby, ntp(p, gs.topic))
+			standbyCounts[mem]++
+		}
+	}
+}
+
 func (gs groupState) deliverScheduledPartitionMoves() {
 	// we're assigning all topics for the same partition
 	fullyReady := make(map[int32]*incrGroupMember)
@@ -20,6 +20,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/aws/go-kafka-event-source/streams/sak"
 	"github.com/google/btree"
 	"github.com/twmb/franz-go/pkg/kgo"
 	"github.com/twmb/franz-go/pkg/kmsg"
@@ -53,6 +54,8 @@ const (
 type IncrGroupMemberInstructions struct {
 	Prepare []TopicPartition
 	Forget  []TopicPartition // not currently used
+	// the complete set of partitions for which the member should maintain a standby copy
+	Standby []TopicPartition
 }
 
 type IncrGroupPartitionState struct {
@@ -61,10 +64,12 @@ type IncrGroupPartitionState struct {
 }
 
 type IncrGroupMemberMeta struct {
-	Preparing []TopicPartition
-	Ready     []TopicPartition
-	Status    MemberStatus
-	LeftAt    int64
+	Preparing      []TopicPartition
+	Ready          []TopicPartition
+	Status         MemberStatus
+	LeftAt         int64
+	AdvertisedAddr string
+	Standby        []TopicPartition
 }
 
 func (igmm IncrGroupMemberMeta) isPreparingOrReadyFor(partition int32, topic string) bool {
@@ -79,6 +84,10 @@ func (igmm IncrGroupMemberMeta) isPreparingFor(partition int32, topic string) bo
 	return containsTopicPartition(partition, topic, igmm.Preparing)
 }
 
+func (igmm IncrGroupMemberMeta) isStandbyFor(partition int32, topic string) bool {
+	return containsTopicPartition(partition, topic, igmm.Standby)
+}
+
 func containsTopicPartition(partition int32, topic string, tps []TopicPartition) bool {
 	tp := ntp(partition, topic)
 	for _, candidate := range tps {
@@ -153,12 +162,21 @@ func (ib incrementalBalanceController) Balance(cb *kgo.ConsumerBalancer, topicDa
 	plan := cb.NewPlan()
 	instructionsByMemberId := make(map[string]*IncrGroupMemberInstructions)
 
+	primary, mirrors := ib.coPartitionedTopics(topicData)
 	for topic, partitionCount := range topicData {
-		gs, imbalanced := ib.balanceTopic(cb, plan, partitionCount, topic)
+		if sak.Contains(mirrors, topic) {
+			continue
+		}
+		var mirroredBy []string
+		if topic == primary {
+			mirroredBy = mirrors
+		}
+		gs, imbalanced := ib.balanceTopic(cb, plan, partitionCount, topic, mirroredBy)
 		log.Infof("Group for %s is balanced: %v", topic, !imbalanced)
 		for memId, incrMem := range gs.members {
 			if instructions, ok := instructionsByMemberId[memId]; ok {
 				instructions.Prepare = append(instructions.Prepare, incrMem.instructions.Prepare...)
+				instructions.Standby = append(instructions.Standby, incrMem.instructions.Standby...)
 			} else {
 				instructions := new(IncrGroupMemberInstructions)
 				*instructions = incrMem.instructions
@@ -175,7 +193,8 @@ func (ib incrementalBalanceController) Balance(cb *kgo.ConsumerBalancer, topicDa
 	return planWrapper{plan.AsMemberIDMap(), instructionsByMemberId}
 }
 
-func (ib incrementalBalanceController) balanceTopic(cb *kgo.ConsumerBalancer, plan *kgo.BalancePlan, partitionCount int32, topic string) (groupState, bool) {
+// Balances `topic` and adds the resulting assignments to `plan` for `topic` and each of `mirrors`.
+func (ib incrementalBalanceController) balanceTopic(cb *kgo.ConsumerBalancer, plan *kgo.BalancePlan, partitionCount int32, topic string, mirrors []string) (groupState, bool) {
 
 	gs := newGroupState(cb, partitionCount, topic)
 
@@ -187,20 +206,49 @@ func (ib incrementalBalanceController) balanceTopic(cb *kgo.ConsumerBalancer, pl
 	}
 
 	imbalanced := gs.balance(ib.budget)
+	gs.assignStandbys(ib.standbyReplicas())
 	// finally add all our decisions to the balance plan
 	gs.inactiveMembers.Ascend(func(mem *incrGroupMember) bool {
 		// log.Debugf("assigned for inactive member: %d", items[0].assignments.Len())
-		addMemberToPlan(plan, mem)
+		addMemberToPlan(plan, mem, mirrors)
 		return true
 	})
 
 	gs.activeMembers.Ascend(func(mem *incrGroupMember) bool {
-		addMemberToPlan(plan, mem)
+		addMemberToPlan(plan, mem, mirrors)
 		return true
 	})
 	return gs, imbalanced
 }
 
+// Returns the topic to balance and the topics which mirror it's assignments. `mirrors` is empty unless the instructionHandler
+// is a coPartitioner consuming more than one of the topics in `topicData`.
+func (ib incrementalBalanceController) coPartitionedTopics(topicData map[string]int32) (primary string, mirrors []string) {
+	cp, ok := ib.instructionHandler.(coPartitioner)
+	if !ok {
+		return
+	}
+	for _, topic := range cp.CoPartitionedTopics() {
+		if _, ok := topicData[topic]; !ok {
+			continue
+		}
+		if len(primary) == 0 {
+			primary = topic
+		} else {
+			mirrors = append(mirrors, topic)
+		}
+	}
+	return
+}
+
+// The number of standby replicas to assign for each partition. Zero unless the instructionHandler is a standbyMaintainer.
+func (ib incrementalBalanceController) standbyReplicas() int {
+	if sm, ok := ib.instructionHandler.(standbyMaintainer); ok {
+		return sm.NumStandbyReplicas()
+	}
+	return 0
+}
+
 func (pw planWrapper) IntoSyncAssignment() []kmsg.SyncGroupRequestGroupAssignment {
 	kassignments := make([]kmsg.SyncGroupRequestGroupAssignment, 0, len(pw.plan))
 	for member, assignment := range pw.plan {
@@ -226,13 +274,16 @@ func (pw planWrapper) IntoSyncAssignment() []kmsg.SyncGroupRequestGroupAssignmen
 	return kassignments
 }
 
-func addMemberToPlan(plan *kgo.BalancePlan, mem *incrGroupMember) {
+func addMemberToPlan(plan *kgo.BalancePlan, mem *incrGroupMember, mirrors []string) {
 	partitions := make([]int32, 0, mem.assignments.Len())
 	mem.assignments.Ascend(func(key int32) bool {
 		partitions = append(partitions, key)
 		return true
 	})
 	plan.AddPartitions(mem.member, mem.topic, partitions)
+	for _, topic := range mirrors {
+		plan.AddPartitions(mem.member, topic, partitions)
+	}
 }
 
 type incrementalRebalancer struct {
@@ -243,6 +294,7 @@ type incrementalRebalancer struct {
 	leaveTime          int64
 	preparing          TopicPartitionSet
 	ready              TopicPartitionSet
+	standby            TopicPartitionSet
 	instructionHandler IncrRebalanceInstructionHandler
 	statusLock         sync.Mutex
 }
@@ -259,6 +311,26 @@ type IncrRebalanceInstructionHandler interface {
 	Client() *kgo.Client
 }
 
+// An optional extension of IncrRebalanceInstructionHandler. If implemented, the address is shared with other group members via IncrGroupMemberMeta.
+type advertiser interface {
+	AdvertisedAddr() string
+}
+
+// An optional extension of IncrRebalanceInstructionHandler. If implemented, the group leader assigns NumStandbyReplicas standby partitions
+// per partition to active group members. With every assignment, MaintainStandbyTopicPartitions is called with the complete set of
+// standby partitions for this member. Any standby partitions not in `tps` should be forgotten.
+type standbyMaintainer interface {
+	NumStandbyReplicas() int
+	MaintainStandbyTopicPartitions(tps []TopicPartition)
+}
+
+// An optional extension of IncrRebalanceInstructionHandler for handlers which consume more than one topic with the same number of partitions.
+// Only the first topic returned by CoPartitionedTopics is balanced, the assignments of the remaining topics mirror it,
+// so the same partition of every topic is always assigned to the same member.
+type coPartitioner interface {
+	CoPartitionedTopics() []string
+}
+
 // Creates an IncrementalRebalancer suitatble for use by the kgo Kafka driver. In most cases, the instructionHandler is the EventSource.
 // `activeTransitions` defines how many partitons may be in receivership at any given point in time.
 //
@@ -269,6 +341,7 @@ type IncrRebalanceInstructionHandler interface {
 //
 // In all cases, any unassigned partitions will be assigned immediately.
 // If a consumer host crashes, for example, it's partitions will be assigned immediately, regardless of preparation state.
+// If standby replicas are in use (see EventSourceConfig.NumStandbyReplicas), those partitions are preferably assigned to a member holding a standby copy.
 //
 // receivership - the state of being dealt with by an official receiver.
 func IncrementalRebalancer(instructionHandler IncrRebalanceInstructionHandler) IncrementalGroupRebalancer {
@@ -287,6 +360,7 @@ func IncrementalRebalancer(instructionHandler IncrRebalanceInstructionHandler) I
 		instructionHandler: instructionHandler,
 		preparing:          NewTopicPartitionSet(),
 		ready:              NewTopicPartitionSet(),
+		standby:            NewTopicPartitionSet(),
 	}
 }
 
@@ -396,6 +470,14 @@ func (ir *incrementalRebalancer) ParseSyncAssignment(assignment []byte) (map[str
 
 	}
 
+	if sm, ok := ir.instructionHandler.(standbyMaintainer); ok {
+		ir.standby = NewTopicPartitionSet()
+		for _, tp := range instructions.Standby {
+			ir.standby.Insert(tp)
+		}
+		sm.MaintainStandbyTopicPartitions(instructions.Standby)
+	}
+
 	for _, tp := range instructions.Prepare {
 		if ir.preparing.Insert(tp) {
 			go ir.instructionHandler.PrepareTopicPartition(tp)
@@ -410,10 +492,10 @@ func (ir *incrementalRebalancer) ParseSyncAssignment(assignment []byte) (map[str
 			prepping := ir.preparing.Remove(tp)
 			prepped := ir.ready.Remove(tp)
 			if prepping || prepped {
-				go func() {
+				go func(tp TopicPartition) {
 					ir.instructionHandler.ForgetPreparedTopicPartition(tp)
 					wg.Done()
-				}()
+				}(tp)
 			} else {
 				wg.Done()
 			}
@@ -452,14 +534,23 @@ func (ir *incrementalRebalancer) JoinGroupMetadata(interests []string, currentAs
 }
 
 func (ir *incrementalRebalancer) userData() []byte {
+	data, _ := json.Marshal(ir.memberMeta())
+	return data
+}
+
+// the metadata shared with other group members on each rebalance
+func (ir *incrementalRebalancer) memberMeta() IncrGroupMemberMeta {
 	ir.statusLock.Lock()
 	defer ir.statusLock.Unlock()
-	data, _ := json.Marshal(IncrGroupMemberMeta{
+	meta := IncrGroupMemberMeta{
 		Status:    ir.memberStatus,
 		LeftAt:    ir.leaveTime,
 		Preparing: ir.preparing.Items(),
 		Ready:     ir.ready.Items(),
-	})
-
-	return data
+		Standby:   ir.standby.Items(),
+	}
+	if a, ok := ir.instructionHandler.(advertiser); ok {
+		meta.AdvertisedAddr = a.AdvertisedAddr()
+	}
+	return meta
 }
@@ -0,0 +1,137 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+type standbyTestHandler struct {
+	replicas int
+}
+
+func (standbyTestHandler) PrepareTopicPartition(TopicPartition)            {}
+func (standbyTestHandler) ForgetPreparedTopicPartition(TopicPartition)     {}
+func (standbyTestHandler) Client() *kgo.Client                             { return nil }
+func (standbyTestHandler) MaintainStandbyTopicPartitions([]TopicPartition) {}
+func (h standbyTestHandler) NumStandbyReplicas() int {
+	return h.replicas
+}
+
+type coPartitionTestHandler struct {
+	standbyTestHandler
+	topics []string
+}
+
+func (h coPartitionTestHandler) CoPartitionedTopics() []string {
+	return h.topics
+}
+
+// `mirrors` are additional topics for which the member owns the same partitions
+func testGroupMember(id, topic string, owned []int32, meta IncrGroupMemberMeta, mirrors ...string) kmsg.JoinGroupResponseMember {
+	cmm := kmsg.NewConsumerMemberMetadata()
+	cmm.Version = 1
+	cmm.Topics = append([]string{topic}, mirrors...)
+	for _, t := range cmm.Topics {
+		ownedPartition := kmsg.NewConsumerMemberMetadataOwnedPartition()
+		ownedPartition.Topic = t
+		ownedPartition.Partitions = owned
+		cmm.OwnedPartitions = append(cmm.OwnedPartitions, ownedPartition)
+	}
+	cmm.UserData, _ = json.Marshal(meta)
+	member := kmsg.NewJoinGroupResponseMember()
+	member.MemberID = id
+	member.ProtocolMetadata = cmm.AppendTo(nil)
+	return member
+}
+
+func TestIncrementalRebalancerStandby(t *testing.T) {
+	topic := "standby"
+	// member "c" has crashed, leaving partitions 4 and 5 unassigned. "b" holds a standby copy of both
+	members := []kmsg.JoinGroupResponseMember{
+		testGroupMember("a", topic, []int32{0, 1}, IncrGroupMemberMeta{
+			Standby: toTopicPartitions(topic, 2),
+		}),
+		testGroupMember("b", topic, []int32{2, 3}, IncrGroupMemberMeta{
+			Standby: toTopicPartitions(topic, 0, 4, 5),
+		}),
+	}
+	controller := incrementalBalanceController{
+		budget:             1,
+		instructionHandler: standbyTestHandler{replicas: 1},
+	}
+	cb, err := kgo.NewConsumerBalancer(controller, members)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := controller.Balance(cb, map[string]int32{topic: 6}).(planWrapper)
+
+	assigned := plan.plan["b"][topic]
+	sort.Slice(assigned, func(i, j int) bool { return assigned[i] < assigned[j] })
+	if !reflect.DeepEqual(assigned, []int32{2, 3, 4, 5}) {
+		t.Errorf("incorrect assignment for standby holder. actual: %v, expected: %v", assigned, []int32{2, 3, 4, 5})
+	}
+
+	expected := map[string][]TopicPartition{
+		"a": toTopicPartitions(topic, 2, 3, 4, 5),
+		"b": toTopicPartitions(topic, 0, 1),
+	}
+	for member, tps := range expected {
+		if standby := plan.instructions[member].Standby; !reflect.DeepEqual(standby, tps) {
+			t.Errorf("incorrect standby instructions for %s. actual: %v, expected: %v", member, standby, tps)
+		}
+	}
+}
+
+func TestIncrementalRebalancerCoPartitioned(t *testing.T) {
+	// member "c" has crashed, leaving partitions 4 and 5 of both topics unassigned
+	members := []kmsg.JoinGroupResponseMember{
+		testGroupMember("a", "left", []int32{0, 1}, IncrGroupMemberMeta{}, "right"),
+		testGroupMember("b", "left", []int32{2, 3}, IncrGroupMemberMeta{}, "right"),
+	}
+	controller := incrementalBalanceController{
+		budget:             1,
+		instructionHandler: coPartitionTestHandler{topics: []string{"left", "right"}},
+	}
+	cb, err := kgo.NewConsumerBalancer(controller, members)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := controller.Balance(cb, map[string]int32{"left": 6, "right": 6}).(planWrapper)
+
+	assignedCount := 0
+	for member, assignment := range plan.plan {
+		left, right := assignment["left"], assignment["right"]
+		sort.Slice(left, func(i, j int) bool { return left[i] < left[j] })
+		sort.Slice(right, func(i, j int) bool { return right[i] < right[j] })
+		if !reflect.DeepEqual(left, right) {
+			t.Errorf("co-partitioned assignments differ for %s. left: %v, right: %v", member, left, right)
+		}
+		assignedCount += len(left)
+		for _, tp := range plan.instructions[member].Prepare {
+			if tp.Topic != "left" {
+				t.Errorf("instructed to prepare mirrored topic: %+v", tp)
+			}
+		}
+	}
+	if assignedCount != 6 {
+		t.Errorf("incorrect assigned partition count. actual: %d, expected: %d", assignedCount, 6)
+	}
+}
@@ -36,10 +36,6 @@ type interjection[T any] struct {
 	cancelOnce       sync.Once
 }
 
-func (ij *interjection[T]) interject(ec *EventContext[T]) ExecutionState {
-	return ij.interjector(ec, time.Now())
-}
-
 func (ij *interjection[T]) init(tp TopicPartition, c chan *interjection[T]) {
 	ij.initOnce.Do(func() {
 		ij.topicPartition = tp
@@ -0,0 +1,80 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package testbroker runs the in-memory broker of the streamstest module in a child process, for the tests of the streams module.
+
+streamstest is a separate module, so that the Go and franz-go versions required by kfake do not apply to applications using streams.
+The streams module can not import it without inheriting those requirements, so its tests run the streamstest command via `go run` instead,
+which resolves the streamstest module independently.
+*/
+package testbroker
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+type Broker struct {
+	addrs []string
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+// Starts the streamstest command and waits for its brokers to listen. `moduleDir` is the path to the streamstest module,
+// relative to the working directory of the test.
+func Start(moduleDir string) (*Broker, error) {
+	cmd := exec.Command("go", "run", "./cmd/streamstest")
+	cmd.Dir = moduleDir
+	// streamstest is not part of the workspace suggested for developing the integration modules
+	cmd.Env = append(os.Environ(), "GOWORK=off")
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		stdin.Close()
+		cmd.Wait()
+		return nil, fmt.Errorf("streamstest did not start: %w", err)
+	}
+	return &Broker{
+		addrs: strings.Split(strings.TrimSpace(line), ","),
+		cmd:   cmd,
+		stdin: stdin,
+	}, nil
+}
+
+// The host:port addresses the in-memory brokers are listening on.
+func (b *Broker) Addrs() []string {
+	return b.addrs
+}
+
+// Closes the standard input of the streamstest command, which stops the brokers, and waits for it to exit.
+func (b *Broker) Close() {
+	b.stdin.Close()
+	b.cmd.Wait()
+}
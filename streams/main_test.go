@@ -23,6 +23,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/go-kafka-event-source/streams/internal/testbroker"
 	"github.com/aws/go-kafka-event-source/streams/sak"
 	"github.com/google/btree"
 	"github.com/google/uuid"
@@ -33,6 +34,8 @@ const kafkaCleanupScript = "kafka_local/cleanup.sh"
 const kafkaDownloadScript = "kafka_local/download-kafka.sh"
 const kafkaWorkingDir = "kafka_local/kafka"
 
+var useLocalKafka = flag.Bool("kafka-local", false, "run tests against a downloaded Kafka distribution rather than the in-memory broker")
+
 func TestMain(m *testing.M) {
 	flag.Parse()
 	InitLogger(SimpleLogger(LogLevelError), LogLevelError)
@@ -40,6 +43,11 @@ func TestMain(m *testing.M) {
 		os.Exit(m.Run())
 		return
 	}
+	if !*useLocalKafka {
+		os.Exit(runWithInMemoryCluster(m))
+		return
+	}
+	testCluster = SimpleCluster([]string{"127.0.0.1:9092"})
 	// cleanup data logs in case we exited abnormally
 	if err := exec.Command("sh", kafkaCleanupScript).Run(); err != nil {
 		fmt.Println(err)
@@ -80,6 +88,17 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
+func runWithInMemoryCluster(m *testing.M) int {
+	broker, err := testbroker.Start("streamstest")
+	if err != nil {
+		fmt.Println("in-memory broker: ", err)
+		return 1
+	}
+	defer broker.Close()
+	testCluster = SimpleCluster(broker.Addrs())
+	return m.Run()
+}
+
 func kafkaScriptCommand(program, command string) *exec.Cmd {
 	return exec.Command("sh", kafkaProgramScript, kafkaWorkingDir, program, command)
 }
@@ -161,7 +180,7 @@ func (s intStore) Revoked() {
 	s.tree.Clear(false)
 }
 
-var testCluster = SimpleCluster([]string{"127.0.0.1:9092"})
+var testCluster Cluster
 
 func testTopicConfig() EventSourceConfig {
 	topicName := uuid.NewString()
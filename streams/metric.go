@@ -19,6 +19,23 @@ import "time"
 const TxnCommitOperation = "TxnCommit"
 const PartitionPreppedOperation = "PartitionPrepped"
 
+// Emitted for each topic partition with events in a committed transaction. Count and Bytes are the number and size of the input records committed.
+// Topic is the topic of the input records, which may be any of the co-partitioned source topics or retry topics of the EventSource.
+const PartitionCommitOperation = "PartitionCommit"
+
+// Emitted whenever partitions are assigned or revoked. PartitionCount is the number of partitions currently assigned.
+const PartitionsAssignedOperation = "PartitionsAssigned"
+
+// Emitted whenever the IncrementalRebalancer starts or stops preparing a partition. PartitionCount is the number of partitions currently being prepared.
+const PartitionsPreparingOperation = "PartitionsPreparing"
+
+// Emitted for each assigned partition every EventSourceConfig.ProgressInterval. Count is the lag in records and Duration() the estimated lag in time.
+// See [PartitionProgress].
+const PartitionLagOperation = "PartitionLag"
+
+// Emitted after each transaction commit. Count is the number of events in the eos producer pool awaiting commit.
+const EosPendingOperation = "EosPending"
+
 type Metric struct {
 	StartTime      time.Time
 	ExecuteTime    time.Time
@@ -28,8 +45,20 @@ type Metric struct {
 	PartitionCount int
 	Partition      int32
 	Operation      string
-	Topic          string
-	GroupId        string
+	// The primary topic of the EventSource, with the exception of PartitionCommitOperation.
+	Topic   string
+	GroupId string
+}
+
+// sends `m` on `metrics` if non-nil, dropping it if the channel is full so that processing is not slowed down
+func emitMetric(metrics chan Metric, m Metric) {
+	if metrics != nil {
+		select {
+		case metrics <- m:
+		default:
+			log.Warnf("metrics channel full, unable to emit metrics: %+v", m)
+		}
+	}
 }
 
 func (m Metric) Duration() time.Duration {
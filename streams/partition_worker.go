@@ -15,6 +15,7 @@
 package streams
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -36,9 +37,14 @@ const (
 	// that your application promises to fulfill the EventContext in the future.
 	// The offset for the associated EventContext will not be commited.
 	Incomplete ExecutionState = 1
-
-	Fatal       ExecutionState = 2
-	unknownType ExecutionState = 3
+	// Fatal signals the EventSource that the event or interjection has failed and can not be completed.
+	// The failure is passed to the EventSourceConfig.ProcessingErrorHandler, which decides whether to skip the event or fail the consumer.
+	// Use EventContext.Fail to pass the cause of the failure to the ProcessingErrorHandler.
+	Fatal ExecutionState = 2
+	// Retried signals the EventSource that the input record has been forwarded to a [RetryQueue] for a later attempt, see [RetryQueue.Retry].
+	// As with Complete, the offset for the associated EventContext will be commited.
+	Retried     ExecutionState = 3
+	unknownType ExecutionState = 4
 )
 
 type AsyncJob[T any] struct {
@@ -63,17 +69,24 @@ type partitionWorker[T StateStore] struct {
 	partitionInput         chan []*kgo.Record
 	maxPending             chan struct{}
 	interjectionInput      chan *interjection[T]
+	queryInput             chan func()
 	eventInput             chan *EventContext[T]
 	interjectionEventInput chan *EventContext[T]
 	asyncCompleter         asyncCompleter[T]
 	stopSignal             chan struct{}
 	revokedSignal          chan struct{}
 	stopped                chan struct{}
+	closed                 chan struct{}
 	changeLog              changeLogPartition[T]
 	eventSource            *EventSource[T]
 	runStatus              sak.RunStatus
 	ready                  int64
-	highestOffset          int64
+	highestOffsets         map[string]*int64 // the next offset to process for each source topic
+	lastTimestamps         map[string]*int64 // the timestamp, in unix milliseconds, of the last record processed for each source topic
+	retryTopics            map[string]struct{}
+	delayed                map[string][]*kgo.Record // retry records which are not yet due, by retry topic
+	retryOffsets           map[string]int64         // the next offset to hold back or schedule for each retry topic
+	retryReady             chan string
 	topicPartition         TopicPartition
 	revocationWaiter       sync.WaitGroup
 }
@@ -90,6 +103,18 @@ func newPartitionWorker[T StateStore](
 
 	recordsInputSize := sak.Max(eosConfig.MaxBatchSize/10, 100)
 	asyncSize := recordsInputSize * 4
+	// the same partition of every co-partitioned topic is processed by this worker
+	highestOffsets := make(map[string]*int64)
+	lastTimestamps := make(map[string]*int64)
+	for _, topic := range eventSource.source.consumedTopics() {
+		offset := int64(-1)
+		highestOffsets[topic] = &offset
+		lastTimestamps[topic] = new(int64)
+	}
+	retryTopics := make(map[string]struct{})
+	for _, topic := range eventSource.source.RetryQueue().Topics() {
+		retryTopics[topic] = struct{}{}
+	}
 	pw := &partitionWorker[T]{
 		eventSource:    eventSource,
 		topicPartition: topicPartition,
@@ -98,6 +123,7 @@ func newPartitionWorker[T StateStore](
 		stopSignal:     make(chan struct{}),
 		revokedSignal:  make(chan struct{}, 1),
 		stopped:        make(chan struct{}),
+		closed:         make(chan struct{}),
 		maxPending:     make(chan struct{}, eosProducer.maxPendingItems()),
 		asyncCompleter: asyncCompleter[T]{
 			asyncJobs: make(chan AsyncJob[T], asyncSize),
@@ -105,9 +131,15 @@ func newPartitionWorker[T StateStore](
 		partitionInput:         make(chan []*kgo.Record, 4),
 		eventInput:             make(chan *EventContext[T], recordsInputSize),
 		interjectionInput:      make(chan *interjection[T], 1),
+		queryInput:             make(chan func()),
 		interjectionEventInput: make(chan *EventContext[T], 1),
 		runStatus:              eventSource.runStatus.Fork(),
-		highestOffset:          -1,
+		highestOffsets:         highestOffsets,
+		lastTimestamps:         lastTimestamps,
+		retryTopics:            retryTopics,
+		delayed:                make(map[string][]*kgo.Record),
+		retryOffsets:           make(map[string]int64),
+		retryReady:             make(chan string, len(retryTopics)),
 	}
 
 	go pw.work(pw.eventSource.interjections, waiter, commitLog)
@@ -130,6 +162,42 @@ func (pw *partitionWorker[T]) revoke() {
 	pw.runStatus.Halt()
 }
 
+// Runs `query` against the StateStore on the worker go-routine, so it will not run concurrently with event processing.
+// Does not involve the eos producer pool, so `query` must not mutate the StateStore.
+func (pw *partitionWorker[T]) query(ctx context.Context, query func(T)) error {
+	if !pw.canInterject() {
+		return ErrPartitionNotReady
+	}
+	done := make(chan struct{})
+	select {
+	case pw.queryInput <- func() {
+		defer close(done)
+		query(pw.changeLog.grab())
+	}:
+	case <-pw.runStatus.Done():
+		return ErrPartitionNotAssigned
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	// the worker has accepted the query, it will run shortly
+	<-done
+	return nil
+}
+
+// Blocks until a revoked worker has closed, which occurs once all pending transactions containing events for this partition have been committed.
+// Returns false if the worker was never activated or did not close before `ctx` is done.
+func (pw *partitionWorker[T]) waitForClose(ctx context.Context) bool {
+	if !pw.canInterject() {
+		return false
+	}
+	select {
+	case <-pw.closed:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 type sincer struct {
 	then time.Time
 }
@@ -147,6 +215,8 @@ func (pw *partitionWorker[T]) pushRecords() {
 			}
 		case ij := <-pw.interjectionInput:
 			pw.scheduleInterjection(ij)
+		case topic := <-pw.retryReady:
+			pw.releaseRetries(topic)
 		case <-pw.runStatus.Done():
 			log.Debugf("Closing worker for %+v", pw.topicPartition)
 			pw.stopSignal <- struct{}{}
@@ -154,6 +224,7 @@ func (pw *partitionWorker[T]) pushRecords() {
 			close(pw.partitionInput)
 			close(pw.eventInput)
 			close(pw.asyncCompleter.asyncJobs)
+			close(pw.closed)
 			log.Debugf("Closed worker for %+v", pw.topicPartition)
 			return
 		}
@@ -167,13 +238,10 @@ func (pw *partitionWorker[T]) scheduleTxnAndExecution(records []*kgo.Record) {
 
 	pw.revocationWaiter.Add(len(records)) // optimistically do one add call
 	for _, record := range records {
-		if record != nil && record.Offset >= pw.highestOffset {
-			ec := newEventContext(pw.runStatus.Ctx(), record, pw.changeLog.changeLogData(), pw)
-			pw.maxPending <- struct{}{}
-			pw.eosProducer.addEventContext(ec)
-			pw.eventInput <- ec
+		if record != nil && !pw.delayRetry(record) {
+			pw.scheduleRecord(record)
 		} else {
-			pw.revocationWaiter.Done() // in the rare occasion this is a stale evetn, decrement the revocation waiter
+			pw.revocationWaiter.Done() // not yet due, released by releaseRetries
 		}
 		// this is needed as, when under load, the record input may starve out interjections
 		// which have a very small input buffer
@@ -181,6 +249,18 @@ func (pw *partitionWorker[T]) scheduleTxnAndExecution(records []*kgo.Record) {
 	}
 }
 
+// the caller must have already added `record` to the revocationWaiter
+func (pw *partitionWorker[T]) scheduleRecord(record *kgo.Record) {
+	if record.Offset >= atomic.LoadInt64(pw.highestOffsets[record.Topic]) {
+		ec := newEventContext(pw.runStatus.Ctx(), record, pw.changeLog.changeLogData(), pw)
+		pw.maxPending <- struct{}{}
+		pw.eosProducer.addEventContext(ec)
+		pw.eventInput <- ec
+	} else {
+		pw.revocationWaiter.Done() // in the rare occasion this is a stale evetn, decrement the revocation waiter
+	}
+}
+
 func (pw *partitionWorker[T]) interleaveInterjection() {
 	select {
 	case ij := <-pw.interjectionInput:
@@ -209,17 +289,16 @@ func (pw *partitionWorker[T]) work(interjections []interjection[T], waiter func(
 	// in the case where this partition was assigned due to a failure on another consumer, this could be a lengthy process
 	// if we continue to consume events for this partition, we will fill it's input buffer
 	// and block other partitions on this consumer. pause the partition until tghe state store is bootstrapped
-	pw.eventSource.consumer.Client().PauseFetchPartitions(map[string][]int32{
-		pw.topicPartition.Topic: {pw.topicPartition.Partition},
-	})
+	pw.eventSource.consumer.Client().PauseFetchPartitions(pw.fetchPartitions())
 	// don't start consuming until this function returns
 	// this function will block until all changelogs for this partition are populated
-	pw.highestOffset = commitLog.lastProcessed(pw.topicPartition)
-	log.Debugf("partitionWorker initialized %+v with lastProcessed offset: %d in %v", pw.topicPartition, pw.highestOffset, elapsed)
+	for topic, offset := range pw.highestOffsets {
+		tp := ntp(pw.topicPartition.Partition, topic)
+		atomic.StoreInt64(offset, commitLog.lastProcessed(tp))
+		log.Debugf("partitionWorker initialized %+v with lastProcessed offset: %d in %v", tp, atomic.LoadInt64(offset), elapsed)
+	}
 	waiter()
-	pw.eventSource.consumer.Client().ResumeFetchPartitions(map[string][]int32{
-		pw.topicPartition.Topic: {pw.topicPartition.Partition},
-	})
+	pw.eventSource.consumer.Client().ResumeFetchPartitions(pw.fetchPartitions())
 	// resume partition if it was paused
 	go pw.pushRecords()
 	atomic.StoreInt64(&pw.ready, 1)
@@ -238,6 +317,8 @@ func (pw *partitionWorker[T]) work(interjections []interjection[T], waiter func(
 			pw.handleInterjection(ec)
 		case job := <-pw.asyncCompleter.asyncJobs:
 			pw.processAsyncJob(job)
+		case query := <-pw.queryInput:
+			query()
 		case <-pw.stopSignal:
 			for _, ij := range ijPtrs {
 				ij.cancel()
@@ -250,16 +331,36 @@ func (pw *partitionWorker[T]) work(interjections []interjection[T], waiter func(
 	}
 }
 
+// Returns this worker's partition of every source topic, suitable for pausing or resuming fetches.
+func (pw *partitionWorker[T]) fetchPartitions() map[string][]int32 {
+	partitions := make(map[string][]int32, len(pw.highestOffsets))
+	for topic := range pw.highestOffsets {
+		partitions[topic] = []int32{pw.topicPartition.Partition}
+	}
+	return partitions
+}
+
 func (pw *partitionWorker[T]) waitForRevocation() {
 	pw.revocationWaiter.Wait() // wait until all pending events have been accpted by a producerNode
 	pw.revokedSignal <- struct{}{}
 }
 
 func (pw *partitionWorker[T]) processAsyncJob(job AsyncJob[T]) {
-	if job.Finalize() == Complete {
-		job.ctx.complete()
+	pw.settle(job.ctx, job.Finalize())
+}
+
+// Completes `ec` if `state` is Complete or Retried. A Fatal state is passed to the ProcessingErrorHandler, and `ec` is completed if it returns Continue.
+// Returns true if `ec` was completed.
+func (pw *partitionWorker[T]) settle(ec *EventContext[T], state ExecutionState) bool {
+	if state == Fatal && pw.eventSource.source.handleProcessingError(ec) == Continue {
+		state = Complete
+	}
+	if state == Complete || state == Retried {
+		ec.complete()
 		<-pw.maxPending
+		return true
 	}
+	return false
 }
 
 func (pw *partitionWorker[T]) isRevoked() bool {
@@ -274,9 +375,7 @@ func (pw *partitionWorker[T]) handleInterjection(ec *EventContext[T]) {
 		if inter.callback != nil {
 			inter.callback() // we need to close off 1-off interjections to prevent sourceConsumer from hanging
 		}
-	} else if ec.producer != nil && inter.interject(ec) == Complete {
-		ec.complete()
-		<-pw.maxPending
+	} else if pw.settle(ec, pw.eventSource.invokeInterjector(ec, inter.interjector, time.Now())) {
 		inter.tick()
 	}
 }
@@ -308,10 +407,9 @@ func (pw *partitionWorker[T]) forwardToEventSource(ec *EventContext[T]) {
 		return
 	}
 	offset := ec.Offset()
-	pw.highestOffset = offset + 1
 	record, _ := ec.Input()
-	if pw.eventSource.handleEvent(ec, record) == Complete {
-		ec.complete()
-		<-pw.maxPending
-	}
+	// read by pushRecords and EventSource.Progress on other go-routines
+	atomic.StoreInt64(pw.highestOffsets[ec.TopicPartition().Topic], offset+1)
+	atomic.StoreInt64(pw.lastTimestamps[ec.TopicPartition().Topic], record.Timestamp().UnixMilli())
+	pw.settle(ec, pw.eventSource.handleEvent(ec, record))
 }
@@ -16,6 +16,7 @@ package streams
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"github.com/twmb/franz-go/pkg/kgo"
 )
@@ -23,6 +24,31 @@ import (
 type changeLogData[T any] struct {
 	store T
 	topic string
+	// the offset following the latest change log record acknowledged by the broker, -1 if there is none. nil if not tracked
+	producedOffset *int64
+}
+
+// Invoked by the producer for each record produced via EventContext.RecordChange.
+// Produce callbacks of different producerNodes may complete out of order, so only the highest offset is retained.
+func (cld changeLogData[T]) recordProduced(record *Record, err error) {
+	if err != nil {
+		return
+	}
+	next := record.kRecord.Offset + 1
+	for {
+		current := atomic.LoadInt64(cld.producedOffset)
+		if next <= current || atomic.CompareAndSwapInt64(cld.producedOffset, current, next) {
+			return
+		}
+	}
+}
+
+// Returns the offset following the latest acknowledged change log record produced for this partition, or -1 if none.
+func (cld changeLogData[T]) lastProducedOffset() int64 {
+	if cld.producedOffset == nil {
+		return -1
+	}
+	return atomic.LoadInt64(cld.producedOffset)
 }
 
 type changeLogPartition[T StateStore] changeLogData[T]
@@ -81,9 +107,11 @@ func (ps *partitionedChangeLog[T]) assign(partition int32) changeLogPartition[T]
 	var sp changeLogPartition[T]
 	log.Debugf("PartitionedStore assigning %d", partition)
 	if sp, ok = ps.data[partition]; !ok {
+		producedOffset := int64(-1)
 		sp = changeLogPartition[T]{
-			store: ps.factory(ntp(partition, ps.changeLogTopic)),
-			topic: ps.changeLogTopic,
+			store:          ps.factory(ntp(partition, ps.changeLogTopic)),
+			topic:          ps.changeLogTopic,
+			producedOffset: &producedOffset,
 		}
 		ps.data[partition] = sp
 	}
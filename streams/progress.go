@@ -0,0 +1,208 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams/sak"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+// The progress of a source TopicPartition assigned to an EventSource. Co-partitioned and retry topics are reported separately.
+type PartitionProgress struct {
+	TopicPartition TopicPartition
+	// False while the StateStore for the partition is still being bootstrapped.
+	Active bool
+	// The next offset to be consumed according to the commit log, or -1 if no offset has been committed.
+	CommittedOffset int64
+	// The offset following the last record dispatched to an EventProcessor, or -1 if no records have been processed.
+	ProcessedOffset int64
+	// The high watermark of the partition on the broker.
+	HighWatermark int64
+	// The number of records between the committed offset (or the log start offset if nothing has been committed) and the high watermark.
+	// Transaction markers occupy an offset, so a caught up partition of a transactional topic may report a lag of 1.
+	Lag int64
+	// The time elapsed since the timestamp of the last processed record, or 0 if Lag is 0 or no records have been processed.
+	TimeLag time.Duration
+}
+
+// The bootstrap progress of a StateStore partition being prepared by the IncrementalRebalancer.
+type StateStoreProgress struct {
+	TopicPartition TopicPartition
+	// True once the change log has been replayed and the partition is awaiting assignment.
+	Ready bool
+	// The number of change log records, and their size in bytes, replayed into the StateStore.
+	Records int
+	Bytes   int
+	// The next change log offset to be replayed, or -1 if replay has not yet started.
+	Offset int64
+	// The high watermark of the change log partition on the broker.
+	HighWatermark int64
+}
+
+// The number of change log records which have yet to be replayed.
+func (ssp StateStoreProgress) Remaining() int64 {
+	if ssp.Offset < 0 {
+		return ssp.HighWatermark
+	}
+	return sak.Max(ssp.HighWatermark-ssp.Offset, 0)
+}
+
+// A point in time snapshot of the progress of an EventSource. See [EventSource.Progress].
+type Progress struct {
+	GroupId string
+	// Sorted by partition, then topic.
+	Partitions []PartitionProgress
+	// Sorted by partition.
+	Preparing []StateStoreProgress
+}
+
+// The sum of Lag for all assigned partitions.
+func (p Progress) Lag() (lag int64) {
+	for _, pp := range p.Partitions {
+		lag += pp.Lag
+	}
+	return
+}
+
+// Returns the progress of every TopicPartition currently assigned to this consumer, and of the StateStore partitions being prepared for assignment.
+// Broker offsets are retrieved on each invocation, so Progress should not be invoked on a hot path.
+func (es *EventSource[T]) Progress(ctx context.Context) (Progress, error) {
+	return es.consumer.progress(ctx)
+}
+
+func (sc *eventSourceConsumer[T]) progress(ctx context.Context) (Progress, error) {
+	progress := Progress{GroupId: sc.source.GroupId()}
+	now := time.Now()
+
+	sc.workerMux.Lock()
+	for p, worker := range sc.workers {
+		for topic, offset := range worker.highestOffsets {
+			tp := ntp(p, topic)
+			pp := PartitionProgress{
+				TopicPartition:  tp,
+				Active:          worker.canInterject(),
+				CommittedOffset: sc.commitLog.Watermark(tp),
+				ProcessedOffset: atomic.LoadInt64(offset),
+			}
+			if ts := atomic.LoadInt64(worker.lastTimestamps[topic]); ts > 0 {
+				pp.TimeLag = now.Sub(time.UnixMilli(ts))
+			}
+			progress.Partitions = append(progress.Partitions, pp)
+		}
+	}
+	sc.workerMux.Unlock()
+
+	sc.preppingMux.Lock()
+	for p, ssp := range sc.prepping {
+		progress.Preparing = append(progress.Preparing, StateStoreProgress{
+			TopicPartition: ntp(p, sc.stateStoreConsumer.topic),
+			Ready:          ssp.partitionState() == ready,
+			Records:        int(ssp.processed()),
+			Bytes:          int(ssp.processedBytes()),
+			Offset:         ssp.replayedOffset(),
+		})
+	}
+	sc.preppingMux.Unlock()
+
+	sort.Slice(progress.Partitions, func(i, j int) bool {
+		a, b := progress.Partitions[i].TopicPartition, progress.Partitions[j].TopicPartition
+		if a.Partition == b.Partition {
+			return a.Topic < b.Topic
+		}
+		return a.Partition < b.Partition
+	})
+	sort.Slice(progress.Preparing, func(i, j int) bool {
+		return progress.Preparing[i].TopicPartition.Partition < progress.Preparing[j].TopicPartition.Partition
+	})
+
+	if len(progress.Partitions) > 0 {
+		admin := kadm.NewClient(sc.client)
+		startOffsets, err := admin.ListStartOffsets(ctx, sc.source.consumedTopics()...)
+		if err != nil {
+			return progress, err
+		}
+		endOffsets, err := admin.ListEndOffsets(ctx, sc.source.consumedTopics()...)
+		if err != nil {
+			return progress, err
+		}
+		for i := range progress.Partitions {
+			pp := &progress.Partitions[i]
+			end, ok := endOffsets.Lookup(pp.TopicPartition.Topic, pp.TopicPartition.Partition)
+			if !ok || end.Err != nil {
+				continue
+			}
+			pp.HighWatermark = end.Offset
+			consumed := pp.CommittedOffset
+			if start, ok := startOffsets.Lookup(pp.TopicPartition.Topic, pp.TopicPartition.Partition); ok && start.Err == nil {
+				consumed = sak.Max(consumed, start.Offset)
+			}
+			pp.Lag = sak.Max(pp.HighWatermark-consumed, 0)
+			if pp.Lag == 0 {
+				pp.TimeLag = 0
+			}
+		}
+	}
+	if len(progress.Preparing) > 0 {
+		endOffsets, err := kadm.NewClient(sc.stateStoreConsumer.client).ListEndOffsets(ctx, sc.stateStoreConsumer.topic)
+		if err != nil {
+			return progress, err
+		}
+		for i := range progress.Preparing {
+			ssp := &progress.Preparing[i]
+			if end, ok := endOffsets.Lookup(ssp.TopicPartition.Topic, ssp.TopicPartition.Partition); ok && end.Err == nil {
+				ssp.HighWatermark = end.Offset
+			}
+		}
+	}
+	return progress, nil
+}
+
+// Emits a PartitionLagOperation metric for each assigned partition every EventSourceConfig.ProgressInterval.
+func (es *EventSource[T]) emitProgress() {
+	ticker := time.NewTicker(es.source.config.ProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(es.runStatus.Ctx(), es.source.config.ProgressInterval)
+			progress, err := es.Progress(ctx)
+			cancel()
+			if err != nil {
+				log.Warnf("unable to retrieve progress for group: %s, err: %v", es.source.GroupId(), err)
+				continue
+			}
+			now := time.Now()
+			for _, pp := range progress.Partitions {
+				es.EmitMetric(Metric{
+					Operation:      PartitionLagOperation,
+					GroupId:        progress.GroupId,
+					Topic:          pp.TopicPartition.Topic,
+					Partition:      pp.TopicPartition.Partition,
+					PartitionCount: 1,
+					Count:          int(pp.Lag),
+					StartTime:      now.Add(-pp.TimeLag),
+					EndTime:        now,
+				})
+			}
+		case <-es.runStatus.Done():
+			return
+		}
+	}
+}
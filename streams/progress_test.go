@@ -0,0 +1,103 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams/sak"
+)
+
+func TestEventSourceProgress(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+		return
+	}
+	lagMetrics := make(chan Metric, 100)
+	cfg := testTopicConfig()
+	cfg.ProgressInterval = 100 * time.Millisecond
+	cfg.MetricsHandler = func(m Metric) {
+		if m.Operation == PartitionLagOperation {
+			select {
+			case lagMetrics <- m:
+			default:
+			}
+		}
+	}
+	es := sak.Must(NewEventSource(cfg, NewIntStore, defaultTestHandler))
+	defer DeleteSource(cfg)
+	processed := make(chan struct{}, 10)
+	RegisterEventType(es, decodeIntStoreItem, func(ec *EventContext[intStore], item intStoreItem) ExecutionState {
+		ec.Store().add(item)
+		processed <- struct{}{}
+		return Complete
+	}, "progress")
+
+	producer := testProducer{NewProducer(es.source.AsDestination())}
+	es.ConsumeEvents()
+	defer es.StopNow()
+	for _, k := range []int{3, 13, 23} {
+		producer.produce(t, "progress", k, k)
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-processed:
+		case <-time.After(defaultTestTimeout):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTestTimeout)
+	defer cancel()
+	tp := ntp(3, cfg.Topic)
+	var pp PartitionProgress
+	for pp.CommittedOffset != 3 {
+		if ctx.Err() != nil {
+			t.Fatalf("timed out waiting for commit, progress: %+v", pp)
+		}
+		progress, err := es.Progress(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(progress.Partitions) != cfg.NumPartitions {
+			t.Fatalf("incorrect partition count. actual: %d, expected: %d", len(progress.Partitions), cfg.NumPartitions)
+		}
+		pp = progress.Partitions[3]
+		time.Sleep(50 * time.Millisecond)
+	}
+	expected := PartitionProgress{
+		TopicPartition:  tp,
+		Active:          true,
+		CommittedOffset: 3,
+		ProcessedOffset: 3,
+		HighWatermark:   3,
+		Lag:             0,
+		TimeLag:         0,
+	}
+	if pp != expected {
+		t.Errorf("incorrect progress. actual: %+v, expected: %+v", pp, expected)
+	}
+
+	select {
+	case m := <-lagMetrics:
+		if m.GroupId != cfg.GroupId || m.Topic != cfg.Topic || m.Count < 0 {
+			t.Errorf("incorrect lag metric: %+v", m)
+		}
+	case <-time.After(defaultTestTimeout):
+		t.Error("timed out waiting for lag metric")
+	}
+}
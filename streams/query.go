@@ -0,0 +1,154 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+/*
+Returned by [EventSource.Query] when the partition for a key is not assigned to this consumer.
+errors.Is(err, ErrPartitionNotAssigned) will return true for a PartitionNotAssignedError.
+If another member of the consumer group owns the partition, MemberId and Host are populated, as well as AdvertisedAddr
+if the owner has EventSourceConfig.AdvertisedAddr set. Your application can use AdvertisedAddr to forward the query to the owner.
+*/
+type PartitionNotAssignedError struct {
+	Partition      int32
+	MemberId       string
+	Host           string
+	AdvertisedAddr string
+}
+
+func (e *PartitionNotAssignedError) Error() string {
+	if len(e.MemberId) == 0 {
+		return fmt.Sprintf("partition %d is not assigned, owner unknown", e.Partition)
+	}
+	return fmt.Sprintf("partition %d is not assigned, owner: %s (host: %s, advertisedAddr: %s)", e.Partition, e.MemberId, e.Host, e.AdvertisedAddr)
+}
+
+func (e *PartitionNotAssignedError) Is(target error) bool {
+	return target == ErrPartitionNotAssigned
+}
+
+// Returns the partition of Topic to which `key` is routed, using EventSourceConfig.Partitioner.
+func (es *EventSource[T]) PartitionForKey(key []byte) int32 {
+	record := kgo.KeySliceRecord(key, nil)
+	record.Partition = AutoAssign
+	partitioner := es.source.partitioner().ForTopic(es.source.Topic())
+	return int32(partitioner.Partition(record, es.source.NumPartitions()))
+}
+
+/*
+Query runs `query` against the local StateStore for the partition which owns `key`, without the overhead of a transaction in the eos producer pool (see [EventSource.Interject]).
+`query` is invoked on the partition's processing go-routine, so it is safe to read from the StateStore, but the StateStore must NOT be mutated.
+Query blocks until `query` has been executed. `ctx` bounds the amount of time spent waiting for the partition to accept the query.
+
+If the partition is not assigned to this consumer, a [*PartitionNotAssignedError] describing the current owner is returned.
+If the partition is assigned, but the StateStore is still being bootstrapped, [ErrPartitionNotReady] is returned.
+
+	func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("key")
+		var item myItem
+		var found bool
+		err := h.eventSource.Query(r.Context(), []byte(key), func(store myStore) {
+			item, found = store.Get(key)
+		})
+		var notAssigned *streams.PartitionNotAssignedError
+		if errors.As(err, &notAssigned) {
+			// forward the request to notAssigned.AdvertisedAddr
+		}
+		...
+	}
+*/
+func (es *EventSource[T]) Query(ctx context.Context, key []byte, query func(T)) error {
+	return es.QueryPartition(ctx, es.PartitionForKey(key), query)
+}
+
+// QueryPartition is the same as [EventSource.Query], but the partition is provided explicitly.
+func (es *EventSource[T]) QueryPartition(ctx context.Context, partition int32, query func(T)) error {
+	return es.consumer.query(ctx, partition, query)
+}
+
+// Needed to share this consumers advertised address with the IncrementalRebalancer. Should NOT be invoked directly.
+func (sc *eventSourceConsumer[T]) AdvertisedAddr() string {
+	return sc.source.config.AdvertisedAddr
+}
+
+func (sc *eventSourceConsumer[T]) query(ctx context.Context, partition int32, query func(T)) error {
+	sc.workerMux.Lock()
+	w := sc.workers[partition]
+	sc.workerMux.Unlock()
+	var err error
+	if w == nil {
+		err = ErrPartitionNotAssigned
+	} else {
+		err = w.query(ctx, query)
+	}
+	if errors.Is(err, ErrPartitionNotAssigned) {
+		return sc.partitionOwner(ctx, partition)
+	}
+	return err
+}
+
+// Looks up the member which currently owns `partition`. Always returns a *PartitionNotAssignedError.
+func (sc *eventSourceConsumer[T]) partitionOwner(ctx context.Context, partition int32) error {
+	notAssigned := &PartitionNotAssignedError{Partition: partition}
+	groups, err := kadm.NewClient(sc.client).DescribeGroups(ctx, sc.source.GroupId())
+	if err != nil {
+		log.Warnf("could not describe group %s, err: %v", sc.source.GroupId(), err)
+		return notAssigned
+	}
+	group, ok := groups[sc.source.GroupId()]
+	if !ok {
+		return notAssigned
+	}
+	topic := sc.source.Topic()
+	for _, member := range group.Members {
+		assigned, ok := member.Assigned.AsConsumer()
+		if !ok || !assignmentContains(assigned.Topics, topic, partition) {
+			continue
+		}
+		notAssigned.MemberId = member.MemberID
+		notAssigned.Host = member.ClientHost
+		if join, ok := member.Join.AsConsumer(); ok {
+			var meta IncrGroupMemberMeta
+			if json.Unmarshal(join.UserData, &meta) == nil {
+				notAssigned.AdvertisedAddr = meta.AdvertisedAddr
+			}
+		}
+		break
+	}
+	return notAssigned
+}
+
+func assignmentContains(topics []kmsg.ConsumerMemberAssignmentTopic, topic string, partition int32) bool {
+	for _, t := range topics {
+		if t.Topic != topic {
+			continue
+		}
+		for _, p := range t.Partitions {
+			if p == partition {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/go-kafka-event-source/streams/sak"
+)
+
+func TestEventSourceQuery(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+		return
+	}
+	itemCount := 100 //must be multiple of 10 for this to work
+
+	c := make(chan string)
+	cfg := testTopicConfig()
+	cfg.AdvertisedAddr = "query-host:8080"
+	es := sak.Must(NewEventSource(cfg, NewIntStore, defaultTestHandler))
+	RegisterEventType(es, func(ir IncomingRecord) (string, error) {
+		return string(ir.Value()), nil
+	}, func(ec *EventContext[intStore], v string) ExecutionState {
+		c <- v
+		return Complete
+	}, "verify")
+	p := testProducer{NewProducer(es.source.AsDestination())}
+	p.produceMany(t, "int", itemCount)
+
+	es.ConsumeEvents()
+	defer es.StopNow()
+	p.waitForAllPartitions(t, c, defaultTestTimeout)
+
+	var found bool
+	var item intStoreItem
+	// the test producer partitions manually by key % 10
+	err := es.QueryPartition(context.Background(), 42%10, func(store intStore) {
+		item, found = store.tree.Get(intStoreItem{Key: 42})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found || item.Value != 42 {
+		t.Errorf("incorrect query result. actual: %+v, %v, expected: %d", item, found, 42)
+	}
+
+	key := []byte("someKey")
+	partition := es.PartitionForKey(key)
+	if partition < 0 || partition >= int32(cfg.NumPartitions) {
+		t.Errorf("invalid partition for key: %d", partition)
+	}
+	if err = es.Query(context.Background(), key, func(intStore) {}); err != nil {
+		t.Error(err)
+	}
+
+	// a consumer with no assignments should point us to the owner
+	client := sak.Must(NewClient(testCluster))
+	defer client.Close()
+	other := &eventSourceConsumer[intStore]{
+		client:  client,
+		source:  es.source,
+		workers: make(map[int32]*partitionWorker[intStore]),
+	}
+	err = other.query(context.Background(), partition, func(intStore) {
+		t.Errorf("query should not be executed")
+	})
+	if !errors.Is(err, ErrPartitionNotAssigned) {
+		t.Fatalf("incorrect error. actual: %v, expected: %v", err, ErrPartitionNotAssigned)
+	}
+	var notAssigned *PartitionNotAssignedError
+	if !errors.As(err, &notAssigned) {
+		t.Fatalf("expected PartitionNotAssignedError")
+	}
+	if notAssigned.AdvertisedAddr != cfg.AdvertisedAddr {
+		t.Errorf("incorrect advertised address. actual: %s, expected: %s", notAssigned.AdvertisedAddr, cfg.AdvertisedAddr)
+	}
+}
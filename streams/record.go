@@ -16,6 +16,7 @@ package streams
 
 import (
 	"bytes"
+	"strconv"
 	"time"
 	"unsafe"
 
@@ -29,6 +30,9 @@ var json = jsoniter.ConfigCompatibleWithStandardLibrary
 // The record.Header key that GKES uses to transmit type information about an IncomingRecord or a ChangeLogEntry.
 const RecordTypeHeaderKey = "__grt__" // let's keep it small. every byte counts
 
+// The header carrying the version of a record's payload, as a decimal integer. See [EventVersions].
+const RecordVersionHeaderKey = "__grv__"
+
 const AutoAssign = int32(-1)
 
 func recordSize(r kgo.Record) int {
@@ -42,11 +46,12 @@ func recordSize(r kgo.Record) int {
 }
 
 type Record struct {
-	keyBuffer   *bytes.Buffer
-	valueBuffer *bytes.Buffer
-	kRecord     kgo.Record
-	recordType  string
-	err         error
+	keyBuffer     *bytes.Buffer
+	valueBuffer   *bytes.Buffer
+	kRecord       kgo.Record
+	recordType    string
+	recordVersion int
+	err           error
 }
 
 var recordPool = sak.NewPool(30000,
@@ -73,6 +78,7 @@ var recordPool = sak.NewPool(30000,
 		r.keyBuffer.Reset()
 		r.valueBuffer.Reset()
 		r.recordType = ""
+		r.recordVersion = 0
 		r.err = nil
 		return r
 	})
@@ -82,8 +88,9 @@ func NewRecord() *Record {
 }
 
 type IncomingRecord struct {
-	kRecord    kgo.Record
-	recordType string
+	kRecord       kgo.Record
+	recordType    string
+	recordVersion int
 }
 
 func newIncomingRecord(incoming *kgo.Record) IncomingRecord {
@@ -91,13 +98,25 @@ func newIncomingRecord(incoming *kgo.Record) IncomingRecord {
 		kRecord: *incoming,
 	}
 	for _, header := range incoming.Headers {
-		if header.Key == RecordTypeHeaderKey {
+		switch header.Key {
+		case RecordTypeHeaderKey:
 			r.recordType = string(header.Value)
+		case RecordVersionHeaderKey:
+			r.recordVersion = parseRecordVersion(header.Value)
 		}
 	}
 	return r
 }
 
+// an invalid version header should not be mistaken for an unversioned record
+func parseRecordVersion(b []byte) int {
+	version, err := strconv.Atoi(string(b))
+	if err != nil || version < 0 {
+		return -1
+	}
+	return version
+}
+
 func (r IncomingRecord) Offset() int64 {
 	return r.kRecord.Offset
 }
@@ -118,6 +137,11 @@ func (r IncomingRecord) RecordType() string {
 	return r.recordType
 }
 
+// The payload version of the record. Returns 0 if the record has no version header, or -1 if the header is not a valid version.
+func (r IncomingRecord) RecordVersion() int {
+	return r.recordVersion
+}
+
 func (r IncomingRecord) Key() []byte {
 	return r.kRecord.Key
 }
@@ -213,11 +237,24 @@ func (r *Record) WithRecordType(recordType string) *Record {
 	return r
 }
 
+// Sets the payload version of the record, which is sent in the RecordVersionHeaderKey header. Versions must not be negative.
+// A version of 0 (the default) sends no header.
+func (r *Record) WithRecordVersion(version int) *Record {
+	r.recordVersion = version
+	return r
+}
+
 func (r *Record) WithPartition(partition int32) *Record {
 	r.kRecord.Partition = int32(partition)
 	return r
 }
 
+// Sets the timestamp of the record. If not set, the record is timestamped when it is produced.
+func (r *Record) WithTimestamp(t time.Time) *Record {
+	r.kRecord.Timestamp = t
+	return r
+}
+
 func addRecordTypeHeader(recordType string, record *kgo.Record) {
 	if len(recordType) == 0 {
 		return
@@ -233,6 +270,21 @@ func addRecordTypeHeader(recordType string, record *kgo.Record) {
 	})
 }
 
+func addRecordVersionHeader(recordVersion int, record *kgo.Record) {
+	if recordVersion == 0 {
+		return
+	}
+	for _, header := range record.Headers {
+		if header.Key == RecordVersionHeaderKey {
+			return
+		}
+	}
+	record.Headers = append(record.Headers, kgo.RecordHeader{
+		Key:   RecordVersionHeaderKey,
+		Value: strconv.AppendInt(nil, int64(recordVersion), 10),
+	})
+}
+
 // used internally for producing.
 func (r *Record) toKafkaRecord() *kgo.Record {
 
@@ -245,6 +297,7 @@ func (r *Record) toKafkaRecord() *kgo.Record {
 		r.kRecord.Value = r.valueBuffer.Bytes()
 	}
 	addRecordTypeHeader(r.recordType, &r.kRecord)
+	addRecordVersionHeader(r.recordVersion, &r.kRecord)
 
 	// this record is already in the heap (it's part of the recordPool)
 	// since we know that this pointer is guaranteed to outlive any produce calls
@@ -265,14 +318,16 @@ func (r *Record) ToKafkaRecord() *kgo.Record {
 		record.Value = append(record.Value, r.valueBuffer.Bytes()...)
 	}
 	addRecordTypeHeader(r.recordType, &r.kRecord)
+	addRecordVersionHeader(r.recordVersion, &r.kRecord)
 	return record
 }
 
 // A convenience function for unit testing. This method should not need to be invoked in a production code.
 func (r *Record) AsIncomingRecord() IncomingRecord {
 	return IncomingRecord{
-		kRecord:    *r.ToKafkaRecord(),
-		recordType: r.recordType,
+		kRecord:       *r.ToKafkaRecord(),
+		recordType:    r.recordType,
+		recordVersion: r.recordVersion,
 	}
 }
 
@@ -290,6 +345,14 @@ func SetRecordType(r *kgo.Record, recordType string) {
 	})
 }
 
+// As SetRecordType, but for the payload version. See [EventVersions].
+func SetRecordVersion(r *kgo.Record, version int) {
+	r.Headers = append(r.Headers, kgo.RecordHeader{
+		Key:   RecordVersionHeaderKey,
+		Value: strconv.AppendInt(nil, int64(version), 10),
+	})
+}
+
 func (r *Record) Release() {
 	recordPool.Release(r)
 }
@@ -348,6 +411,11 @@ func (cle ChangeLogEntry) WithEntryType(entryType string) ChangeLogEntry {
 	return cle
 }
 
+func (cle ChangeLogEntry) WithEntryVersion(version int) ChangeLogEntry {
+	cle.record.recordVersion = version
+	return cle
+}
+
 func (cle ChangeLogEntry) WithHeader(key string, value []byte) ChangeLogEntry {
 	cle.record = cle.record.WithHeader(key, value)
 	return cle
@@ -0,0 +1,173 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams/sak"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Returned by [ResetSource] if the consumer group still has members.
+var ErrGroupActive = errors.New("consumer group has active members")
+
+// Configures [ResetSource].
+type ResetConfig struct {
+	// The partitions to reset, for every source topic and retry topic. If empty, all partitions are reset. Ignored when Offsets is set.
+	Partitions []int32
+	// Each partition is reset to the first record with a timestamp at or after Timestamp.
+	// If zero, partitions are reset to the start of the source topic. Ignored when Offsets is set.
+	Timestamp time.Time
+	// The next offset to be consumed for each TopicPartition to reset. Takes precedence over Partitions and Timestamp.
+	Offsets map[TopicPartition]int64
+	// If true, every key in the state store topic partitions being reset is deleted with a tombstone before the commit log is rewound,
+	// so the StateStore is rebuilt solely from reprocessed events. Snapshots taken before the reset are superseded by the tombstones.
+	// Leave Timestamp zero to rebuild the StateStore from scratch.
+	TruncateStateStore bool
+}
+
+/*
+ResetSource rewinds the commit log for config.GroupId so that events are reprocessed from the offsets described by `reset`,
+without deleting any topics. Returns the offsets written to the commit log.
+
+The consumer group must be stopped, as the commit log is only read when a partition is assigned. If the group has any members,
+ResetSource returns an error wrapping ErrGroupActive and nothing is written.
+
+When TruncateStateStore is used, StateStore implementations must treat a record with an empty value as a deletion, which is also
+a requirement for compaction of the state store topic. Note that with multiple source topics, all topics share a state store partition,
+so truncating a partition discards state derived from every topic.
+*/
+func ResetSource(ctx context.Context, config EventSourceConfig, reset ResetConfig) (map[TopicPartition]int64, error) {
+	source := newSource(config)
+	if err := ensureGroupInactive(ctx, source); err != nil {
+		return nil, err
+	}
+	offsets := reset.Offsets
+	if len(offsets) == 0 {
+		var err error
+		if offsets, err = resetOffsets(ctx, source, reset); err != nil {
+			return nil, err
+		}
+	}
+	if len(offsets) == 0 {
+		return nil, fmt.Errorf("no partitions to reset for group: %s", source.GroupId())
+	}
+	if reset.TruncateStateStore {
+		partitions := make([]int32, 0, len(offsets))
+		for tp := range offsets {
+			if !sak.Contains(partitions, tp.Partition) {
+				partitions = append(partitions, tp.Partition)
+			}
+		}
+		if err := truncateStateStore(ctx, source, partitions); err != nil {
+			return nil, err
+		}
+	}
+	log.Infof("resetting commit log for group: %s, offsets: %v", source.GroupId(), offsets)
+	return offsets, WriteCommitLogOffsets(ctx, config, offsets)
+}
+
+func ensureGroupInactive(ctx context.Context, source *Source) error {
+	client, err := NewClient(source.config.SourceCluster)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	groups, err := kadm.NewClient(client).DescribeGroups(ctx, source.GroupId())
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		// a group which has never had members may not be known to the coordinator
+		if group.Err != nil && !errors.Is(group.Err, kerr.GroupIDNotFound) {
+			return group.Err
+		}
+		if len(group.Members) > 0 {
+			return fmt.Errorf("%w: group %s is %s with %d members", ErrGroupActive, group.Group, group.State, len(group.Members))
+		}
+	}
+	return nil
+}
+
+func resetOffsets(ctx context.Context, source *Source, reset ResetConfig) (map[TopicPartition]int64, error) {
+	client, err := NewClient(source.config.SourceCluster)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	admin := kadm.NewClient(client)
+	var listed kadm.ListedOffsets
+	if reset.Timestamp.IsZero() {
+		listed, err = admin.ListStartOffsets(ctx, source.consumedTopics()...)
+	} else {
+		listed, err = listOffsetsAfterMilli(ctx, admin, reset.Timestamp.UnixMilli(), source.consumedTopics()...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = listed.Error(); err != nil {
+		return nil, err
+	}
+	offsets := make(map[TopicPartition]int64)
+	listed.Each(func(lo kadm.ListedOffset) {
+		if len(reset.Partitions) == 0 || sak.Contains(reset.Partitions, lo.Partition) {
+			offsets[ntp(lo.Partition, lo.Topic)] = lo.Offset
+		}
+	})
+	return offsets, nil
+}
+
+// Writes a tombstone for every live key in `partitions` of the state store topic.
+// DeleteRecords is not an option, as Kafka does not allow it for compacted topics.
+func truncateStateStore(ctx context.Context, source *Source, partitions []int32) error {
+	topic := source.StateStoreTopicName()
+	keys := make(map[TopicPartition]map[string]struct{})
+	err := readTopicToEnd(ctx, source.stateCluster(), topic, partitions, func(r *kgo.Record) {
+		tp := ntp(r.Partition, r.Topic)
+		if keys[tp] == nil {
+			keys[tp] = make(map[string]struct{})
+		}
+		if len(r.Value) == 0 {
+			delete(keys[tp], string(r.Key))
+		} else {
+			keys[tp][string(r.Key)] = struct{}{}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	client, err := NewClient(source.stateCluster(), kgo.RecordPartitioner(kgo.ManualPartitioner()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	var tombstones []*kgo.Record
+	for tp, partitionKeys := range keys {
+		for key := range partitionKeys {
+			tombstones = append(tombstones, &kgo.Record{Topic: tp.Topic, Partition: tp.Partition, Key: []byte(key)})
+		}
+		log.Infof("truncating state store partition %+v, keys: %d", tp, len(partitionKeys))
+	}
+	if len(tombstones) == 0 {
+		return nil
+	}
+	return client.ProduceSync(ctx, tombstones...).FirstErr()
+}
@@ -0,0 +1,112 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams/sak"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func liveStateStoreKeys(t *testing.T, cfg EventSourceConfig, partition int32) int {
+	source := newSource(cfg)
+	keys := make(map[string]struct{})
+	err := readTopicToEnd(context.Background(), testCluster, source.StateStoreTopicName(), []int32{partition}, func(r *kgo.Record) {
+		if len(r.Value) == 0 {
+			delete(keys, string(r.Key))
+		} else {
+			keys[string(r.Key)] = struct{}{}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(keys)
+}
+
+func TestResetSource(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+		return
+	}
+	cfg := testTopicConfig()
+	cfg.CommitLogFormat = CommitLogFormatV1
+	es := sak.Must(NewEventSource(cfg, NewIntStore, defaultTestHandler))
+	defer DeleteSource(cfg)
+	processed := make(chan struct{}, 10)
+	RegisterEventType(es, decodeIntStoreItem, func(ec *EventContext[intStore], item intStoreItem) ExecutionState {
+		state := defaultTestHandler(ec, ec.input)
+		processed <- struct{}{}
+		return state
+	}, "reset")
+
+	producer := testProducer{NewProducer(es.source.AsDestination())}
+	es.ConsumeEvents()
+	defer es.StopNow()
+	for _, k := range []int{3, 13, 23, 4} {
+		producer.produce(t, "reset", k, k)
+	}
+	for i := 0; i < 4; i++ {
+		select {
+		case <-processed:
+		case <-time.After(defaultTestTimeout):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTestTimeout)
+	defer cancel()
+	for {
+		offsets, err := CommitLogOffsets(ctx, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if offsets[ntp(3, cfg.Topic)] == 3 && offsets[ntp(4, cfg.Topic)] == 1 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	reset := ResetConfig{Partitions: []int32{3}, TruncateStateStore: true}
+	if _, err := ResetSource(ctx, cfg, reset); !errors.Is(err, ErrGroupActive) {
+		t.Errorf("incorrect error while group is active. actual: %v, expected: %v", err, ErrGroupActive)
+	}
+	es.StopNow()
+	<-es.Done()
+
+	var offsets map[TopicPartition]int64
+	var err error
+	for offsets, err = ResetSource(ctx, cfg, reset); errors.Is(err, ErrGroupActive); offsets, err = ResetSource(ctx, cfg, reset) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offsets) != 1 || offsets[ntp(3, cfg.Topic)] != 0 {
+		t.Errorf("incorrect reset offsets: %v", offsets)
+	}
+	if offsets, err = CommitLogOffsets(ctx, cfg); err != nil || offsets[ntp(3, cfg.Topic)] != 0 || offsets[ntp(4, cfg.Topic)] != 1 {
+		t.Errorf("incorrect commit log offsets after reset: %v, %v", offsets, err)
+	}
+	if live := liveStateStoreKeys(t, cfg, 3); live != 0 {
+		t.Errorf("incorrect live keys for truncated partition. actual: %d, expected: %d", live, 0)
+	}
+	if live := liveStateStoreKeys(t, cfg, 4); live != 1 {
+		t.Errorf("incorrect live keys for untouched partition. actual: %d, expected: %d", live, 1)
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Headers added to records forwarded to a retry topic.
+const (
+	// The number of times the record has been retried, starting at 1.
+	RetryAttemptHeader = "gkes_retry_attempt"
+	// The topic the record was originally consumed from.
+	RetryTopicHeader = "gkes_retry_topic"
+	RetryErrorHeader = "gkes_retry_error"
+	// The time, in unix milliseconds, at which the record should be redelivered.
+	RetryDueHeader = "gkes_retry_due"
+)
+
+const retryHeaderPrefix = "gkes_retry_"
+
+/*
+A RetryQueue redelivers records which failed processing due to a transient error (a flaky downstream dependency for example), after a delay,
+without blocking the partition. Each entry of [EventSourceConfig].RetryDelays is backed by a retry topic, co-partitioned with the source topic
+and consumed by the same EventSource. Records are forwarded to the retry topic for their attempt via the transactional producer,
+so they are committed in the same transaction as the offset of the failed record.
+
+A retry topic partition is paused while it's oldest record is not yet due, then the record is redelivered to the same partition worker,
+and StateStore, as the original record. Redelivered records retain their original key, value and headers (including the record type),
+but their TopicPartition is that of the retry topic. The original topic is available via the RetryTopicHeader.
+Once all delays are exhausted, the record is forwarded to the [DeadLetterQueue], if EventSourceConfig.DeadLetterDestination is set, or dropped.
+
+	func processOrder(ec *streams.EventContext[myStore], order Order) streams.ExecutionState {
+		if err := inventoryService.Reserve(order); err != nil {
+			return eventSource.RetryQueue().Retry(ec, "Order", err)
+		}
+		...
+	}
+*/
+type RetryQueue struct {
+	topics      []string
+	delays      []time.Duration
+	deadLetters *DeadLetterQueue
+}
+
+// The retry topic names, one per EventSourceConfig.RetryDelays entry, in the same order.
+func (rq RetryQueue) Topics() []string {
+	return rq.topics
+}
+
+// Forwards the input record of `ec` to the retry topic for it's next attempt, or to the DeadLetterQueue if all attempts are exhausted.
+// `ec` must be an *EventContext, otherwise the failure is only logged. Returns [Retried] if the record was forwarded to a retry topic,
+// otherwise the result of [DeadLetterQueue.Forward], or [Complete], so Retry may be returned directly from an EventProcessor.
+// Interjections have no input record and are ignored.
+func (rq RetryQueue) Retry(ec ErrorContext, eventType string, err error) ExecutionState {
+	input, ok := ec.Input()
+	if !ok {
+		return Complete
+	}
+	attempt := RetryAttempt(input) + 1
+	if attempt > len(rq.topics) {
+		if rq.deadLetters != nil {
+			return rq.deadLetters.Forward(ec, eventType, err)
+		}
+		log.Errorf("dropping record for %+v, offset: %d, eventType: %s, retries exhausted: %v", ec.TopicPartition(), ec.Offset(), eventType, err)
+		return Complete
+	}
+	fw, ok := ec.(forwarder)
+	if !ok {
+		log.Errorf("could not retry record for %+v, offset: %d, eventType: %s, error: %v", ec.TopicPartition(), ec.Offset(), eventType, err)
+		return Complete
+	}
+	log.Debugf("retrying record for %+v, offset: %d, eventType: %s, attempt: %d, error: %v", ec.TopicPartition(), ec.Offset(), eventType, attempt, err)
+	fw.Forward(rq.retryRecord(input, attempt, err))
+	return Retried
+}
+
+func (rq RetryQueue) retryRecord(input IncomingRecord, attempt int, err error) *Record {
+	originalTopic := string(input.HeaderValue(RetryTopicHeader))
+	if len(originalTopic) == 0 {
+		originalTopic = input.TopicPartition().Topic
+	}
+	record := NewRecord().
+		WithTopic(rq.topics[attempt-1]).
+		// retry topics are co-partitioned, so the record is redelivered to the same partition worker
+		WithPartition(input.TopicPartition().Partition).
+		WithKey(input.Key()).
+		WithValue(input.Value())
+	for _, header := range input.Headers() {
+		if !strings.HasPrefix(header.Key, retryHeaderPrefix) {
+			record.WithHeader(header.Key, header.Value)
+		}
+	}
+	errString := "<nil>"
+	if err != nil {
+		errString = err.Error()
+	}
+	due := time.Now().Add(rq.delays[attempt-1]).UnixMilli()
+	return record.
+		WithHeader(RetryAttemptHeader, []byte(strconv.Itoa(attempt))).
+		WithHeader(RetryTopicHeader, []byte(originalTopic)).
+		WithHeader(RetryErrorHeader, []byte(errString)).
+		WithHeader(RetryDueHeader, []byte(strconv.FormatInt(due, 10)))
+}
+
+// Returns the number of times `record` has been retried, or 0 if `record` has not been redelivered by a [RetryQueue].
+func RetryAttempt(record IncomingRecord) int {
+	attempt, _ := strconv.Atoi(string(record.HeaderValue(RetryAttemptHeader)))
+	return attempt
+}
+
+// returns the time at which a retry record should be redelivered. records without a due header are due immediately.
+func retryDue(record *kgo.Record) time.Time {
+	for _, header := range record.Headers {
+		if header.Key == RetryDueHeader {
+			if due, err := strconv.ParseInt(string(header.Value), 10, 64); err == nil {
+				return time.UnixMilli(due)
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// Holds back records of a retry topic until they are due. Returns true if `record` has been held back, in which case the retry topic partition is paused
+// until all held back records have been released. Records are held back in offset order, so once a record is held back, all following records
+// for that retry topic are as well. Only invoked from the pushRecords go-routine.
+func (pw *partitionWorker[T]) delayRetry(record *kgo.Record) bool {
+	if _, ok := pw.retryTopics[record.Topic]; !ok {
+		return false
+	}
+	if record.Offset < pw.retryOffsets[record.Topic] {
+		// already held back or scheduled, the partition may have been re-fetched after pausing
+		return true
+	}
+	pw.retryOffsets[record.Topic] = record.Offset + 1
+	if delayed := pw.delayed[record.Topic]; len(delayed) > 0 {
+		pw.delayed[record.Topic] = append(delayed, record)
+		return true
+	}
+	due := retryDue(record)
+	if !due.After(time.Now()) {
+		return false
+	}
+	pw.delayed[record.Topic] = []*kgo.Record{record}
+	pw.eventSource.consumer.Client().PauseFetchPartitions(map[string][]int32{record.Topic: {pw.topicPartition.Partition}})
+	pw.releaseRetriesAt(record.Topic, due)
+	return true
+}
+
+func (pw *partitionWorker[T]) releaseRetriesAt(topic string, due time.Time) {
+	time.AfterFunc(time.Until(due), func() {
+		select {
+		case pw.retryReady <- topic:
+		case <-pw.runStatus.Done():
+		}
+	})
+}
+
+// Schedules the held back records of `topic` which are now due. If records remain, waits for the next one,
+// otherwise resumes fetching the retry topic partition. Only invoked from the pushRecords go-routine.
+func (pw *partitionWorker[T]) releaseRetries(topic string) {
+	if pw.isRevoked() {
+		return
+	}
+	delayed := pw.delayed[topic]
+	now := time.Now()
+	released := 0
+	for ; released < len(delayed); released++ {
+		if retryDue(delayed[released]).After(now) {
+			break
+		}
+	}
+	pw.revocationWaiter.Add(released)
+	for _, record := range delayed[:released] {
+		pw.scheduleRecord(record)
+		pw.interleaveInterjection()
+	}
+	if released < len(delayed) {
+		pw.delayed[topic] = delayed[released:]
+		pw.releaseRetriesAt(topic, retryDue(delayed[released]))
+		return
+	}
+	delete(pw.delayed, topic)
+	pw.eventSource.consumer.Client().ResumeFetchPartitions(map[string][]int32{topic: {pw.topicPartition.Partition}})
+}
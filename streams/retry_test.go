@@ -0,0 +1,195 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams/sak"
+)
+
+// converts an output record back into a Record, so it can be piped into the TestDriver
+func redeliver(output IncomingRecord) *Record {
+	record := NewRecord().WithKey(output.Key()).WithValue(output.Value())
+	for _, header := range output.Headers() {
+		record.WithHeader(header.Key, header.Value)
+	}
+	return record
+}
+
+func TestRetryQueue(t *testing.T) {
+	driver := NewTestDriver(EventSourceConfig{
+		GroupId:               "driver_group",
+		Topic:                 "driver_topic",
+		NumPartitions:         4,
+		RetryDelays:           []time.Duration{time.Second, 30 * time.Second},
+		DeadLetterDestination: Destination{DefaultTopic: "driver_dlq"},
+	}, NewIntStore, defaultTestHandler)
+	defer driver.Close()
+	es := driver.EventSource()
+	retryTopics := es.RetryQueue().Topics()
+	if len(retryTopics) != 2 || retryTopics[0] != "gkes_retry_driver_topic_driver_group_1000ms" {
+		t.Fatalf("incorrect retry topics: %v", retryTopics)
+	}
+	if topics := es.Source().Topics(); len(topics) != 1 {
+		t.Errorf("retry topics included in source topics: %v", topics)
+	}
+	if topics := es.source.consumedTopics(); len(topics) != 3 || topics[1] != retryTopics[0] || topics[2] != retryTopics[1] {
+		t.Errorf("retry topics not consumed: %v", topics)
+	}
+
+	attempts := []int{}
+	states := []ExecutionState{}
+	RegisterEventType(es, decodeIntStoreItem, func(ec *EventContext[intStore], item intStoreItem) ExecutionState {
+		input, _ := ec.Input()
+		attempts = append(attempts, RetryAttempt(input))
+		state := es.RetryQueue().Retry(ec, "flaky", errBadItem)
+		states = append(states, state)
+		return state
+	}, "flaky")
+
+	driver.Pipe(1, intRecord("flaky", 1, 10))
+	start := time.Now()
+	for i, topic := range retryTopics {
+		retries := driver.Output(topic)
+		if len(retries) != 1 {
+			t.Fatalf("incorrect retry count for %s. actual: %d, expected: %d", topic, len(retries), 1)
+		}
+		retry := retries[0]
+		if retry.TopicPartition().Partition != 1 {
+			t.Errorf("incorrect retry partition. actual: %d, expected: %d", retry.TopicPartition().Partition, 1)
+		}
+		if attempt := RetryAttempt(retry); attempt != i+1 {
+			t.Errorf("incorrect retry attempt. actual: %d, expected: %d", attempt, i+1)
+		}
+		if original := string(retry.HeaderValue(RetryTopicHeader)); original != "driver_topic" {
+			t.Errorf("incorrect original topic. actual: %s, expected: %s", original, "driver_topic")
+		}
+		if retry.RecordType() != "flaky" {
+			t.Errorf("incorrect record type. actual: %s, expected: %s", retry.RecordType(), "flaky")
+		}
+		due := retryDue(&retry.kRecord)
+		if minDue := start.Add(es.source.config.RetryDelays[i]).Truncate(time.Millisecond); due.Before(minDue) {
+			t.Errorf("incorrect due time. actual: %v, expected at least: %v", due, minDue)
+		}
+		driver.ClearOutput()
+		driver.PipeTopic(topic, 1, redeliver(retry))
+	}
+
+	// retries exhausted
+	deadLetters := driver.Output("driver_dlq")
+	if len(deadLetters) != 1 {
+		t.Fatalf("incorrect dead letter count. actual: %d, expected: %d", len(deadLetters), 1)
+	}
+	if topic := string(deadLetters[0].HeaderValue(DeadLetterTopicHeader)); topic != retryTopics[1] {
+		t.Errorf("incorrect dead letter topic header. actual: %s, expected: %s", topic, retryTopics[1])
+	}
+	if len(attempts) != 3 || attempts[0] != 0 || attempts[1] != 1 || attempts[2] != 2 {
+		t.Errorf("incorrect attempts: %v", attempts)
+	}
+	if len(states) != 3 || states[0] != Retried || states[1] != Retried || states[2] != Complete {
+		t.Errorf("incorrect execution states: %v", states)
+	}
+}
+
+func TestRetryQueueRequiresSourceCluster(t *testing.T) {
+	_, err := CreateSource(EventSourceConfig{
+		GroupId:       "retry_group",
+		Topic:         "retry_topic",
+		SourceCluster: SimpleCluster{"127.0.0.1:9092"},
+		StateCluster:  SimpleCluster{"127.0.0.2:9092"},
+		RetryDelays:   []time.Duration{time.Second},
+	})
+	if err == nil {
+		t.Errorf("expected error for RetryDelays with a separate StateCluster")
+	}
+}
+
+func TestEventSourceRetries(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+		return
+	}
+	delays := []time.Duration{200 * time.Millisecond, 400 * time.Millisecond}
+	cfg := testTopicConfig()
+	cfg.RetryDelays = delays
+	cfg.DeadLetterDestination = Destination{DefaultTopic: cfg.Topic + "_dlq"}
+	es := sak.Must(NewEventSource(cfg, NewIntStore, defaultTestHandler))
+	defer DeleteSource(cfg)
+
+	type delivery struct {
+		attempt int
+		at      time.Time
+	}
+	deliveries := make(chan delivery, 10)
+	RegisterEventType(es, decodeIntStoreItem, func(ec *EventContext[intStore], item intStoreItem) ExecutionState {
+		input, _ := ec.Input()
+		attempt := RetryAttempt(input)
+		deliveries <- delivery{attempt: attempt, at: time.Now()}
+		if attempt < len(delays) {
+			return es.RetryQueue().Retry(ec, "flaky", errBadItem)
+		}
+		ec.Store().add(item)
+		return Complete
+	}, "flaky")
+
+	producer := testProducer{NewProducer(es.source.AsDestination())}
+	es.ConsumeEvents()
+	defer es.StopNow()
+	producer.produce(t, "flaky", 3, 30)
+
+	var previous time.Time
+	for i := 0; i <= len(delays); i++ {
+		select {
+		case d := <-deliveries:
+			if d.attempt != i {
+				t.Fatalf("incorrect attempt. actual: %d, expected: %d", d.attempt, i)
+			}
+			// allow for millisecond precision of the due header
+			if i > 0 && d.at.Sub(previous) < delays[i-1]-time.Millisecond {
+				t.Errorf("retry %d redelivered early, after %v", i, d.at.Sub(previous))
+			}
+			previous = d.at
+		case <-time.After(defaultTestTimeout):
+			t.Fatalf("timed out waiting for attempt %d", i)
+		}
+	}
+
+	found := false
+	es.InterjectAllSync(func(ec *EventContext[intStore], _ time.Time) ExecutionState {
+		if _, ok := ec.Store().tree.Get(intStoreItem{Key: 3}); ok {
+			found = true
+		}
+		return Complete
+	})
+	if !found {
+		t.Errorf("retried item was not stored")
+	}
+
+	// nothing should be dead lettered
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	replayed, err := ReplayDeadLetters(ctx, es.DeadLetterQueue().Destination(), testCluster, func(IncomingRecord) bool { return true })
+	if err != nil || replayed != 0 {
+		t.Errorf("unexpected dead letters: %d, %v", replayed, err)
+	}
+	select {
+	case d := <-deliveries:
+		t.Errorf("unexpected delivery: %+v", d)
+	default:
+	}
+}
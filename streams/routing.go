@@ -0,0 +1,162 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// A predicate used by [RegisterRoute] to decide whether an IncomingRecord should be routed to an EventProcessor.
+// Invoked for every record consumed by the EventSource, so it should be cheap.
+type RouteMatcher func(IncomingRecord) bool
+
+// Matches records with a header of `key` whose value equals `value`.
+func MatchHeader(key string, value []byte) RouteMatcher {
+	return func(record IncomingRecord) bool {
+		for _, header := range record.Headers() {
+			if header.Key == key && bytes.Equal(header.Value, value) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Matches records with a header of `key`, regardless of it's value.
+func HasHeader(key string) RouteMatcher {
+	return func(record IncomingRecord) bool {
+		for _, header := range record.Headers() {
+			if header.Key == key {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Matches records whose key starts with `prefix`.
+func MatchKeyPrefix(prefix []byte) RouteMatcher {
+	return func(record IncomingRecord) bool {
+		return bytes.HasPrefix(record.Key(), prefix)
+	}
+}
+
+// Matches records which match all of `matchers`.
+func MatchAll(matchers ...RouteMatcher) RouteMatcher {
+	return func(record IncomingRecord) bool {
+		for _, match := range matchers {
+			if !match(record) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+type routingRule[T any] struct {
+	name     string
+	match    RouteMatcher
+	executor eventExecutor[T]
+}
+
+/*
+Registers a routing rule which sends records matching `match` to `eventProcessor`, regardless of their record type.
+Routing rules take precedence over event types registered via RegisterEventType, and are evaluated in the order they were registered,
+so the first matching rule wins. `name` identifies the rule in [EventSource.Routes] and is passed to the DeserializationErrorHandler
+in place of the record type.
+
+	// records relayed from the legacy system carry no record type header
+	streams.RegisterRoute(eventSource, "legacyOrders", streams.MatchHeader("source", []byte("legacy")), decodeLegacyOrder, handleLegacyOrder)
+
+Must not be called after `EventSource.ConsumeEvents()`
+*/
+func RegisterRoute[T StateStore, V any](es *EventSource[T], name string, match RouteMatcher, decoder IncomingRecordDecoder[V], eventProcessor EventProcessor[T, V]) {
+	es.rules = append(es.rules, routingRule[T]{
+		name:     name,
+		match:    match,
+		executor: newEventProcessorExecutor(decoder, eventProcessor, namedDeserializationErrorHandler(name, es.source.deserializationErrorHandler())),
+	})
+}
+
+func namedDeserializationErrorHandler(name string, handler DeserializationErrorHandler) DeserializationErrorHandler {
+	return func(ec ErrorContext, _ string, err error) ErrorResponse {
+		return handler(ec, name, err)
+	}
+}
+
+// Describes how a Route matches records.
+type RouteKind int
+
+const (
+	// A rule registered with RegisterRoute.
+	RuleRoute RouteKind = iota
+	// An event type registered with RegisterEventType, or a variant thereof.
+	RecordTypeRoute
+	// The default processor, invoked for records which match no other route.
+	DefaultRoute
+)
+
+func (rk RouteKind) String() string {
+	switch rk {
+	case RuleRoute:
+		return "rule"
+	case RecordTypeRoute:
+		return "recordType"
+	case DefaultRoute:
+		return "default"
+	}
+	return fmt.Sprintf("RouteKind(%d)", int(rk))
+}
+
+// A route registered with an EventSource. See [EventSource.Routes].
+type Route struct {
+	Kind RouteKind
+	// The rule name or event type.
+	Name string
+}
+
+func (r Route) String() string {
+	if r.Kind == DefaultRoute {
+		return r.Kind.String()
+	}
+	return r.Kind.String() + ":" + r.Name
+}
+
+/*
+Returns the routes registered with the EventSource, in the order they are evaluated for each record:
+
+  - routing rules, in registration order. The first matching rule wins.
+  - event types, matched against IncomingRecord.RecordType(). As a record has a single record type, at most one can match, and they are listed in name order.
+  - the default processor.
+
+Intended for debugging. Records routed to an event type whose EventVersions can not decode the record version are also sent to the default processor.
+*/
+func (es *EventSource[T]) Routes() []Route {
+	routes := make([]Route, 0, len(es.rules)+len(es.processors)+1)
+	for _, rule := range es.rules {
+		routes = append(routes, Route{Kind: RuleRoute, Name: rule.name})
+	}
+	eventTypes := make([]string, 0, len(es.processors))
+	for eventType := range es.processors {
+		eventTypes = append(eventTypes, eventType)
+	}
+	sort.Strings(eventTypes)
+	for _, eventType := range eventTypes {
+		routes = append(routes, Route{Kind: RecordTypeRoute, Name: eventType})
+	}
+	return append(routes, Route{Kind: DefaultRoute})
+}
@@ -0,0 +1,94 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestRouting(t *testing.T) {
+	driver := newTestDriverForTest()
+	defer driver.Close()
+
+	var routed []string
+	processor := func(name string) EventProcessor[intStore, intStoreItem] {
+		return func(ec *EventContext[intStore], item intStoreItem) ExecutionState {
+			routed = append(routed, name)
+			return Complete
+		}
+	}
+	es := driver.EventSource()
+	RegisterEventType(es, decodeIntStoreItem, processor("b"), "b")
+	RegisterEventType(es, decodeIntStoreItem, processor("a"), "a")
+	RegisterEventType(es, decodeIntStoreItem, processor("duplicate"), "a")
+	RegisterRoute(es, "priority", MatchHeader("priority", []byte("high")), decodeIntStoreItem, processor("priority"))
+	RegisterRoute(es, "tenant", MatchAll(HasHeader("tenant"), MatchKeyPrefix([]byte("acme:"))), decodeIntStoreItem, processor("tenant"))
+	RegisterRoute(es, "predicate", func(ir IncomingRecord) bool {
+		return ir.RecordType() == "c"
+	}, decodeIntStoreItem, processor("predicate"))
+
+	driver.Pipe(0,
+		intRecord("a", 1, 1),
+		intRecord("b", 2, 2),
+		intRecord("a", 3, 3).WithHeader("priority", []byte("high")),
+		intRecord("a", 4, 4).WithHeader("priority", []byte("low")),
+		NewRecord().WithRecordType("b").WithKeyString("acme:5").WithHeader("tenant", nil).WithHeader("priority", []byte("high")),
+		NewRecord().WithRecordType("b").WithKeyString("acme:6").WithHeader("tenant", nil),
+		NewRecord().WithRecordType("b").WithKeyString("other:7").WithHeader("tenant", nil),
+		intRecord("c", 8, 8),
+		intRecord("d", 9, 9))
+
+	expected := []string{"a", "b", "priority", "a", "priority", "tenant", "b", "predicate"}
+	if !reflect.DeepEqual(routed, expected) {
+		t.Errorf("incorrect routing. actual: %v, expected: %v", routed, expected)
+	}
+	if changeLog := driver.ChangeLog(0); len(changeLog) != 1 || changeLog[0].RecordType() != "defaultHandler" {
+		t.Errorf("unrouted record not passed to default handler: %+v", changeLog)
+	}
+
+	routes := fmt.Sprint(es.Routes())
+	if expectedRoutes := "[rule:priority rule:tenant rule:predicate recordType:a recordType:b default]"; routes != expectedRoutes {
+		t.Errorf("incorrect routes. actual: %s, expected: %s", routes, expectedRoutes)
+	}
+}
+
+func TestRouteDeserializationError(t *testing.T) {
+	var eventType string
+	driver := NewTestDriver(EventSourceConfig{
+		GroupId:       "routing_group",
+		Topic:         "routing_topic",
+		NumPartitions: 1,
+		DeserializationErrorHandler: func(ec ErrorContext, et string, err error) ErrorResponse {
+			eventType = et
+			return Continue
+		},
+	}, NewIntStore, defaultTestHandler)
+	defer driver.Close()
+
+	RegisterRoute(driver.EventSource(), "keyed", MatchKeyPrefix([]byte("key")), func(IncomingRecord) (int, error) {
+		return 0, fmt.Errorf("undecodable")
+	}, func(*EventContext[intStore], int) ExecutionState {
+		t.Errorf("unexpected event")
+		return Complete
+	})
+	if state := driver.Pipe(0, NewRecord().WithKeyString("key1").WithRecordType("keyType")); state != Complete {
+		t.Errorf("incorrect execution state. actual: %v, expected: %v", state, Complete)
+	}
+	if eventType != "keyed" {
+		t.Errorf("incorrect event type. actual: %s, expected: %s", eventType, "keyed")
+	}
+}
@@ -129,6 +129,17 @@ func ToStructSlice[T any](ptrs []*T) []T {
 	return pointers
 }
 
+// A utility function that reports whether `v` is present in `s`.
+// Used internally but exposed for your consumption.
+func Contains[T comparable](s []T, v T) bool {
+	for _, item := range s {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
 // A utility function that copies a map[K]T.
 // Useful when you need to iterate over items in a map that is synchronized buy a Mutex.
 // Used internally but exposed for your consumption.
@@ -0,0 +1,255 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams/sak"
+	"github.com/google/uuid"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+const (
+	snapshotIdHeader     = "gkes_snapshot_id"
+	snapshotIndexHeader  = "gkes_snapshot_index"
+	snapshotChunksHeader = "gkes_snapshot_chunks"
+	snapshotOffsetHeader = "gkes_snapshot_offset"
+	// keep well under the default max.message.bytes of 1MB
+	snapshotChunkSize   = 512 * 1024
+	snapshotLoadTimeout = 5 * time.Minute
+)
+
+/*
+SnapshotStateStore is an optional extension of StateStore for implementations which can serialize their entire contents.
+When EventSourceConfig.SnapshotInterval is set, the EventSource periodically writes a snapshot of each active partition
+to the snapshot topic (see [Source.SnapshotTopicName]), along with the state store topic offset the snapshot is consistent with.
+When a partition is assigned, the latest snapshot is restored and only the change log records after that offset are replayed.
+
+Snapshot is invoked on the partition's processing go-routine, so the store will not be mutated while it executes.
+Restore is invoked before any calls to ReceiveChange. If Restore returns an error, the store must be left empty,
+as the entire change log will be replayed.
+
+As the snapshot offset is a lower bound, a few change log records already reflected in a snapshot may be replayed after Restore.
+ReceiveChange must therefore be idempotent, which is the case for any store where a change log record simply replaces (or deletes) the value for a key.
+*/
+type SnapshotStateStore interface {
+	StateStore
+	Snapshot(w io.Writer) error
+	Restore(r io.Reader) error
+}
+
+// The Interjector scheduled when snapshots are enabled.
+func (es *EventSource[T]) snapshot(ec *EventContext[T], _ time.Time) ExecutionState {
+	store, ok := any(ec.Store()).(SnapshotStateStore)
+	if !ok {
+		log.Warnf("SnapshotInterval is set, but StateStore does not implement SnapshotStateStore")
+		return Complete
+	}
+	start := time.Now()
+	tp := ec.TopicPartition()
+	// resolve the offset before serializing, from offsets already known to this process, as we are on the processing go-routine.
+	// every change log record below it was replayed when the partition was assigned, or produced since, so is reflected in the store.
+	// records still in flight will have a higher offset, and we don't mind replaying a few records that are already reflected in the snapshot
+	offset := sak.Max(es.consumer.stateStoreConsumer.replayedOffset(tp.Partition), ec.changeLog.lastProducedOffset())
+	if offset < 0 {
+		log.Warnf("not taking snapshot of %+v, state store offset is not yet known", tp)
+		return Complete
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := store.Snapshot(buf); err != nil {
+		log.Errorf("could not snapshot %+v, err: %v", tp, err)
+		return Complete
+	}
+	size := buf.Len()
+	// snapshots are produced in the same transaction as the change log records which preceded them
+	// if the transaction is aborted, so is the snapshot
+	for _, record := range snapshotRecords(es.source.SnapshotTopicName(), tp.Partition, offset, buf.Bytes()) {
+		ec.Forward(record)
+	}
+	log.Debugf("snapshot of %+v at offset %d, %d bytes in %v", tp, offset, size, time.Since(start))
+	return Complete
+}
+
+// splits a snapshot into chunks, so we don't exceed max.message.bytes
+func snapshotRecords(topic string, partition int32, offset int64, snapshot []byte) []*Record {
+	id := []byte(uuid.NewString())
+	chunkCount := (len(snapshot) + snapshotChunkSize - 1) / snapshotChunkSize
+	if chunkCount == 0 {
+		chunkCount = 1
+	}
+	chunks := []byte(strconv.Itoa(chunkCount))
+	offsetBytes := []byte(strconv.FormatInt(offset, 10))
+	records := make([]*Record, 0, chunkCount)
+	for i := 0; i < chunkCount; i++ {
+		start := i * snapshotChunkSize
+		end := sak.Min(start+snapshotChunkSize, len(snapshot))
+		index := strconv.Itoa(i)
+		records = append(records, NewRecord().
+			WithTopic(topic).
+			WithPartition(partition).
+			// keyed by chunk index so compaction retains only the latest snapshot (plus any stale chunks from a larger, older snapshot)
+			WithKeyString(index).
+			WithValue(snapshot[start:end]).
+			WithHeader(snapshotIdHeader, id).
+			WithHeader(snapshotIndexHeader, []byte(index)).
+			WithHeader(snapshotChunksHeader, chunks).
+			WithHeader(snapshotOffsetHeader, offsetBytes))
+	}
+	return records
+}
+
+type snapshotImage struct {
+	chunks   [][]byte
+	received int
+	offset   int64
+}
+
+func (si *snapshotImage) complete() bool {
+	return si.received == len(si.chunks)
+}
+
+func (si *snapshotImage) reader() io.Reader {
+	readers := make([]io.Reader, len(si.chunks))
+	for i, chunk := range si.chunks {
+		readers[i] = bytes.NewReader(chunk)
+	}
+	return io.MultiReader(readers...)
+}
+
+// reassembles snapshot chunks as they are read from the snapshot topic
+type snapshotAssembler struct {
+	pending map[string]*snapshotImage
+	latest  *snapshotImage
+}
+
+func newSnapshotAssembler() *snapshotAssembler {
+	return &snapshotAssembler{pending: make(map[string]*snapshotImage)}
+}
+
+func snapshotHeaderInt(record *kgo.Record, key string) (int64, bool) {
+	for _, header := range record.Headers {
+		if header.Key == key {
+			v, err := strconv.ParseInt(string(header.Value), 10, 64)
+			return v, err == nil
+		}
+	}
+	return 0, false
+}
+
+func (sa *snapshotAssembler) add(record *kgo.Record) {
+	var id string
+	for _, header := range record.Headers {
+		if header.Key == snapshotIdHeader {
+			id = string(header.Value)
+		}
+	}
+	index, okIndex := snapshotHeaderInt(record, snapshotIndexHeader)
+	chunks, okChunks := snapshotHeaderInt(record, snapshotChunksHeader)
+	offset, okOffset := snapshotHeaderInt(record, snapshotOffsetHeader)
+	if len(id) == 0 || !okIndex || !okChunks || !okOffset || index < 0 || index >= chunks {
+		log.Warnf("ignoring malformed snapshot record %s/%d offset: %d", record.Topic, record.Partition, record.Offset)
+		return
+	}
+	image, ok := sa.pending[id]
+	if !ok {
+		image = &snapshotImage{
+			chunks: make([][]byte, chunks),
+			offset: offset,
+		}
+		sa.pending[id] = image
+	}
+	if image.chunks[index] == nil {
+		image.received++
+	}
+	image.chunks[index] = record.Value
+	if image.complete() {
+		delete(sa.pending, id)
+		if sa.latest == nil || image.offset >= sa.latest.offset {
+			sa.latest = image
+		}
+	}
+}
+
+// Reads the snapshot topic for `partition` and returns the latest complete snapshot, or nil if there is none.
+func loadSnapshot(source *Source, partition int32) (*snapshotImage, error) {
+	topic := source.SnapshotTopicName()
+	client, err := NewClient(source.stateCluster(),
+		kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{
+			topic: {partition: kgo.NewOffset().AtStart()},
+		}),
+		kgo.FetchMaxWait(time.Second),
+		kgo.RecordPartitioner(kgo.ManualPartitioner()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	// as with state store partitions, a marker tells us when we've caught up
+	mark := []byte(uuid.NewString())
+	if err = sendMarkerMessage(client, ntp(partition, topic), mark); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), snapshotLoadTimeout)
+	defer cancel()
+	assembler := newSnapshotAssembler()
+	for {
+		f := client.PollFetches(ctx)
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		for _, fetchErr := range f.Errors() {
+			return nil, fetchErr.Err
+		}
+		caughtUp := false
+		f.EachRecord(func(record *kgo.Record) {
+			if caughtUp {
+				return
+			}
+			if isMarkerRecord(record) {
+				caughtUp = bytes.Equal(record.Value, mark)
+				return
+			}
+			assembler.add(record)
+		})
+		if caughtUp {
+			return assembler.latest, nil
+		}
+	}
+}
+
+// Restores the latest snapshot for `partition` into `store`. Returns the state store topic offset from which to resume consumption.
+func restoreSnapshot(source *Source, store SnapshotStateStore, partition int32) (offset int64, ok bool) {
+	start := time.Now()
+	image, err := loadSnapshot(source, partition)
+	if err != nil {
+		log.Errorf("could not load snapshot for partition %d, err: %v", partition, err)
+		return 0, false
+	}
+	if image == nil {
+		log.Infof("no snapshot found for partition %d", partition)
+		return 0, false
+	}
+	if err = store.Restore(image.reader()); err != nil {
+		log.Errorf("could not restore snapshot for partition %d, err: %v", partition, err)
+		return 0, false
+	}
+	log.Infof("restored snapshot for partition %d at offset %d in %v", partition, image.offset, time.Since(start))
+	return image.offset, true
+}
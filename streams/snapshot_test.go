@@ -0,0 +1,163 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams/sak"
+)
+
+type snapshotIntStore struct {
+	intStore
+	restored *atomic.Int32
+}
+
+func (s snapshotIntStore) Snapshot(w io.Writer) (err error) {
+	s.tree.Ascend(func(item intStoreItem) bool {
+		err = binary.Write(w, binary.BigEndian, [2]int64{int64(item.Key), int64(item.Value)})
+		return err == nil
+	})
+	return
+}
+
+func (s snapshotIntStore) Restore(r io.Reader) error {
+	var kv [2]int64
+	for {
+		if err := binary.Read(r, binary.BigEndian, &kv); err == io.EOF {
+			break
+		} else if err != nil {
+			s.tree.Clear(false)
+			return err
+		}
+		s.add(intStoreItem{Key: int(kv[0]), Value: int(kv[1])})
+	}
+	s.restored.Add(1)
+	return nil
+}
+
+func snapshotTestHandler(ec *EventContext[snapshotIntStore], ir IncomingRecord) ExecutionState {
+	s := ec.Store()
+	cle := NewChangeLogEntry()
+	if item, ok := s.decodeRecord(ir); ok {
+		s.add(item)
+		item.encodeKey(cle)
+		item.encodeValue(cle)
+	} else {
+		item.encodeKey(cle)
+		s.del(item)
+	}
+	ec.RecordChange(cle)
+	return Complete
+}
+
+func TestSnapshotAssembler(t *testing.T) {
+	large := bytes.Repeat([]byte{1}, snapshotChunkSize*2+10)
+	small := []byte("small")
+	assembler := newSnapshotAssembler()
+	for _, record := range snapshotRecords("snapshots", 0, 100, large) {
+		assembler.add(record.toKafkaRecord())
+	}
+	if assembler.latest == nil || assembler.latest.offset != 100 || len(assembler.latest.chunks) != 3 {
+		t.Fatalf("incorrect snapshot: %+v", assembler.latest)
+	}
+	restored, _ := io.ReadAll(assembler.latest.reader())
+	if !bytes.Equal(restored, large) {
+		t.Errorf("snapshot does not match")
+	}
+
+	records := snapshotRecords("snapshots", 0, 200, small)
+	if len(records) != 1 {
+		t.Fatalf("incorrect chunk count. actual: %d, expected: %d", len(records), 1)
+	}
+	// a chunk of an incomplete snapshot should not replace the latest
+	partial := snapshotRecords("snapshots", 0, 300, large)
+	assembler.add(partial[1].toKafkaRecord())
+	assembler.add(records[0].toKafkaRecord())
+	if assembler.latest.offset != 200 {
+		t.Errorf("incorrect snapshot offset. actual: %d, expected: %d", assembler.latest.offset, 200)
+	}
+	restored, _ = io.ReadAll(assembler.latest.reader())
+	if !bytes.Equal(restored, small) {
+		t.Errorf("snapshot does not match")
+	}
+}
+
+func TestEventSourceSnapshotRestore(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+		return
+	}
+	itemCount := 1000 //must be multiple of 10 for this to work
+	cfg := testTopicConfig()
+	cfg.SnapshotInterval = 100 * time.Millisecond
+	restored := new(atomic.Int32)
+	c := make(chan string)
+
+	newSnapshotEventSource := func() *EventSource[snapshotIntStore] {
+		es := sak.Must(NewEventSource(cfg, func(tp TopicPartition) snapshotIntStore {
+			return snapshotIntStore{NewIntStore(tp), restored}
+		}, snapshotTestHandler))
+		RegisterEventType(es, func(ir IncomingRecord) (string, error) {
+			return string(ir.Value()), nil
+		}, func(ec *EventContext[snapshotIntStore], v string) ExecutionState {
+			c <- v
+			return Complete
+		}, "verify")
+		es.ConsumeEvents()
+		return es
+	}
+
+	es := newSnapshotEventSource()
+	p := testProducer{NewProducer(es.source.AsDestination())}
+	p.produceMany(t, "int", itemCount)
+	p.waitForAllPartitions(t, c, defaultTestTimeout)
+
+	deadline := time.Now().Add(defaultTestTimeout)
+	for partition := int32(0); partition < int32(cfg.NumPartitions); partition++ {
+		for {
+			image, err := loadSnapshot(es.source, partition)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if image != nil && image.offset > 0 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("no snapshot written for partition %d", partition)
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	es.StopNow()
+
+	es = newSnapshotEventSource()
+	defer es.StopNow()
+	p.waitForAllPartitions(t, c, defaultTestTimeout)
+	if r := restored.Load(); r != int32(cfg.NumPartitions) {
+		t.Errorf("incorrect restored partition count. actual: %d, expected: %d", r, cfg.NumPartitions)
+	}
+	es.InterjectAllSync(func(ec *EventContext[snapshotIntStore], _ time.Time) ExecutionState {
+		if l := ec.Store().tree.Len(); l != itemCount/10 {
+			t.Errorf("incorrect number of items in partition. actual: %d, expected: %d", l, itemCount/10)
+		}
+		return Complete
+	})
+}
@@ -20,6 +20,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/aws/go-kafka-event-source/streams/sak"
 	"github.com/twmb/franz-go/pkg/kgo"
 )
 
@@ -45,6 +46,11 @@ type EventSourceConfig struct {
 	GroupId string
 	// The Kafka topic to consume
 	Topic string
+	// Additional Kafka topics to consume. Every topic must have the same number of partitions as Topic (co-partitioned), and the same partition
+	// of every topic is processed by the same partition worker against the same StateStore, so events for related topics may be correlated (see
+	// [github.com/aws/go-kafka-event-source/streams/windowing.Join]). If Topic is empty, the first of Topics is used in it's place.
+	// Consuming more than one topic requires the [IncrementalRebalancer].
+	Topics []string
 	// The compacted Kafka topic on which to publish/consume [StateStore] data. If not provided, GKES will generate a name which includes
 	// Topic and GroupId.
 	StateStoreTopic string
@@ -56,6 +62,8 @@ type EventSourceConfig struct {
 	MinInSync int
 	// The number of Kafka partitions to use for the applications commit log. Defaults to 5 if unset.
 	CommitLogPartitions int
+	// The encoding of records written to the commit log. Defaults to CommitLogFormatLegacy. Records of either format are always readable. See [CommitLogFormat].
+	CommitLogFormat CommitLogFormat
 	// The Kafka cluster on which Topic resides, or the source of incoming events.
 	SourceCluster Cluster
 	// StateCluster is the Kafka cluster on which the commit log and the StateStore topic resides. If left unset (recommended), defaults to SourceCluster.
@@ -82,6 +90,13 @@ type EventSourceConfig struct {
 	// (presumably because the MetricHandler is not able to keep up),
 	// GKES will drop the metric and log at WARN level to prevent processing slow down.
 	MetricsHandler MetricsHandler
+	// If greater than zero, and MetricsHandler is set, a PartitionLagOperation Metric is emitted for each assigned partition at this interval.
+	// See [EventSource.Progress].
+	ProgressInterval time.Duration
+
+	// If non-nil, trace context is propagated from IncomingRecords, through a span around each EventProcessor and Interjector invocation,
+	// to the Records forwarded by the EventContext. See [Tracer].
+	Tracer Tracer
 
 	// Called when a partition has been assigned to the EventSource consumer client. This does not indicate that the partion is being processed.
 	OnPartitionAssigned SourcePartitionEventHandler
@@ -97,6 +112,37 @@ type EventSourceConfig struct {
 	OnPartitionRevoked          SourcePartitionEventHandler
 	DeserializationErrorHandler DeserializationErrorHandler
 	TxnErrorHandler             TxnErrorHandler
+	// Invoked when an EventProcessor, AsyncJob finalizer or Interjector returns [Fatal], or a [FallibleEventProcessor] returns an error.
+	// Returning Continue skips the event, committing it's offset. Defaults to [DefaultProcessingErrorHandler].
+	ProcessingErrorHandler ProcessingErrorHandler
+	// If greater than zero, and your StateStore implements [SnapshotStateStore], a snapshot of each active partition is written to SnapshotTopic
+	// at this interval (plus or minus 10%). Newly assigned partitions are bootstrapped from the latest snapshot, followed by the tail of the change log.
+	SnapshotInterval time.Duration
+	// The compacted Kafka topic on which to publish/consume StateStore snapshots. If not provided, GKES will generate a name which includes
+	// Topic and GroupId. Only created if SnapshotInterval is set.
+	SnapshotTopic string
+	// The address (host:port for example) at which this consumer serves interactive queries. Shared with other consumer group members
+	// so that [EventSource.Query] can report which member owns a partition. Only available when using the [IncrementalRebalancer].
+	AdvertisedAddr string
+	// The partitioner used by [EventSource.Query] to route a key to a partition of Topic. This should match the partitioner
+	// used by producers of Topic. Defaults to NewOptionalPartitioner(kgo.StickyKeyPartitioner(nil)), which gives parity with the canonical Java murmur2 partitioner.
+	Partitioner kgo.Partitioner
+	// The number of partitions, in addition to its assignments, for which each consumer keeps a warm standby copy of the StateStore.
+	// Standby copies continuously consume the state store topic. If a consumer fails, the [IncrementalRebalancer] prefers members holding
+	// a standby copy when reassigning its partitions, avoiding a full replay of the change log. The group leader's value is used.
+	// Only available when using the [IncrementalRebalancer]. Defaults to 0.
+	NumStandbyReplicas int
+	// If DefaultTopic is set, records which fail deserialization or processing may be forwarded to this dead letter topic (see [DeadLetterQueue]).
+	// Unless DeserializationErrorHandler is set, records which can not be deserialized are dead lettered rather than dropped.
+	// Dead letter records are produced by the transactional producer, so the topic is created on StateCluster.
+	// NumPartitions defaults to 1 and Cluster defaults to StateCluster.
+	DeadLetterDestination Destination
+	// The delays of tiered retry topics for records which fail processing due to a transient error (1s, 30s and 5m for example).
+	// A retry topic is created for each delay, co-partitioned with Topic and consumed by the EventSource (see [RetryQueue]).
+	// Retry topics are subscribed to explicitly, they are not added to Topics.
+	// Since retry records are produced by the transactional producer, CreateSource returns an error unless StateCluster is unset or the same cluster as SourceCluster,
+	// and, as with Topics, retries require the [IncrementalRebalancer].
+	RetryDelays []time.Duration
 }
 
 // A readonly wrapper of [EventSourceConfig]. When an [EventSource] is initialized, it reconciles the actual Topic configuration (NumPartitions)
@@ -108,7 +154,26 @@ type Source struct {
 }
 
 func newSource(config EventSourceConfig) *Source {
-	return &Source{state: uint64(Healthy), config: config, failure: make(chan error)}
+	config.Topic, config.Topics = resolveTopics(config.Topic, config.Topics)
+	// only the first failure is acted upon, later failures must not block
+	return &Source{state: uint64(Healthy), config: config, failure: make(chan error, 1)}
+}
+
+// Returns the primary topic and the de-duplicated list of all topics, with the primary topic first.
+func resolveTopics(topic string, topics []string) (string, []string) {
+	if len(topic) == 0 && len(topics) > 0 {
+		topic = topics[0]
+	}
+	if len(topic) == 0 {
+		return topic, nil
+	}
+	resolved := []string{topic}
+	for _, t := range topics {
+		if len(t) > 0 && !sak.Contains(resolved, t) {
+			resolved = append(resolved, t)
+		}
+	}
+	return topic, resolved
 }
 
 // A convenience method for creating a [Destination] form your Source. Can be used for creating a [Producer] or [BatchProducer] which publishes to your [EventSource].
@@ -148,10 +213,56 @@ func (s *Source) eosErrorHandler() TxnErrorHandler {
 }
 
 func (s *Source) deserializationErrorHandler() DeserializationErrorHandler {
-	if s.config.TxnErrorHandler == nil {
-		return DefaultDeserializationErrorHandler
+	if s.config.DeserializationErrorHandler != nil {
+		return s.config.DeserializationErrorHandler
+	}
+	if s.deadLettersEnabled() {
+		return s.DeadLetterQueue().DeserializationErrorHandler
+	}
+	return DefaultDeserializationErrorHandler
+}
+
+func (s *Source) processingErrorHandler() ProcessingErrorHandler {
+	if s.config.ProcessingErrorHandler == nil {
+		return DefaultProcessingErrorHandler
+	}
+	return s.config.ProcessingErrorHandler
+}
+
+// Invokes the ProcessingErrorHandler for an EventContext which returned Fatal.
+func (s *Source) processingErrorResponse(ec failedContext) (ErrorResponse, error) {
+	err := ec.failure()
+	eventType := ""
+	if input, ok := ec.Input(); ok {
+		eventType = input.RecordType()
 	}
-	return s.config.DeserializationErrorHandler
+	return s.processingErrorHandler()(ec, eventType, err), err
+}
+
+// As processingErrorResponse, but fails the Source unless the handler returns Continue.
+func (s *Source) handleProcessingError(ec failedContext) ErrorResponse {
+	response, err := s.processingErrorResponse(ec)
+	if response != Continue {
+		s.fail(err)
+		if response == FatallyExit {
+			panic(err)
+		}
+	}
+	return response
+}
+
+func (s *Source) deadLettersEnabled() bool {
+	return len(s.config.DeadLetterDestination.DefaultTopic) > 0
+}
+
+// Returns a [DeadLetterQueue] for EventSourceConfig.DeadLetterDestination. If DeadLetterDestination.DefaultTopic is not set,
+// records forwarded to the DeadLetterQueue will fail to produce.
+func (s *Source) DeadLetterQueue() DeadLetterQueue {
+	destination := s.config.DeadLetterDestination
+	if destination.Cluster == nil {
+		destination.Cluster = s.stateCluster()
+	}
+	return NewDeadLetterQueue(destination)
 }
 
 func (s *Source) executeHandler(handler SourcePartitionEventHandler, partitions []int32) {
@@ -168,13 +279,35 @@ func (s *Source) State() EventSourceState {
 
 func (s *Source) fail(err error) {
 	atomic.StoreUint64(&s.state, uint64(Unhealthy))
-	s.failure <- err
+	select {
+	case s.failure <- err:
+	default:
+		log.Errorf("EventSource is already failing, ignoring: %v", err)
+	}
 }
 
 func (s *Source) Topic() string {
 	return s.config.Topic
 }
 
+// Returns every source topic of Source, Topic first. Retry topics are not included.
+func (s *Source) Topics() []string {
+	return s.config.Topics
+}
+
+// Returns every topic consumed by the EventSource, Topics followed by the retry topics, which are consumed along side them.
+func (s *Source) consumedTopics() []string {
+	if len(s.config.RetryDelays) == 0 {
+		return s.config.Topics
+	}
+	return append(append([]string{}, s.config.Topics...), s.RetryTopicNames()...)
+}
+
+// Returns true if Source consumes more than one topic.
+func (s *Source) isCoPartitioned() bool {
+	return len(s.consumedTopics()) > 1
+}
+
 func (s *Source) GroupId() string {
 	return s.config.GroupId
 }
@@ -204,6 +337,47 @@ func (s *Source) StateStoreTopicName() string {
 	return fmt.Sprintf("gkes_change_log_%s_%s", s.config.Topic, s.config.GroupId)
 }
 
+// Returns the formatted topic name used for [SnapshotStateStore] snapshots of Source
+func (s *Source) SnapshotTopicName() string {
+	if len(s.config.SnapshotTopic) > 0 {
+		return s.config.SnapshotTopic
+	}
+	return fmt.Sprintf("gkes_snapshot_%s_%s", s.config.Topic, s.config.GroupId)
+}
+
+// Returns the formatted topic names used for the retry topics of Source, one per EventSourceConfig.RetryDelays entry
+func (s *Source) RetryTopicNames() []string {
+	names := make([]string, len(s.config.RetryDelays))
+	for i, delay := range s.config.RetryDelays {
+		names[i] = fmt.Sprintf("gkes_retry_%s_%s_%dms", s.config.Topic, s.config.GroupId, delay.Milliseconds())
+	}
+	return names
+}
+
+// Returns a [RetryQueue] for EventSourceConfig.RetryDelays. If no delays are configured, records are immediately forwarded to the DeadLetterQueue.
+func (s *Source) RetryQueue() RetryQueue {
+	rq := RetryQueue{
+		topics: s.RetryTopicNames(),
+		delays: s.config.RetryDelays,
+	}
+	if s.deadLettersEnabled() {
+		dlq := s.DeadLetterQueue()
+		rq.deadLetters = &dlq
+	}
+	return rq
+}
+
+func (s *Source) partitioner() kgo.Partitioner {
+	if s.config.Partitioner == nil {
+		return NewOptionalPartitioner(kgo.StickyKeyPartitioner(nil))
+	}
+	return s.config.Partitioner
+}
+
+func (s *Source) snapshotsEnabled() bool {
+	return s.config.SnapshotInterval > 0
+}
+
 // Returns Source.StateCluster if defined, otherwise Source.Cluster
 func (s *Source) stateCluster() Cluster {
 	if s.config.StateCluster == nil {
@@ -230,6 +404,13 @@ func replicationFactorConfig(source *Source) int {
 	return source.config.ReplicationFactor
 }
 
+func deadLetterPartitionsConfig(source *Source) int {
+	if source.config.DeadLetterDestination.NumPartitions <= 0 {
+		return 1
+	}
+	return source.config.DeadLetterDestination.NumPartitions
+}
+
 func commitLogPartitionsConfig(source *Source) int {
 	if source.config.CommitLogPartitions <= 0 {
 		return 5
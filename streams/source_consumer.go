@@ -24,6 +24,9 @@ import (
 	"github.com/twmb/franz-go/pkg/kgo"
 )
 
+// the maximum amount of time to wait for pending transactions when checkpointing revoked partitions. applies to all partitions revoked together
+const checkpointTimeout = 30 * time.Second
+
 // A thick wrapper around a kgo.Client. Handles interaction with IncrementalRebalancer, as well as providing mechanisms for interjecting into a stream.
 type eventSourceConsumer[T StateStore] struct {
 	client             *kgo.Client
@@ -32,6 +35,7 @@ type eventSourceConsumer[T StateStore] struct {
 	ctx                context.Context
 	workers            map[int32]*partitionWorker[T]
 	prepping           map[int32]*stateStorePartition[T]
+	standby            map[int32]*stateStorePartition[T]
 	workerMux          sync.Mutex
 	preppingMux        sync.Mutex
 	incrBalancer       IncrementalGroupRebalancer
@@ -56,6 +60,7 @@ func newEventSourceConsumer[T StateStore](eventSource *EventSource[T], additiona
 		ctx:              eventSource.runStatus.Ctx(),
 		workers:          make(map[int32]*partitionWorker[T]),
 		prepping:         make(map[int32]*stateStorePartition[T]),
+		standby:          make(map[int32]*stateStorePartition[T]),
 		eventSource:      eventSource,
 		source:           source,
 		commitLog:        cl,
@@ -71,7 +76,7 @@ func newEventSourceConsumer[T StateStore](eventSource *EventSource[T], additiona
 	opts := []kgo.Opt{
 		balancerOpt,
 		kgo.ConsumerGroup(source.config.GroupId),
-		kgo.ConsumeTopics(source.config.Topic),
+		kgo.ConsumeTopics(source.consumedTopics()...),
 		kgo.OnPartitionsAssigned(sc.partitionsAssigned),
 		kgo.OnPartitionsRevoked(sc.partitionsRevoked),
 		kgo.SessionTimeout(6 * time.Second),
@@ -141,10 +146,19 @@ func (sc *eventSourceConsumer[T]) PrepareTopicPartition(tp TopicPartition) {
 	defer sc.preppingMux.Unlock()
 	partition := tp.Partition
 	if _, ok := sc.prepping[partition]; !ok {
-		store := sc.partitionedStore.assign(partition)
-
-		ssp := sc.stateStoreConsumer.preparePartition(partition, store)
+		var ssp *stateStorePartition[T]
+		if standby, ok := sc.standby[partition]; ok {
+			// already warm, we just need to catch up
+			log.Debugf("prepping standby partition %+v", tp)
+			delete(sc.standby, partition)
+			standby.setState(prepping)
+			ssp = standby
+		} else {
+			store := sc.partitionedStore.assign(partition)
+			ssp = sc.stateStoreConsumer.preparePartition(partition, store)
+		}
 		sc.prepping[partition] = ssp
+		sc.emitPartitionCount(PartitionsPreparingOperation, len(sc.prepping))
 		go func() {
 			start := time.Now()
 			log.Debugf("prepping %+v", tp)
@@ -180,6 +194,7 @@ func (sc *eventSourceConsumer[T]) ForgetPreparedTopicPartition(tp TopicPartition
 	if _, ok := sc.prepping[tp.Partition]; ok {
 		sc.stateStoreConsumer.cancelPartition(tp.Partition)
 		delete(sc.prepping, tp.Partition)
+		sc.emitPartitionCount(PartitionsPreparingOperation, len(sc.prepping))
 	} else {
 		// what to do? probably nothing, but if we have a double assignment, we could have problems
 		// need to investigate this race condition further
@@ -187,6 +202,50 @@ func (sc *eventSourceConsumer[T]) ForgetPreparedTopicPartition(tp TopicPartition
 	}
 }
 
+// Needed to fulfill the standbyMaintainer interface defined by IncrementalGroupRebalancer.
+// Should NOT be invoked directly.
+func (sc *eventSourceConsumer[T]) NumStandbyReplicas() int {
+	return sc.source.config.NumStandbyReplicas
+}
+
+// Needed to fulfill the standbyMaintainer interface defined by IncrementalGroupRebalancer.
+// Should NOT be invoked directly.
+func (sc *eventSourceConsumer[T]) MaintainStandbyTopicPartitions(tps []TopicPartition) {
+	sc.workerMux.Lock()
+	defer sc.workerMux.Unlock()
+	sc.preppingMux.Lock()
+	defer sc.preppingMux.Unlock()
+	wanted := make(map[int32]struct{}, len(tps))
+	for _, tp := range tps {
+		wanted[tp.Partition] = struct{}{}
+	}
+	for p := range sc.standby {
+		if _, ok := wanted[p]; !ok {
+			log.Debugf("forgetting standby partition %d", p)
+			sc.stateStoreConsumer.cancelPartition(p)
+			sc.partitionedStore.revoke(p)
+			delete(sc.standby, p)
+		}
+	}
+	for p := range wanted {
+		_, active := sc.workers[p]
+		_, prepped := sc.prepping[p]
+		_, warm := sc.standby[p]
+		if active || prepped || warm {
+			continue
+		}
+		log.Debugf("maintaining standby partition %d", p)
+		store := sc.partitionedStore.assign(p)
+		sc.standby[p] = sc.stateStoreConsumer.standbyPartition(p, store)
+	}
+}
+
+// Needed to fulfill the coPartitioner interface defined by IncrementalGroupRebalancer.
+// Should NOT be invoked directly.
+func (sc *eventSourceConsumer[T]) CoPartitionedTopics() []string {
+	return sc.source.consumedTopics()
+}
+
 func (sc *eventSourceConsumer[T]) assignPartitions(topic string, partitions []int32) {
 	sc.workerMux.Lock()
 	defer sc.workerMux.Unlock()
@@ -207,6 +266,14 @@ func (sc *eventSourceConsumer[T]) assignPartitions(topic string, partitions []in
 				prepper.sync()
 				// sc.client.ResumeFetchPartitions(map[string][]int32{topic: {p}})
 			})
+		} else if standby, ok := sc.standby[p]; ok && sc.workers[p] == nil {
+			log.Infof("syncing standby partition %+v", standby.topicPartition)
+			delete(sc.standby, p)
+			// the next marker will complete the sync
+			standby.setState(ready)
+			sc.workers[p] = newPartitionWorker(sc.eventSource, tp, sc.commitLog, store, sc.producerPool, func() {
+				standby.sync()
+			})
 		} else if _, ok := sc.workers[p]; !ok {
 			prepper = sc.stateStoreConsumer.activatePartition(p, store)
 			log.Infof("syncing unprepped partition %+v", prepper.topicPartition)
@@ -217,6 +284,8 @@ func (sc *eventSourceConsumer[T]) assignPartitions(topic string, partitions []in
 		}
 
 	}
+	sc.emitPartitionCount(PartitionsAssignedOperation, len(sc.workers))
+	sc.emitPartitionCount(PartitionsPreparingOperation, len(sc.prepping))
 	sc.incrBalancer.PartitionsAssigned(toTopicPartitions(topic, partitions...)...)
 	// notify observers
 	sc.source.onPartitionsAssigned(partitions)
@@ -229,27 +298,101 @@ func (sc *eventSourceConsumer[T]) revokePartitions(topic string, partitions []in
 		return
 	}
 
+	revoked := make([]*partitionWorker[T], 0, len(partitions))
 	for _, p := range partitions {
 		sc.source.onPartitionWillRevoke(p)
 		if worker, ok := sc.workers[p]; ok {
 			worker.revoke()
 			delete(sc.workers, p)
+			revoked = append(revoked, worker)
 		}
+	}
+	sc.checkpoint(revoked)
+	for _, p := range partitions {
 		sc.partitionedStore.revoke(p)
 	}
+	sc.emitPartitionCount(PartitionsAssignedOperation, len(sc.workers))
 	// notify observers
 	sc.source.onPartitionsRevoked(partitions)
 }
 
+func (sc *eventSourceConsumer[T]) emitPartitionCount(operation string, count int) {
+	now := time.Now()
+	emitMetric(sc.metrics, Metric{
+		Operation:      operation,
+		StartTime:      now,
+		EndTime:        now,
+		PartitionCount: count,
+		Partition:      -1,
+		Topic:          sc.source.Topic(),
+		GroupId:        sc.source.GroupId(),
+	})
+}
+
+// Checkpoints revoked partitions in parallel, as we are blocking the rebalance. Partitions which can not be checkpointed
+// within checkpointTimeout are skipped, and will be fully replayed on their next assignment.
+func (sc *eventSourceConsumer[T]) checkpoint(revoked []*partitionWorker[T]) {
+	ctx, cancel := context.WithTimeout(context.Background(), checkpointTimeout)
+	defer cancel()
+	wg := sync.WaitGroup{}
+	for _, worker := range revoked {
+		cs, ok := any(worker.changeLog.grab()).(CheckpointStateStore)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(worker *partitionWorker[T], cs CheckpointStateStore) {
+			defer wg.Done()
+			sc.checkpointPartition(ctx, worker, cs)
+		}(worker, cs)
+	}
+	wg.Wait()
+}
+
+// Waits for all pending transactions for the partition to commit, then records the end offset of the state store topic partition
+// so that it need not be replayed on the next assignment.
+func (sc *eventSourceConsumer[T]) checkpointPartition(ctx context.Context, worker *partitionWorker[T], cs CheckpointStateStore) {
+	if !worker.waitForClose(ctx) {
+		log.Warnf("not checkpointing %+v, partition was not active or pending transactions did not complete", worker.topicPartition)
+		return
+	}
+	offset, err := sc.stateStoreConsumer.committedEndOffset(ctx, worker.topicPartition.Partition)
+	if err != nil {
+		log.Errorf("could not retrieve state store offset for %+v, err: %v", worker.topicPartition, err)
+		return
+	}
+	if err = cs.WriteCheckpoint(offset); err != nil {
+		log.Errorf("could not checkpoint %+v, err: %v", worker.topicPartition, err)
+		return
+	}
+	log.Infof("checkpointed state store for %+v at offset: %d", worker.topicPartition, offset)
+}
+
+// Co-partitioned topics share a partitionWorker and StateStore, so their assignments are merged into those of the primary topic.
+func (sc *eventSourceConsumer[T]) mergeCoPartitioned(assignments map[string][]int32) map[string][]int32 {
+	if !sc.source.isCoPartitioned() {
+		return assignments
+	}
+	merged := []int32{}
+	for _, partitions := range assignments {
+		for _, p := range partitions {
+			if !sak.Contains(merged, p) {
+				merged = append(merged, p)
+			}
+		}
+	}
+	return map[string][]int32{sc.source.Topic(): merged}
+}
+
 func (sc *eventSourceConsumer[T]) partitionsAssigned(ctx context.Context, _ *kgo.Client, assignments map[string][]int32) {
-	for topic, partitions := range assignments {
+	for topic, partitions := range sc.mergeCoPartitioned(assignments) {
 		log.Debugf("assigned topic: %s, partitions: %v", topic, assignments)
 		sc.assignPartitions(topic, partitions)
 	}
 }
 
 func (sc *eventSourceConsumer[T]) partitionsRevoked(ctx context.Context, _ *kgo.Client, assignments map[string][]int32) {
-	for topic, partitions := range assignments {
+	for topic, partitions := range sc.mergeCoPartitioned(assignments) {
 		log.Debugf("revoked topic: %s, partitions: %v", topic, assignments)
 		sc.revokePartitions(topic, partitions)
 	}
@@ -395,5 +538,7 @@ func (sc *eventSourceConsumer[T]) leave() <-chan struct{} {
 // Immediately stops the consumer, leaving the consumer group abruptly.
 func (sc *eventSourceConsumer[T]) stop() {
 	sc.client.Close()
+	// standby stores are not revoked with our assignments, release them here
+	sc.MaintainStandbyTopicPartitions(nil)
 	log.Infof("left group: %v", sc.source.GroupId())
 }
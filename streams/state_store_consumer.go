@@ -16,12 +16,14 @@ package streams
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/aws/go-kafka-event-source/streams/sak"
 	"github.com/google/uuid"
+	"github.com/twmb/franz-go/pkg/kadm"
 	"github.com/twmb/franz-go/pkg/kgo"
 )
 
@@ -47,6 +49,28 @@ type StateStore interface {
 	Revoked()
 }
 
+/*
+CheckpointStateStore is an optional extension of StateStore for implementations which persist their contents locally
+(on disk for example) between partition assignments and process restarts. When a partition is assigned, the state store topic is
+consumed from the checkpointed offset rather than the beginning, so only the tail of the change log is replayed.
+
+ReadCheckpoint is invoked when a partition is assigned, before any calls to ReceiveChange. If `ok` is false,
+the implementation must discard any local contents before returning, as the entire change log will be replayed.
+Since the store will be mutated by event processing once active, a checkpoint is only valid once. Implementations should discard the
+checkpoint when it is read, so that an unclean shutdown results in a full replay on the next assignment.
+
+WriteCheckpoint is invoked when the partition is revoked cleanly, after all transactions for the partition have been committed
+and before Revoked() is called. `offset` is the next offset of the state store topic partition to be consumed.
+Local contents must be durable before WriteCheckpoint returns.
+
+See [github.com/aws/go-kafka-event-source/streams/stores.DiskStore] for an example implementation.
+*/
+type CheckpointStateStore interface {
+	StateStore
+	ReadCheckpoint() (offset int64, ok bool)
+	WriteCheckpoint(offset int64) error
+}
+
 type stateStorePartition[T StateStore] struct {
 	buffer         chan []*kgo.Record
 	client         *kgo.Client
@@ -55,8 +79,12 @@ type stateStorePartition[T StateStore] struct {
 	state          partitionState
 	count          uint64
 	byteCount      uint64
+	offset         int64 // the next offset to be replayed
 	waiterLock     sync.Mutex
 	highWatermark  int64
+	// incremented by each prep() and cancel(), so a goroutine started by an earlier prep() can tell that it is stale
+	generation uint64
+	prepLock   sync.Mutex
 }
 
 func (ssp *stateStorePartition[T]) add(ftp kgo.FetchTopicPartition) {
@@ -81,6 +109,9 @@ func (ssp *stateStorePartition[T]) kill() {
 }
 
 func (ssp *stateStorePartition[T]) cancel() {
+	ssp.prepLock.Lock()
+	defer ssp.prepLock.Unlock()
+	ssp.generation++
 	ssp.pause()
 	ssp.kill()
 }
@@ -119,6 +150,10 @@ func (ssp *stateStorePartition[T]) processedBytes() uint64 {
 	return atomic.LoadUint64(&ssp.byteCount)
 }
 
+func (ssp *stateStorePartition[T]) replayedOffset() int64 {
+	return atomic.LoadInt64(&ssp.offset)
+}
+
 func (ssp *stateStorePartition[T]) partitionState() partitionState {
 	return partitionState(atomic.LoadUint32((*uint32)(&ssp.state)))
 }
@@ -127,20 +162,38 @@ func (ssp *stateStorePartition[T]) setState(state partitionState) {
 	atomic.StoreUint32((*uint32)(&ssp.state), uint32(state))
 }
 
-func (ssp *stateStorePartition[T]) prep(intitialState partitionState, store changeLogPartition[T]) {
+func (ssp *stateStorePartition[T]) prep(intitialState partitionState, store changeLogPartition[T], startingOffset func() kgo.EpochOffset) {
+	ssp.prepLock.Lock()
+	defer ssp.prepLock.Unlock()
+	ssp.generation++
+	generation := ssp.generation
 	ssp.setState(intitialState)
 	ssp.count = 0
 	ssp.byteCount = 0
-	ssp.buffer = make(chan []*kgo.Record, 1024)
+	atomic.StoreInt64(&ssp.offset, -1)
+	buffer := make(chan []*kgo.Record, 1024)
+	ssp.buffer = buffer
 	topic := ssp.topicPartition.Topic
 	partition := ssp.topicPartition.Partition
-	ssp.client.SetOffsets(map[string]map[int32]kgo.EpochOffset{
-		topic: {partition: startEpochOffset},
-	})
-	ssp.client.ResumeFetchPartitions(map[string][]int32{
-		topic: {partition},
-	})
-	go ssp.populate(store)
+	// resolving the starting offset may involve restoring a snapshot, so don't block the caller
+	// any sync() markers sent in the meantime will have a higher offset, so there is no danger of missing them
+	go func() {
+		offset := startingOffset()
+		ssp.prepLock.Lock()
+		if ssp.generation != generation {
+			// cancelled, and possibly prepped again, while resolving the starting offset
+			ssp.prepLock.Unlock()
+			return
+		}
+		ssp.client.SetOffsets(map[string]map[int32]kgo.EpochOffset{
+			topic: {partition: offset},
+		})
+		ssp.client.ResumeFetchPartitions(map[string][]int32{
+			topic: {partition},
+		})
+		ssp.prepLock.Unlock()
+		ssp.populate(store, buffer)
+	}()
 }
 
 func (ssp *stateStorePartition[T]) isCompletionMarker(val []byte) (complete bool) {
@@ -162,9 +215,9 @@ func (ssp *stateStorePartition[T]) isCompletionMarker(val []byte) (complete bool
 	return
 }
 
-func (ssp *stateStorePartition[T]) populate(store changeLogPartition[T]) {
+func (ssp *stateStorePartition[T]) populate(store changeLogPartition[T], buffer chan []*kgo.Record) {
 	log.Debugf("starting populator for %+v", ssp.topicPartition)
-	for records := range ssp.buffer {
+	for records := range buffer {
 		if !ssp.handleRecordsAndContinue(records, store) {
 			log.Debugf("closed populator for %+v", ssp.topicPartition)
 			return
@@ -174,6 +227,7 @@ func (ssp *stateStorePartition[T]) populate(store changeLogPartition[T]) {
 
 func (ssp *stateStorePartition[T]) handleRecordsAndContinue(records []*kgo.Record, store changeLogPartition[T]) bool {
 	for _, record := range records {
+		atomic.StoreInt64(&ssp.offset, record.Offset+1)
 		if isMarkerRecord(record) {
 			if ssp.isCompletionMarker(record.Value) {
 				return false
@@ -261,7 +315,11 @@ func (ssc *stateStoreConsumer[T]) consume() {
 			}
 		}
 		f.EachPartition(func(partitionFetch kgo.FetchTopicPartition) {
-			ssp := ssc.partitions[partitionFetch.Partition]
+			ssp, ok := ssc.partitions[partitionFetch.Partition]
+			if !ok {
+				// client level errors (a poll timeout for example) are reported with a partition of -1
+				return
+			}
 			if ssp.partitionState() == paused {
 				ssp.pause()
 			} else {
@@ -285,7 +343,9 @@ func (ssc *stateStoreConsumer[T]) preparePartition(p int32, store changeLogParti
 	ssc.mux.Lock()
 	defer ssc.mux.Unlock()
 	ssp := ssc.partitions[p]
-	ssp.prep(prepping, store)
+	ssp.prep(prepping, store, func() kgo.EpochOffset {
+		return ssc.startingOffset(store.grab(), p)
+	})
 	return ssp
 }
 
@@ -293,10 +353,65 @@ func (ssc *stateStoreConsumer[T]) activatePartition(p int32, store changeLogPart
 	ssc.mux.Lock()
 	defer ssc.mux.Unlock()
 	ssp := ssc.partitions[p]
-	ssp.prep(ready, store)
+	ssp.prep(ready, store, func() kgo.EpochOffset {
+		return ssc.startingOffset(store.grab(), p)
+	})
 	return ssp
 }
 
+// Continuously consumes the change log for partition `p` into `store`, which is not assigned to this consumer.
+// The partition remains in the standby state until it is promoted or cancelled.
+func (ssc *stateStoreConsumer[T]) standbyPartition(p int32, store changeLogPartition[T]) *stateStorePartition[T] {
+	ssc.mux.Lock()
+	defer ssc.mux.Unlock()
+	ssp := ssc.partitions[p]
+	ssp.prep(standby, store, func() kgo.EpochOffset {
+		return ssc.startingOffset(store.grab(), p)
+	})
+	return ssp
+}
+
+// Returns the offset at which to begin replaying the change log for `store`. A local checkpoint takes precedence over a snapshot.
+func (ssc *stateStoreConsumer[T]) startingOffset(store T, p int32) kgo.EpochOffset {
+	tp := ntp(p, ssc.topic)
+	if cs, ok := any(store).(CheckpointStateStore); ok {
+		if offset, ok := cs.ReadCheckpoint(); ok {
+			log.Infof("resuming state store %+v from checkpoint offset: %d", tp, offset)
+			return kgo.EpochOffset{Offset: offset, Epoch: -1}
+		}
+		log.Infof("no checkpoint for state store %+v", tp)
+	}
+	if ss, ok := any(store).(SnapshotStateStore); ok && ssc.source.snapshotsEnabled() {
+		if offset, ok := restoreSnapshot(ssc.source, ss, p); ok {
+			log.Infof("resuming state store %+v from snapshot offset: %d", tp, offset)
+			return kgo.EpochOffset{Offset: offset, Epoch: -1}
+		}
+	}
+	return startEpochOffset
+}
+
+// Returns the offset following the last change log record replayed into the store for partition `p`, or -1 if the partition is not being replayed.
+// Once an assigned partition has been synced, this is the change log offset at the time of assignment.
+func (ssc *stateStoreConsumer[T]) replayedOffset(p int32) int64 {
+	if ssp, ok := ssc.partitions[p]; ok {
+		return ssp.replayedOffset()
+	}
+	return -1
+}
+
+// Returns the last stable offset of the state store topic for partition `p`.
+func (ssc *stateStoreConsumer[T]) committedEndOffset(ctx context.Context, p int32) (int64, error) {
+	offsets, err := kadm.NewClient(ssc.client).ListCommittedOffsets(ctx, ssc.topic)
+	if err != nil {
+		return -1, err
+	}
+	offset, ok := offsets.Lookup(ssc.topic, p)
+	if !ok {
+		return -1, fmt.Errorf("no offset returned for %s/%d", ssc.topic, p)
+	}
+	return offset.Offset, offset.Err
+}
+
 func (ssc *stateStoreConsumer[T]) stop() {
 	ssc.mux.Lock()
 	defer ssc.mux.Unlock()
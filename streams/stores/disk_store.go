@@ -0,0 +1,223 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stores
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams"
+	"github.com/aws/go-kafka-event-source/streams/sak"
+	bolt "go.etcd.io/bbolt"
+)
+
+var itemsBucket = []byte("items")
+var metaBucket = []byte("meta")
+var checkpointKey = []byte("checkpoint")
+
+/*
+DiskStore is a [streams.CheckpointStateStore] backed by an embedded bbolt database, one file per partition.
+Contents survive partition revocation and process restarts. When a partition is revoked cleanly, the EventSource
+records the state store topic offset in the database, so the next assignment of the partition on the same host only replays
+the tail of the change log.
+
+Writes are not synced to disk individually. If the process exits without a clean revocation, the checkpoint will not be present
+and the store is rebuilt from the beginning of the change log on the next assignment.
+*/
+type DiskStore[T Keyed] struct {
+	db             *bolt.DB
+	codec          streams.Codec[T]
+	topicPartition streams.TopicPartition
+}
+
+// Returns a StateStoreFactory which creates DiskStores in `dir`, using JsonCodec. Panics if a database can not be opened.
+func NewJsonDiskStoreFactory[T Keyed](dir string) streams.StateStoreFactory[*DiskStore[T]] {
+	return NewDiskStoreFactory[T](dir, streams.JsonCodec[T]{})
+}
+
+// Returns a StateStoreFactory which creates DiskStores in `dir`. Panics if a database can not be opened.
+func NewDiskStoreFactory[T Keyed](dir string, codec streams.Codec[T]) streams.StateStoreFactory[*DiskStore[T]] {
+	return func(tp streams.TopicPartition) *DiskStore[T] {
+		return sak.Must(NewDiskStore(dir, tp, codec))
+	}
+}
+
+// Opens, or creates, the database for `tp` in `dir`. If the existing database is unreadable, it is discarded and recreated.
+func NewDiskStore[T Keyed](dir string, tp streams.TopicPartition, codec streams.Codec[T]) (*DiskStore[T], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%d.db", tp.Topic, tp.Partition))
+	db, err := openDiskStoreDb(path)
+	if err != nil {
+		// a crash with NoSync set can leave the database in a corrupted state
+		// the contents would be discarded anyway since there is no checkpoint, so start over
+		if err = os.Remove(path); err != nil {
+			return nil, err
+		}
+		if db, err = openDiskStoreDb(path); err != nil {
+			return nil, err
+		}
+	}
+	return &DiskStore[T]{
+		db:             db,
+		codec:          codec,
+		topicPartition: tp,
+	}, nil
+}
+
+func openDiskStoreDb(path string) (*bolt.DB, error) {
+	db, err := bolt.Open(path, 0o644, &bolt.Options{
+		Timeout: 10 * time.Second,
+		NoSync:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(itemsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(metaBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// The path of the underlying database file.
+func (s *DiskStore[T]) Path() string {
+	return s.db.Path()
+}
+
+func (s *DiskStore[T]) Put(item T) (streams.ChangeLogEntry, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := s.codec.Encode(buf, item); err != nil {
+		return streams.ChangeLogEntry{}, err
+	}
+	key := item.Key()
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(itemsBucket).Put([]byte(key), buf.Bytes())
+	})
+	return streams.NewChangeLogEntry().WithKeyString(key).WithValue(buf.Bytes()), err
+}
+
+func (s *DiskStore[T]) Get(id string) (val T, ok bool, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket(itemsBucket).Get([]byte(id)); b != nil {
+			ok = true
+			val, err = s.codec.Decode(b)
+			return err
+		}
+		return nil
+	})
+	return
+}
+
+func (s *DiskStore[T]) Delete(item T) (cle streams.ChangeLogEntry, ok bool, err error) {
+	key := []byte(item.Key())
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(itemsBucket)
+		if ok = bucket.Get(key) != nil; ok {
+			return bucket.Delete(key)
+		}
+		return nil
+	})
+	if ok && err == nil {
+		cle = streams.NewChangeLogEntry().WithKey(key)
+	}
+	return
+}
+
+// Invokes `f` for every item in the store, in key order. Iteration stops if `f` returns false.
+func (s *DiskStore[T]) ForEach(f func(T) bool) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(itemsBucket).Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			item, err := s.codec.Decode(v)
+			if err != nil {
+				return err
+			}
+			if !f(item) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+func (s *DiskStore[T]) ReceiveChange(record streams.IncomingRecord) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(itemsBucket)
+		if len(record.Value()) == 0 {
+			return bucket.Delete(record.Key())
+		}
+		return bucket.Put(record.Key(), record.Value())
+	})
+}
+
+// Needed to fulfill the streams.CheckpointStateStore interface. Should NOT be invoked directly.
+// Consumes the checkpoint if present, otherwise clears the store. Panics if the database can not be updated,
+// as the contents of the store could not be trusted.
+func (s *DiskStore[T]) ReadCheckpoint() (offset int64, ok bool) {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+		if b := meta.Get(checkpointKey); len(b) == 8 {
+			offset, ok = int64(binary.BigEndian.Uint64(b)), true
+			return meta.Delete(checkpointKey)
+		}
+		if err := tx.DeleteBucket(itemsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket(itemsBucket)
+		return err
+	})
+	if err == nil {
+		// the store will be mutated once active, make sure the checkpoint is gone for good
+		err = s.db.Sync()
+	}
+	if err != nil {
+		panic(fmt.Errorf("DiskStore %+v could not read checkpoint: %w", s.topicPartition, err))
+	}
+	return
+}
+
+// Needed to fulfill the streams.CheckpointStateStore interface. Should NOT be invoked directly.
+func (s *DiskStore[T]) WriteCheckpoint(offset int64) error {
+	if err := s.db.Sync(); err != nil {
+		return err
+	}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, uint64(offset))
+		return tx.Bucket(metaBucket).Put(checkpointKey, b)
+	})
+	if err != nil {
+		return err
+	}
+	return s.db.Sync()
+}
+
+// Closes the underlying database. The contents remain on disk.
+func (s *DiskStore[T]) Revoked() {
+	s.db.Close()
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stores
+
+import (
+	"testing"
+
+	"github.com/aws/go-kafka-event-source/streams"
+)
+
+type diskItem struct {
+	Id    string
+	Value int
+}
+
+func (di diskItem) Key() string {
+	return di.Id
+}
+
+func TestDiskStoreCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	tp := streams.TopicPartition{Topic: "disk_store", Partition: 3}
+	factory := NewJsonDiskStoreFactory[diskItem](dir)
+
+	store := factory(tp)
+	if _, ok := store.ReadCheckpoint(); ok {
+		t.Fatalf("unexpected checkpoint for new store")
+	}
+	if _, err := store.Put(diskItem{Id: "a", Value: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.WriteCheckpoint(42); err != nil {
+		t.Fatal(err)
+	}
+	store.Revoked()
+
+	store = factory(tp)
+	if offset, ok := store.ReadCheckpoint(); !ok || offset != 42 {
+		t.Errorf("incorrect checkpoint. actual: %d, %v, expected: %d, %v", offset, ok, 42, true)
+	}
+	if item, ok, err := store.Get("a"); err != nil || !ok || item.Value != 1 {
+		t.Errorf("item not restored. actual: %+v, %v, err: %v", item, ok, err)
+	}
+	// simulate an unclean shutdown, no checkpoint is written
+	store.Revoked()
+
+	store = factory(tp)
+	defer store.Revoked()
+	if _, ok := store.ReadCheckpoint(); ok {
+		t.Errorf("checkpoint should only be valid once")
+	}
+	if _, ok, _ := store.Get("a"); ok {
+		t.Errorf("store should be empty without a checkpoint")
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+/*
+A Stream is a typed pipeline of processing steps, which compiles down to a single EventProcessor registered with an EventSource.
+Create a Stream with [From] or [FromRoute], then add steps:
+
+	orders := streams.From(eventSource, streams.JsonItemDecoder[Order], "order").
+		Filter(func(ec *streams.EventContext[myStore], order Order) bool {
+			return order.Total > 0
+		})
+	branches := streams.Map(orders, toInvoice).Branch(isDomestic, isInternational)
+	branches[0].To(domestic, streams.EncodeWith("invoice", streams.JsonCodec[Invoice]{}))
+	branches[1].To(international, streams.EncodeWith("invoice", streams.JsonCodec[Invoice]{}))
+
+Go methods can not introduce type parameters, so steps which change the event type ([Map] and [FlatMap]) are functions rather than methods.
+
+Every step receives the EventContext of the consumed event, so steps may read the partition StateStore via EventContext.Store and
+record changes to it via EventContext.RecordChange. Records produced by To are sent via EventContext.Forward, so state changes and
+output records are committed in the same transaction as the consumed event's offset, exactly as with a hand written EventProcessor.
+
+A step may be followed by more than one step, in which case each event is passed to all of them in the order they were added.
+Every step is invoked, so an asynchronous step (one which returns Incomplete, see EventContext.AsyncJobComplete) does not prevent the steps which follow it
+from processing the event, and Incomplete is returned to the EventSource. At most one step may return Incomplete for each event, as each
+asynchronous job completes the event. Processing of an event stops at the first step which returns Fatal, which is passed to the ProcessingErrorHandler.
+Steps must not be added after `EventSource.ConsumeEvents()`.
+*/
+type Stream[T StateStore, V any] struct {
+	next []EventProcessor[T, V]
+}
+
+// A function which encodes an event into a Record to be forwarded by [Stream.To].
+type RecordEncoder[V any] func(V) (*Record, error)
+
+// Creates a RecordEncoder which encodes the event value with `codec`, with a record type of `recordType`.
+// The Key of the record is left uninitialized, so the key of the consumed record is used.
+func EncodeWith[V any](recordType string, codec Codec[V]) RecordEncoder[V] {
+	return func(v V) (*Record, error) {
+		record := NewRecord().WithRecordType(recordType)
+		if err := codec.Encode(record.ValueWriter(), v); err != nil {
+			record.Release()
+			return nil, err
+		}
+		return record, nil
+	}
+}
+
+// Creates a Stream of events of eventType, registered via RegisterEventType.
+func From[T StateStore, V any](es *EventSource[T], decoder IncomingRecordDecoder[V], eventType string) *Stream[T, V] {
+	s := new(Stream[T, V])
+	RegisterEventType(es, decoder, s.process, eventType)
+	return s
+}
+
+// Creates a Stream of events matching `match`, registered via RegisterRoute.
+func FromRoute[T StateStore, V any](es *EventSource[T], name string, match RouteMatcher, decoder IncomingRecordDecoder[V]) *Stream[T, V] {
+	s := new(Stream[T, V])
+	RegisterRoute(es, name, match, decoder, s.process)
+	return s
+}
+
+func (s *Stream[T, V]) process(ec *EventContext[T], v V) ExecutionState {
+	state := Complete
+	for _, next := range s.next {
+		if state = combineStates(ec, state, next(ec, v)); state == Fatal {
+			return Fatal
+		}
+	}
+	return state
+}
+
+// Combines the ExecutionState of a step with that of the steps which preceded it for the same event.
+func combineStates[T StateStore](ec *EventContext[T], current, next ExecutionState) ExecutionState {
+	switch {
+	case next == Complete:
+		return current
+	case current == Incomplete && next == Fatal:
+		// the event will be completed by the pending asynchronous job, so the failure can not be returned to the EventSource
+		log.Errorf("stream step failed for %+v, offset: %d, after a preceding step returned Incomplete, error: %v", ec.TopicPartition(), ec.Offset(), ec.failure())
+		return Incomplete
+	case current == Incomplete && next == Incomplete:
+		log.Errorf("more than one stream step returned Incomplete for %+v, offset: %d", ec.TopicPartition(), ec.Offset())
+		return Incomplete
+	case current == Incomplete && next == Retried:
+		// the event will still be completed by the pending asynchronous job
+		return Incomplete
+	}
+	return next
+}
+
+func (s *Stream[T, V]) then(processor EventProcessor[T, V]) {
+	s.next = append(s.next, processor)
+}
+
+// Returns a Stream of the events for which `predicate` returns true.
+func (s *Stream[T, V]) Filter(predicate func(*EventContext[T], V) bool) *Stream[T, V] {
+	filtered := new(Stream[T, V])
+	s.then(func(ec *EventContext[T], v V) ExecutionState {
+		if predicate(ec, v) {
+			return filtered.process(ec, v)
+		}
+		return Complete
+	})
+	return filtered
+}
+
+// Invokes `fn` for every event, typically to update the StateStore, and returns a Stream of the same events.
+func (s *Stream[T, V]) Peek(fn func(*EventContext[T], V)) *Stream[T, V] {
+	peeked := new(Stream[T, V])
+	s.then(func(ec *EventContext[T], v V) ExecutionState {
+		fn(ec, v)
+		return peeked.process(ec, v)
+	})
+	return peeked
+}
+
+// Splits the Stream into one Stream per predicate. Each event is passed to the Stream of the first predicate which returns true,
+// and events which match no predicate are dropped.
+func (s *Stream[T, V]) Branch(predicates ...func(*EventContext[T], V) bool) []*Stream[T, V] {
+	branches := make([]*Stream[T, V], len(predicates))
+	for i := range branches {
+		branches[i] = new(Stream[T, V])
+	}
+	s.then(func(ec *EventContext[T], v V) ExecutionState {
+		for i, predicate := range predicates {
+			if predicate(ec, v) {
+				return branches[i].process(ec, v)
+			}
+		}
+		return Complete
+	})
+	return branches
+}
+
+// Encodes each event with `encoder` and forwards it to `destination`. If the encoded Record has no topic, destination.DefaultTopic is used,
+// and if it has no key, the key of the consumed record is used so that related events remain co-partitioned.
+// An encoding error is passed to the EventSourceConfig.ProcessingErrorHandler.
+func (s *Stream[T, V]) To(destination Destination, encoder RecordEncoder[V]) {
+	s.then(func(ec *EventContext[T], v V) ExecutionState {
+		record, err := encoder(v)
+		if err != nil {
+			return ec.Fail(err)
+		}
+		if len(record.kRecord.Topic) == 0 {
+			record.WithTopic(destination.DefaultTopic)
+		}
+		if record.keyBuffer.Len() == 0 {
+			if input, ok := ec.Input(); ok {
+				record.WriteKey(input.Key())
+			}
+		}
+		ec.Forward(record)
+		return Complete
+	})
+}
+
+// Passes each event to `eventProcessor`. If it returns Incomplete, the event is completed by it's asynchronous job, while
+// Fatal stops processing of the event.
+func (s *Stream[T, V]) Process(eventProcessor EventProcessor[T, V]) {
+	s.then(eventProcessor)
+}
+
+// Returns a Stream of the results of `fn` for every event in `s`.
+func Map[T StateStore, V any, W any](s *Stream[T, V], fn func(*EventContext[T], V) W) *Stream[T, W] {
+	mapped := new(Stream[T, W])
+	s.then(func(ec *EventContext[T], v V) ExecutionState {
+		return mapped.process(ec, fn(ec, v))
+	})
+	return mapped
+}
+
+// Returns a Stream of every result of `fn` for every event in `s`. Results are processed in order,
+// so, as with sibling steps, at most one result may be processed asynchronously.
+func FlatMap[T StateStore, V any, W any](s *Stream[T, V], fn func(*EventContext[T], V) []W) *Stream[T, W] {
+	mapped := new(Stream[T, W])
+	s.then(func(ec *EventContext[T], v V) ExecutionState {
+		state := Complete
+		for _, w := range fn(ec, v) {
+			if state = combineStates(ec, state, mapped.process(ec, w)); state == Fatal {
+				return Fatal
+			}
+		}
+		return state
+	})
+	return mapped
+}
@@ -0,0 +1,161 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	driver := newTestDriverForTest()
+	defer driver.Close()
+	es := driver.EventSource()
+
+	items := From(es, decodeIntStoreItem, "item").
+		Peek(func(ec *EventContext[intStore], item intStoreItem) {
+			ec.Store().add(item)
+			cle := NewChangeLogEntry().WithEntryType("item")
+			item.encodeKey(cle)
+			item.encodeValue(cle)
+			ec.RecordChange(cle)
+		}).
+		Filter(func(ec *EventContext[intStore], item intStoreItem) bool {
+			return item.Value > 0
+		})
+	doubled := Map(items, func(ec *EventContext[intStore], item intStoreItem) int {
+		return item.Value * 2
+	})
+	branches := doubled.Branch(
+		func(ec *EventContext[intStore], v int) bool { return v%4 == 0 },
+		func(ec *EventContext[intStore], v int) bool { return v < 10 })
+	branches[0].To(Destination{DefaultTopic: "fours"}, EncodeWith[int]("doubled", IntCodec))
+	branches[1].To(Destination{DefaultTopic: "small"}, func(v int) (*Record, error) {
+		record := NewRecord().WithRecordType("small").WithTopic("override")
+		IntCodec.Encode(record.KeyWriter(), -v)
+		IntCodec.Encode(record.ValueWriter(), v)
+		return record, nil
+	})
+	FlatMap(From(es, decodeIntStoreItem, "split"), func(ec *EventContext[intStore], item intStoreItem) []int {
+		split := make([]int, item.Value)
+		for i := range split {
+			split[i] = i
+		}
+		return split
+	}).To(Destination{DefaultTopic: "split"}, EncodeWith[int]("part", IntCodec))
+
+	driver.Pipe(0,
+		intRecord("item", 1, 2),
+		intRecord("item", 2, 3),
+		intRecord("item", 3, 0),
+		intRecord("item", 4, 7),
+		intRecord("split", 5, 3))
+
+	if l := driver.Store(0).tree.Len(); l != 4 {
+		t.Errorf("incorrect store size. actual: %d, expected: %d", l, 4)
+	}
+	if l := len(driver.ChangeLog(0)); l != 4 {
+		t.Errorf("incorrect change log length. actual: %d, expected: %d", l, 4)
+	}
+	fours := driver.Output("fours")
+	if len(fours) != 1 {
+		t.Fatalf("incorrect output count. actual: %d, expected: %d", len(fours), 1)
+	}
+	if key, _ := IntCodec.Decode(fours[0].Key()); key != 1 {
+		t.Errorf("consumed key not used. actual: %d, expected: %d", key, 1)
+	}
+	if value, _ := IntCodec.Decode(fours[0].Value()); value != 4 || fours[0].RecordType() != "doubled" {
+		t.Errorf("incorrect output: %d, %s", value, fours[0].RecordType())
+	}
+	small := driver.Output("override")
+	if len(small) != 1 || len(driver.Output("small")) != 0 {
+		t.Fatalf("encoded topic not used: %+v", small)
+	}
+	if key, _ := IntCodec.Decode(small[0].Key()); key != -6 {
+		t.Errorf("encoded key not used. actual: %d, expected: %d", key, -6)
+	}
+	split := driver.Output("split")
+	if len(split) != 3 {
+		t.Fatalf("incorrect split count. actual: %d, expected: %d", len(split), 3)
+	}
+	for i, record := range split {
+		if value, _ := IntCodec.Decode(record.Value()); value != i {
+			t.Errorf("incorrect split order. actual: %d, expected: %d", value, i)
+		}
+	}
+}
+
+func TestStreamEncodingError(t *testing.T) {
+	var processingErr error
+	driver := NewTestDriver(EventSourceConfig{
+		GroupId:       "stream_group",
+		Topic:         "stream_topic",
+		NumPartitions: 1,
+		ProcessingErrorHandler: func(ec ErrorContext, eventType string, err error) ErrorResponse {
+			processingErr = err
+			return Continue
+		},
+	}, NewIntStore, defaultTestHandler)
+	defer driver.Close()
+
+	encodingErr := errors.New("encoding failed")
+	From(driver.EventSource(), decodeIntStoreItem, "item").To(Destination{DefaultTopic: "output"}, func(intStoreItem) (*Record, error) {
+		return nil, encodingErr
+	})
+	driver.Pipe(0, intRecord("item", 1, 1))
+	if !errors.Is(processingErr, encodingErr) {
+		t.Errorf("incorrect processing error. actual: %v, expected: %v", processingErr, encodingErr)
+	}
+	if len(driver.Output("output")) != 0 {
+		t.Errorf("unexpected output")
+	}
+}
+
+func TestStreamAsyncStep(t *testing.T) {
+	driver := newTestDriverForTest()
+	defer driver.Close()
+
+	items := From(driver.EventSource(), decodeIntStoreItem, "item")
+	items.Process(func(ec *EventContext[intStore], item intStoreItem) ExecutionState {
+		go ec.AsyncJobComplete(func() ExecutionState {
+			ec.Store().add(item)
+			return Complete
+		})
+		return Incomplete
+	})
+	Map(items, func(ec *EventContext[intStore], item intStoreItem) int {
+		return item.Value
+	}).To(Destination{DefaultTopic: "output"}, EncodeWith[int]("value", IntCodec))
+
+	if state := driver.Pipe(0, intRecord("item", 1, 2)); state != Incomplete {
+		t.Errorf("incorrect execution state. actual: %v, expected: %v", state, Incomplete)
+	}
+	if err := driver.AwaitAsync(defaultTestTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if driver.Pending() != 0 {
+		t.Errorf("incorrect pending count. actual: %d, expected: %d", driver.Pending(), 0)
+	}
+	if l := driver.Store(0).tree.Len(); l != 1 {
+		t.Errorf("incorrect store size. actual: %d, expected: %d", l, 1)
+	}
+	output := driver.Output("output")
+	if len(output) != 1 {
+		t.Fatalf("step following async step skipped. output count: %d, expected: %d", len(output), 1)
+	}
+	if value, _ := IntCodec.Decode(output[0].Value()); value != 2 {
+		t.Errorf("incorrect output. actual: %d, expected: %d", value, 2)
+	}
+}
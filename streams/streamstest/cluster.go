@@ -0,0 +1,95 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package streamstest provides an in-process, Kafka protocol compatible broker for testing GKES applications
+without a running Kafka cluster. The broker supports everything an [github.com/aws/go-kafka-event-source/streams.EventSource]
+needs to function: transactions, consumer groups (including the IncrementalGroupRebalancer) and compacted topics.
+
+The [Cluster] returned by [NewCluster] fulfills the [github.com/aws/go-kafka-event-source/streams.Cluster] interface, so it can be used
+anywhere a SourceCluster, StateCluster or Destination.Cluster is expected:
+
+	func TestMyEventSource(t *testing.T) {
+		cluster, err := streamstest.NewCluster()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cluster.Close()
+
+		es, err := streams.NewEventSource(streams.EventSourceConfig{
+			GroupId:       "myGroup",
+			Topic:         "myTopic",
+			NumPartitions: 10,
+			SourceCluster: cluster,
+		}, myStoreFactory, myDefaultHandler)
+		...
+	}
+
+The broker listens on the loopback interface only and holds all data in memory. All data is lost once Close() is called.
+streamstest is a separate module from streams, so the Go and franz-go versions required by kfake only apply to the tests which use it.
+For the same reason, it does not import the streams package. The streams module runs its own tests against the streamstest command,
+which serves a Cluster until its standard input is closed.
+*/
+package streamstest
+
+import (
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Cluster is an in-memory Kafka cluster. It implements [github.com/aws/go-kafka-event-source/streams.Cluster].
+type Cluster struct {
+	fake  *kfake.Cluster
+	addrs []string
+}
+
+// NewCluster starts a new, single broker, in-memory Kafka cluster. `opts` are passed directly to the underlying
+// [kfake.Cluster] and may be used to override the defaults (kfake.NumBrokers(3) for example).
+func NewCluster(opts ...kfake.Opt) (*Cluster, error) {
+	kfakeOpts := append([]kfake.Opt{kfake.NumBrokers(1)}, opts...)
+	fake, err := kfake.NewCluster(kfakeOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Cluster{
+		fake:  fake,
+		addrs: fake.ListenAddrs(),
+	}, nil
+}
+
+// Returns []kgo.Opt{kgo.SeedBrokers(...)} for the in-memory brokers.
+func (c *Cluster) Config() ([]kgo.Opt, error) {
+	return []kgo.Opt{kgo.SeedBrokers(c.addrs...)}, nil
+}
+
+// The host:port addresses the in-memory brokers are listening on.
+func (c *Cluster) Addrs() []string {
+	return c.addrs
+}
+
+// Immediately compacts all topics with a cleanup.policy of `compact`, such as StateStore and commit log topics.
+// Useful for verifying that a StateStore can be rebuilt from a compacted change log.
+func (c *Cluster) Compact() {
+	c.fake.Compact()
+}
+
+// Provides access to the underlying [kfake.Cluster] for fault injection (kfake.Cluster.ControlKey for example).
+func (c *Cluster) Fake() *kfake.Cluster {
+	return c.fake
+}
+
+// Shuts down the brokers. Any clients still connected will receive network errors.
+func (c *Cluster) Close() {
+	c.fake.Close()
+}
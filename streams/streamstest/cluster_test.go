@@ -0,0 +1,67 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streamstest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestClusterTransactions(t *testing.T) {
+	const topic = "transactions"
+	cluster, err := NewCluster(kfake.SeedTopics(1, topic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cluster.Close()
+	opts, _ := cluster.Config()
+
+	producer, err := kgo.NewClient(append(opts, kgo.TransactionalID("streamstest"), kgo.DefaultProduceTopic(topic))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer producer.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for _, value := range []string{"aborted", "committed"} {
+		if err = producer.BeginTransaction(); err != nil {
+			t.Fatal(err)
+		}
+		if err = producer.ProduceSync(ctx, kgo.StringRecord(value)).FirstErr(); err != nil {
+			t.Fatal(err)
+		}
+		if err = producer.EndTransaction(ctx, kgo.TransactionEndTry(value == "committed")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	consumer, err := kgo.NewClient(append(opts, kgo.ConsumeTopics(topic), kgo.FetchIsolationLevel(kgo.ReadCommitted()))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer consumer.Close()
+	fetches := consumer.PollFetches(ctx)
+	if err = fetches.Err(); err != nil {
+		t.Fatal(err)
+	}
+	records := fetches.Records()
+	if len(records) != 1 || string(records[0].Value) != "committed" {
+		t.Errorf("aborted record consumed: %+v", records)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Command streamstest serves an in-memory [github.com/aws/go-kafka-event-source/streams/streamstest.Cluster] until its standard input is closed.
+Once the brokers are listening, their addresses are written to standard output, comma separated, on a single line.
+
+	go run github.com/aws/go-kafka-event-source/streams/streamstest/cmd/streamstest [-brokers 1]
+
+This allows tests which can not import streamstest, such as those of the streams module itself, to run against the in-memory broker
+in a child process.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aws/go-kafka-event-source/streams/streamstest"
+	"github.com/twmb/franz-go/pkg/kfake"
+)
+
+func main() {
+	brokers := flag.Int("brokers", 1, "the number of in-memory brokers")
+	flag.Parse()
+	cluster, err := streamstest.NewCluster(kfake.NumBrokers(*brokers))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer cluster.Close()
+	fmt.Println(strings.Join(cluster.Addrs(), ","))
+	// blocks until the parent process closes stdin, or exits
+	io.Copy(io.Discard, os.Stdin)
+}
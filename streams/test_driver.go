@@ -0,0 +1,360 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams/sak"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+var ErrAsyncTimeout = errors.New("timed out waiting for async jobs to complete")
+
+type testDriverInterjection[T any] struct {
+	interjection *interjection[T]
+	next         time.Time
+}
+
+/*
+TestDriver runs records through the processors of an EventSource without a Kafka cluster. Records are dispatched exactly as they
+would be by a running EventSource (registered event types first, then the default processor), but everything happens synchronously
+on the calling go-routine and time is controlled by a fake clock.
+Records produced via EventContext.Forward or EventContext.RecordChange are captured per topic rather than produced.
+
+	func TestMyProcessor(t *testing.T) {
+		driver := streams.NewTestDriver(streams.EventSourceConfig{
+			GroupId: "myGroup",
+			Topic: "myTopic",
+			NumPartitions: 10,
+		}, newMyStore, myDefaultProcessor)
+		defer driver.Close()
+
+		registerMyEventTypes(driver.EventSource()) // invokes streams.RegisterEventType and EventSource.ScheduleInterjection
+		driver.Pipe(0, streams.NewRecord().WithRecordType("myType").WithKeyString("key").WithValue(myValue))
+		driver.AdvanceTime(time.Minute) // fires any interjections due in the next minute for partition 0
+
+		for _, record := range driver.Output("myOutputTopic") {
+			...
+		}
+		for _, entry := range driver.ChangeLog(0) {
+			...
+		}
+	}
+
+A TestDriver is not thread-safe, with the exception of async completion (EventContext.AsyncJobComplete), which may be invoked from any go-routine.
+Async jobs are only finalized on the calling go-routine, during Pipe(), AdvanceTime(), Interject() or AwaitAsync().
+*/
+type TestDriver[T StateStore] struct {
+	eventSource   *EventSource[T]
+	changeLog     *partitionedChangeLog[T]
+	now           time.Time
+	offsets       map[TopicPartition]int64
+	interjections map[int32][]*testDriverInterjection[T]
+	asyncJobs     chan AsyncJob[T]
+	pending       map[*EventContext[T]]struct{}
+	output        map[string][]IncomingRecord
+	outputMux     sync.Mutex
+	failure       error
+}
+
+// Creates a TestDriver. `config` only needs a GroupId and a Topic, no connection is made to SourceCluster or StateCluster.
+// The returned TestDriver starts its fake clock at time.Now().
+func NewTestDriver[T StateStore](config EventSourceConfig, stateStoreFactory StateStoreFactory[T], defaultProcessor EventProcessor[T, IncomingRecord]) *TestDriver[T] {
+	source := newSource(config)
+	es := &EventSource[T]{
+		defaultProcessor:  defaultProcessor,
+		stateStoreFactory: stateStoreFactory,
+		source:            source,
+		runStatus:         sak.NewRunStatus(context.Background()),
+		done:              make(chan struct{}, 1),
+		tracer:            source.config.Tracer,
+	}
+	return &TestDriver[T]{
+		eventSource:   es,
+		changeLog:     newPartitionedChangeLog(es.createChangeLogReceiver, source.StateStoreTopicName()),
+		now:           time.Now(),
+		offsets:       make(map[TopicPartition]int64),
+		interjections: make(map[int32][]*testDriverInterjection[T]),
+		asyncJobs:     make(chan AsyncJob[T], 1024),
+		pending:       make(map[*EventContext[T]]struct{}),
+		output:        make(map[string][]IncomingRecord),
+	}
+}
+
+// The EventSource backing this TestDriver. Use it with RegisterEventType, RegisterDefaultHandler, ScheduleInterjection and
+// async processors such as CreateAsyncJobScheduler. It is not connected to Kafka, so ConsumeEvents() must not be called.
+func (td *TestDriver[T]) EventSource() *EventSource[T] {
+	return td.eventSource
+}
+
+// The current time of the fake clock.
+func (td *TestDriver[T]) Now() time.Time {
+	return td.now
+}
+
+// Returns the StateStore for `partition`, creating it with the StateStoreFactory if necessary.
+func (td *TestDriver[T]) Store(partition int32) T {
+	return td.assign(partition).store
+}
+
+func (td *TestDriver[T]) assign(partition int32) changeLogPartition[T] {
+	if _, ok := td.interjections[partition]; !ok {
+		ijs := make([]*testDriverInterjection[T], 0, len(td.eventSource.interjections))
+		for _, ij := range sak.ToPtrSlice(td.eventSource.interjections) {
+			ij.topicPartition = ntp(partition, td.eventSource.source.Topic())
+			ijs = append(ijs, &testDriverInterjection[T]{
+				interjection: ij,
+				next:         td.now.Add(ij.every),
+			})
+		}
+		td.interjections[partition] = ijs
+	}
+	return td.changeLog.assign(partition)
+}
+
+/*
+Pipe sends `records` to `partition` of the source topic, invoking the same processors that a running EventSource would.
+The topic, partition and offset of each record are assigned by the TestDriver. If a record has no timestamp,
+the current time of the fake clock is used. Returns the ExecutionState of the last record processed.
+Records returning Incomplete remain pending until their async jobs are finalized. See [TestDriver.AwaitAsync].
+
+As with EventContext.Forward, records are returned to the record pool once processed and should not be referenced afterwards.
+*/
+func (td *TestDriver[T]) Pipe(partition int32, records ...*Record) ExecutionState {
+	return td.PipeTopic(td.eventSource.source.Topic(), partition, records...)
+}
+
+// PipeTopic behaves like Pipe, but sends `records` to `partition` of `topic`, which should be one of EventSourceConfig.Topics.
+// As with a running EventSource, the same partition of every topic shares a StateStore.
+func (td *TestDriver[T]) PipeTopic(topic string, partition int32, records ...*Record) ExecutionState {
+	state := Complete
+	changeLog := td.assign(partition)
+	tp := ntp(partition, topic)
+	for _, record := range records {
+		kRecord := td.toIncomingKafkaRecord(record)
+		record.Release()
+		kRecord.Topic = topic
+		kRecord.Partition = partition
+		kRecord.Offset = td.offsets[tp]
+		if kRecord.Timestamp.IsZero() {
+			kRecord.Timestamp = td.now
+		}
+		td.offsets[tp]++
+
+		ec := td.newEventContext(changeLog, tp)
+		ec.input = newIncomingRecord(kRecord)
+		state = td.eventSource.handleEvent(ec, ec.input)
+		td.track(ec, state)
+		td.finalizeAsyncJobs()
+	}
+	return state
+}
+
+// AdvanceTime moves the fake clock forward by `d`, firing every interjection registered via EventSource.ScheduleInterjection that comes due,
+// in chronological order, for every partition that has received records. Jitter is ignored so that interjections fire deterministically.
+func (td *TestDriver[T]) AdvanceTime(d time.Duration) {
+	target := td.now.Add(d)
+	for {
+		var partition int32
+		var due *testDriverInterjection[T]
+		for p, ijs := range td.interjections {
+			for _, ij := range ijs {
+				if ij.interjection.every <= 0 || ij.next.After(target) {
+					continue
+				}
+				if due == nil || ij.next.Before(due.next) || (ij.next.Equal(due.next) && p < partition) {
+					partition, due = p, ij
+				}
+			}
+		}
+		if due == nil {
+			break
+		}
+		td.now = due.next
+		due.next = due.next.Add(due.interjection.every)
+		td.interject(partition, due.interjection)
+	}
+	td.now = target
+	td.finalizeAsyncJobs()
+}
+
+// Executes `cmd` in the context of the given partition, returning the resulting ExecutionState.
+func (td *TestDriver[T]) Interject(partition int32, cmd Interjector[T]) ExecutionState {
+	return td.interject(partition, &interjection[T]{
+		isOneOff:       true,
+		topicPartition: ntp(partition, td.eventSource.source.Topic()),
+		interjector:    cmd,
+	})
+}
+
+func (td *TestDriver[T]) interject(partition int32, ij *interjection[T]) ExecutionState {
+	ec := td.newEventContext(td.assign(partition), ij.topicPartition)
+	ec.interjection = ij
+	state := td.eventSource.invokeInterjector(ec, ij.interjector, td.now)
+	td.track(ec, state)
+	td.finalizeAsyncJobs()
+	return state
+}
+
+// The number of events or interjections which returned Incomplete and have not yet been finalized.
+func (td *TestDriver[T]) Pending() int {
+	return len(td.pending)
+}
+
+// AwaitAsync finalizes async jobs (see EventContext.AsyncJobComplete) as they are completed, blocking until no events are pending.
+// Returns [ErrAsyncTimeout] if events are still pending after `timeout`.
+func (td *TestDriver[T]) AwaitAsync(timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for len(td.pending) > 0 {
+		select {
+		case job := <-td.asyncJobs:
+			td.finalize(job)
+		case <-timer.C:
+			return ErrAsyncTimeout
+		}
+	}
+	return nil
+}
+
+// Returns all records produced to `topic` via EventContext.Forward or EventContext.RecordChange, in the order they were produced.
+func (td *TestDriver[T]) Output(topic string) []IncomingRecord {
+	td.outputMux.Lock()
+	defer td.outputMux.Unlock()
+	return append([]IncomingRecord(nil), td.output[topic]...)
+}
+
+// Returns the ChangeLogEntries recorded for `partition` via EventContext.RecordChange, in the order they were recorded.
+func (td *TestDriver[T]) ChangeLog(partition int32) []IncomingRecord {
+	entries := []IncomingRecord{}
+	for _, record := range td.Output(td.eventSource.source.StateStoreTopicName()) {
+		if record.TopicPartition().Partition == partition {
+			entries = append(entries, record)
+		}
+	}
+	return entries
+}
+
+// Clears all captured output.
+func (td *TestDriver[T]) ClearOutput() {
+	td.outputMux.Lock()
+	defer td.outputMux.Unlock()
+	td.output = make(map[string][]IncomingRecord)
+}
+
+// Halts any async processors created with the EventSource and revokes all StateStores.
+func (td *TestDriver[T]) Close() {
+	td.eventSource.runStatus.Halt()
+	for p := range td.interjections {
+		td.changeLog.revoke(p)
+	}
+}
+
+// Needed to fulfill the EventContextProducer interface. Should NOT be invoked directly.
+func (td *TestDriver[T]) ProduceRecord(ec *EventContext[T], record *Record, cb func(*Record, error)) {
+	kRecord := td.toIncomingKafkaRecord(record)
+	if kRecord.Timestamp.IsZero() {
+		kRecord.Timestamp = td.now
+	}
+	td.outputMux.Lock()
+	td.output[kRecord.Topic] = append(td.output[kRecord.Topic], newIncomingRecord(kRecord))
+	td.outputMux.Unlock()
+	if cb != nil {
+		cb(record, nil)
+	}
+	record.Release()
+}
+
+// Needed to fulfill the AsyncCompleter interface. Should NOT be invoked directly.
+func (td *TestDriver[T]) AsyncComplete(job AsyncJob[T]) {
+	td.asyncJobs <- job
+}
+
+func (td *TestDriver[T]) newEventContext(changeLog changeLogPartition[T], tp TopicPartition) *EventContext[T] {
+	return &EventContext[T]{
+		ctx:            td.eventSource.runStatus.Ctx(),
+		producer:       td,
+		asyncCompleter: td,
+		changeLog:      changeLog.changeLogData(),
+		topicPartition: tp,
+		done:           make(chan struct{}),
+	}
+}
+
+// Returns the first error for which the ProcessingErrorHandler returned FailConsumer or FatallyExit, or nil.
+// A running EventSource would have stopped consuming, the TestDriver leaves the failed event pending and continues.
+func (td *TestDriver[T]) Failure() error {
+	return td.failure
+}
+
+// passes a Fatal state to the ProcessingErrorHandler, returning Complete if the failed event should be skipped
+func (td *TestDriver[T]) settle(ec *EventContext[T], state ExecutionState) ExecutionState {
+	if state != Fatal {
+		return state
+	}
+	response, err := td.eventSource.source.processingErrorResponse(ec)
+	if response == Continue {
+		return Complete
+	}
+	if td.failure == nil {
+		td.failure = err
+	}
+	return state
+}
+
+func (td *TestDriver[T]) track(ec *EventContext[T], state ExecutionState) {
+	if state = td.settle(ec, state); state == Complete || state == Retried {
+		ec.complete()
+	} else {
+		td.pending[ec] = struct{}{}
+	}
+}
+
+func (td *TestDriver[T]) finalize(job AsyncJob[T]) {
+	if state := td.settle(job.ctx, job.Finalize()); state == Complete || state == Retried {
+		if _, ok := td.pending[job.ctx]; ok {
+			delete(td.pending, job.ctx)
+			job.ctx.complete()
+		}
+	}
+}
+
+func (td *TestDriver[T]) finalizeAsyncJobs() {
+	for {
+		select {
+		case job := <-td.asyncJobs:
+			td.finalize(job)
+		default:
+			return
+		}
+	}
+}
+
+// creates a stand alone copy of `record` as it would appear to a consumer
+func (td *TestDriver[T]) toIncomingKafkaRecord(record *Record) *kgo.Record {
+	kRecord := record.kRecord
+	kRecord.Key = append([]byte(nil), record.keyBuffer.Bytes()...)
+	if record.valueBuffer.Len() > 0 {
+		kRecord.Value = append([]byte(nil), record.valueBuffer.Bytes()...)
+	}
+	kRecord.Headers = append([]kgo.RecordHeader(nil), record.kRecord.Headers...)
+	addRecordTypeHeader(record.recordType, &kRecord)
+	addRecordVersionHeader(record.recordVersion, &kRecord)
+	return &kRecord
+}
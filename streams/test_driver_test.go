@@ -0,0 +1,128 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestDriverForTest() *TestDriver[intStore] {
+	return NewTestDriver(EventSourceConfig{
+		GroupId:       "driver_group",
+		Topic:         "driver_topic",
+		NumPartitions: 4,
+	}, NewIntStore, defaultTestHandler)
+}
+
+func intRecord(recordType string, k, v int) *Record {
+	r := NewRecord().WithRecordType(recordType)
+	IntCodec.Encode(r.KeyWriter(), k)
+	IntCodec.Encode(r.ValueWriter(), v)
+	return r
+}
+
+func TestTestDriverDispatch(t *testing.T) {
+	driver := newTestDriverForTest()
+	defer driver.Close()
+
+	RegisterEventType(driver.EventSource(), decodeIntStoreItem, func(ec *EventContext[intStore], item intStoreItem) ExecutionState {
+		ec.Store().add(item)
+		ec.Forward(intRecord("forwarded", item.Key, item.Value).WithTopic("output"))
+		return Complete
+	}, "forward")
+
+	driver.Pipe(1,
+		intRecord("forward", 1, 10),
+		intRecord("", 2, 20))
+
+	if l := driver.Store(1).tree.Len(); l != 2 {
+		t.Errorf("incorrect store size. actual: %d, expected: %d", l, 2)
+	}
+	output := driver.Output("output")
+	if len(output) != 1 {
+		t.Fatalf("incorrect output count. actual: %d, expected: %d", len(output), 1)
+	}
+	if output[0].RecordType() != "forwarded" {
+		t.Errorf("incorrect record type. actual: %s, expected: %s", output[0].RecordType(), "forwarded")
+	}
+	if key, _ := IntCodec.Decode(output[0].Key()); key != 1 {
+		t.Errorf("incorrect key. actual: %d, expected: %d", key, 1)
+	}
+	changeLog := driver.ChangeLog(1)
+	if len(changeLog) != 1 || changeLog[0].RecordType() != "defaultHandler" {
+		t.Errorf("incorrect change log: %+v", changeLog)
+	}
+	if len(driver.ChangeLog(0)) != 0 {
+		t.Errorf("unexpected change log entries for partition 0")
+	}
+}
+
+func TestTestDriverInterjections(t *testing.T) {
+	driver := newTestDriverForTest()
+	defer driver.Close()
+
+	start := driver.Now()
+	var fired []time.Time
+	driver.EventSource().ScheduleInterjection(func(ec *EventContext[intStore], now time.Time) ExecutionState {
+		if !ec.IsInterjection() {
+			t.Errorf("expected interjection context")
+		}
+		fired = append(fired, now)
+		return Complete
+	}, time.Minute, time.Second)
+
+	driver.AdvanceTime(time.Hour)
+	if len(fired) != 0 {
+		t.Errorf("interjection fired for unassigned partition")
+	}
+
+	driver.Pipe(0, intRecord("", 1, 1))
+	driver.AdvanceTime(3*time.Minute + 30*time.Second)
+	if len(fired) != 3 {
+		t.Fatalf("incorrect interjection count. actual: %d, expected: %d", len(fired), 3)
+	}
+	for i, now := range fired {
+		if expected := start.Add(time.Hour + time.Duration(i+1)*time.Minute); !now.Equal(expected) {
+			t.Errorf("incorrect interjection time. actual: %v, expected: %v", now, expected)
+		}
+	}
+}
+
+func TestTestDriverAsync(t *testing.T) {
+	driver := newTestDriverForTest()
+	defer driver.Close()
+
+	RegisterEventType(driver.EventSource(), decodeIntStoreItem, func(ec *EventContext[intStore], item intStoreItem) ExecutionState {
+		go ec.AsyncJobComplete(func() ExecutionState {
+			ec.Store().add(item)
+			return Complete
+		})
+		return Incomplete
+	}, "async")
+
+	if state := driver.Pipe(2, intRecord("async", 1, 1)); state != Incomplete {
+		t.Errorf("incorrect ExecutionState. actual: %v, expected: %v", state, Incomplete)
+	}
+	if err := driver.AwaitAsync(defaultTestTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if driver.Pending() != 0 {
+		t.Errorf("incorrect pending count. actual: %d, expected: %d", driver.Pending(), 0)
+	}
+	if l := driver.Store(2).tree.Len(); l != 1 {
+		t.Errorf("incorrect store size. actual: %d, expected: %d", l, 1)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"reflect"
 	"strconv"
 	"time"
 
@@ -195,7 +196,7 @@ func isNetworkError(err error) bool {
 		log.Warnf("network error for operation: %s, error: %v", opError.Op, opError)
 		return true
 	} else if err != nil {
-		log.Errorf("non network error for operation: %s, error: %v", opError.Op, opError)
+		log.Errorf("non network error: %v", err)
 	}
 	return false
 }
@@ -208,13 +209,20 @@ func CreateSource(sourceConfig EventSourceConfig) (resolved *Source, err error)
 	if len(sourceConfig.GroupId) == 0 {
 		return nil, fmt.Errorf("GroupId not provided")
 	}
-	if len(sourceConfig.Topic) == 0 {
+	if len(sourceConfig.Topic) == 0 && len(sourceConfig.Topics) == 0 {
 		return nil, fmt.Errorf("Topic not provided")
 	}
 	if sourceConfig.SourceCluster == nil {
 		return nil, fmt.Errorf("SourceCluster not provided")
 	}
 	source := newSource(sourceConfig)
+	if source.isCoPartitioned() && !onlyIncrementalBalanceStrategy(source.config.BalanceStrategies) {
+		return nil, fmt.Errorf("consuming multiple topics, or retry topics, requires the IncrementalBalanceStrategy")
+	}
+	// retry records are produced by the transactional producer, which is connected to StateCluster
+	if len(source.config.RetryDelays) > 0 && !reflect.DeepEqual(source.stateCluster(), source.config.SourceCluster) {
+		return nil, fmt.Errorf("RetryDelays requires StateCluster to be unset, or the same cluster as SourceCluster")
+	}
 	for retryCount := 0; retryCount < 15; retryCount++ {
 		resolved, err = createSource(source)
 		if isNetworkError(err) {
@@ -226,11 +234,21 @@ func CreateSource(sourceConfig EventSourceConfig) (resolved *Source, err error)
 	return
 }
 
+// Only the IncrementalRebalancer assigns the same partition of every topic to the same consumer.
+func onlyIncrementalBalanceStrategy(strategies []BalanceStrategy) bool {
+	for _, strategy := range strategies {
+		if strategy != IncrementalBalanceStrategy {
+			return false
+		}
+	}
+	return true
+}
+
 func resolveOrCreateTopics(source *Source, sourceTopicAdminClient, eosAdminClient *kadm.Client) (*Source, error) {
 	topic := source.Topic()
 	commitLogName := source.CommitLogTopicNameForGroupId()
 	changLogName := source.StateStoreTopicName()
-	res, err := sourceTopicAdminClient.ListTopicsWithInternal(context.Background(), topic)
+	res, err := sourceTopicAdminClient.ListTopicsWithInternal(context.Background(), source.consumedTopics()...)
 	if err != nil {
 		return nil, err
 	}
@@ -244,11 +262,35 @@ func resolveOrCreateTopics(source *Source, sourceTopicAdminClient, eosAdminClien
 			return nil, err
 		}
 	}
+	// additional topics, and retry topics, must be co-partitioned with the primary topic
+	for _, t := range source.consumedTopics()[1:] {
+		if val, ok := res[t]; ok && val.Err == nil {
+			partitionCount := len(val.Partitions.Numbers())
+			if partitionCount != source.config.NumPartitions {
+				return nil, fmt.Errorf("topic %s partitition count (%d) does not match source topic partition count (%d)",
+					t, partitionCount, source.config.NumPartitions)
+			}
+		} else {
+			err = createTopic(sourceTopicAdminClient, source.NumPartitions(),
+				replicationFactorConfig(source), minInSyncConfig(source), DeleteCleanupPolicy, 1, t)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
 
 	topics := []string{commitLogName}
 	if len(changLogName) > 0 {
 		topics = append(topics, changLogName)
 	}
+	snapshotName := source.SnapshotTopicName()
+	if source.snapshotsEnabled() {
+		topics = append(topics, snapshotName)
+	}
+	deadLetterName := source.config.DeadLetterDestination.DefaultTopic
+	if source.deadLettersEnabled() {
+		topics = append(topics, deadLetterName)
+	}
 	res, err = eosAdminClient.ListTopicsWithInternal(context.Background(), topics...)
 	if err != nil {
 		return nil, err
@@ -278,11 +320,33 @@ func resolveOrCreateTopics(source *Source, sourceTopicAdminClient, eosAdminClien
 			}
 		}
 	}
+
+	if source.snapshotsEnabled() {
+		if val, ok := res[snapshotName]; !ok || val.Err != nil {
+			err = createTopic(eosAdminClient, source.NumPartitions(),
+				replicationFactorConfig(source), minInSyncConfig(source), CompactCleanupPolicy, 0.5, snapshotName)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if source.deadLettersEnabled() {
+		if val, ok := res[deadLetterName]; !ok || val.Err != nil {
+			err = createTopic(eosAdminClient, deadLetterPartitionsConfig(source),
+				replicationFactorConfig(source), minInSyncConfig(source), DeleteCleanupPolicy, 1, deadLetterName)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
 	return source, nil
 }
 
 // Deletes all topics associated with a Source. Provided for local testing purpoose only.
 // Do not call this in deployed applications unless your topics are transient in nature.
+// Only EventSourceConfig.Topic is deleted from the source topics, as any additional co-partitioned topics may be consumed by other applications.
+// For the same reason, the DeadLetterDestination topic is never deleted, even if it was created by CreateSource.
 func DeleteSource(sourceConfig EventSourceConfig) error {
 	source := newSource(sourceConfig)
 	sourceTopicClient, err := NewClient(source.config.SourceCluster)
@@ -296,10 +360,11 @@ func DeleteSource(sourceConfig EventSourceConfig) error {
 
 	sourceTopicAdminClient := kadm.NewClient(sourceTopicClient)
 	eosAdminClient := kadm.NewClient(eosClient)
-	sourceTopicAdminClient.DeleteTopics(context.Background(), source.config.Topic)
+	sourceTopicAdminClient.DeleteTopics(context.Background(), append([]string{source.Topic()}, source.RetryTopicNames()...)...)
 	eosAdminClient.DeleteTopics(context.Background(),
 		source.CommitLogTopicNameForGroupId(),
-		source.StateStoreTopicName())
+		source.StateStoreTopicName(),
+		source.SnapshotTopicName())
 	return nil
 }
 
@@ -0,0 +1,165 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"context"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// The W3C trace context header keys.
+const (
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+)
+
+// Provides read/write access to trace context stored in record headers.
+// The method set is that of go.opentelemetry.io/otel/propagation.TextMapCarrier, so a TraceCarrier may be passed directly to an OpenTelemetry propagator.
+type TraceCarrier interface {
+	Get(key string) string
+	Set(key, value string)
+	Keys() []string
+}
+
+// Describes the span opened around an EventProcessor or Interjector invocation.
+type SpanInfo struct {
+	// "process <eventType>" for events and "interject" for Interjections.
+	Name           string
+	GroupId        string
+	EventType      string
+	TopicPartition TopicPartition
+	// -1 for Interjections
+	Offset int64
+}
+
+// A span started by a Tracer.
+type Span interface {
+	// Ends the span. `err` is non-nil if the invocation returned [Fatal].
+	End(err error)
+}
+
+/*
+A Tracer propagates distributed trace context through an EventSource. If EventSourceConfig.Tracer is set:
+
+  - the trace context of each IncomingRecord is extracted from it's headers
+  - a span is started around each EventProcessor and Interjector invocation, with the extracted context as it's parent
+  - the context of that span is available via [EventContext.Context], and is injected into the headers of every Record passed to
+    EventContext.Forward or EventContext.RecordChange
+
+GKES does not depend on any tracing library. See [github.com/aws/go-kafka-event-source/tracing/otel] for an OpenTelemetry implementation.
+*/
+type Tracer interface {
+	// Returns a copy of `ctx` containing the trace context held by `carrier`, if any.
+	Extract(ctx context.Context, carrier TraceCarrier) context.Context
+	// Writes the trace context of `ctx` to `carrier`.
+	Inject(ctx context.Context, carrier TraceCarrier)
+	// Starts a span described by `info`, as a child of any span in `ctx`.
+	Start(ctx context.Context, info SpanInfo) (context.Context, Span)
+}
+
+// A read-only TraceCarrier for an IncomingRecord.
+type incomingRecordCarrier struct {
+	record *IncomingRecord
+}
+
+func (c incomingRecordCarrier) Get(key string) string {
+	return string(c.record.HeaderValue(key))
+}
+
+func (c incomingRecordCarrier) Set(string, string) {}
+
+func (c incomingRecordCarrier) Keys() []string {
+	return headerKeys(c.record.kRecord.Headers)
+}
+
+// A TraceCarrier for an outgoing Record. Set replaces an existing header, so trace context copied from an input record is overwritten.
+type recordCarrier struct {
+	record *Record
+}
+
+func (c recordCarrier) Get(key string) string {
+	for _, header := range c.record.kRecord.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (c recordCarrier) Set(key, value string) {
+	for i, header := range c.record.kRecord.Headers {
+		if header.Key == key {
+			c.record.kRecord.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.record.WithHeader(key, []byte(value))
+}
+
+func (c recordCarrier) Keys() []string {
+	return headerKeys(c.record.kRecord.Headers)
+}
+
+func headerKeys(headers []kgo.RecordHeader) []string {
+	keys := make([]string, len(headers))
+	for i, header := range headers {
+		keys[i] = header.Key
+	}
+	return keys
+}
+
+// Starts a span for `ec`, extracting the parent trace context from the input record of non-interjection events.
+func (es *EventSource[T]) startSpan(ec *EventContext[T], eventType string) Span {
+	ctx := ec.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	info := SpanInfo{
+		Name:           "interject",
+		GroupId:        es.source.GroupId(),
+		TopicPartition: ec.TopicPartition(),
+		Offset:         ec.Offset(),
+	}
+	if !ec.IsInterjection() {
+		ctx = es.tracer.Extract(ctx, incomingRecordCarrier{&ec.input})
+		info.Name = "process " + eventType
+		info.EventType = eventType
+	}
+	ctx, span := es.tracer.Start(ctx, info)
+	ec.tracer = es.tracer
+	ec.traceCtx = ctx
+	return span
+}
+
+func endSpan[T any](ec *EventContext[T], span Span, state ExecutionState) {
+	var err error
+	if state == Fatal {
+		err = ec.failure()
+	}
+	span.End(err)
+}
+
+// Invokes `interjector` within a span, if a Tracer is configured.
+func (es *EventSource[T]) invokeInterjector(ec *EventContext[T], interjector Interjector[T], now time.Time) ExecutionState {
+	if es.tracer == nil {
+		return interjector(ec, now)
+	}
+	span := es.startSpan(ec, "")
+	state := interjector(ec, now)
+	endSpan(ec, span, state)
+	return state
+}
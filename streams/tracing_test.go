@@ -0,0 +1,152 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streams
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testSpanKey struct{}
+
+// an in-memory Tracer which propagates a simplified traceparent: "<traceId>-<spanId>"
+type testTracer struct {
+	nextId int
+	spans  []*testSpan
+}
+
+type testSpan struct {
+	info    SpanInfo
+	traceId string
+	spanId  string
+	parent  string
+	ended   bool
+	err     error
+}
+
+func (s *testSpan) End(err error) {
+	s.ended = true
+	s.err = err
+}
+
+func (tt *testTracer) Extract(ctx context.Context, carrier TraceCarrier) context.Context {
+	if traceId, spanId, ok := strings.Cut(carrier.Get(TraceParentHeader), "-"); ok {
+		return context.WithValue(ctx, testSpanKey{}, &testSpan{traceId: traceId, spanId: spanId})
+	}
+	return ctx
+}
+
+func (tt *testTracer) Inject(ctx context.Context, carrier TraceCarrier) {
+	if span, ok := ctx.Value(testSpanKey{}).(*testSpan); ok {
+		carrier.Set(TraceParentHeader, span.traceId+"-"+span.spanId)
+	}
+}
+
+func (tt *testTracer) Start(ctx context.Context, info SpanInfo) (context.Context, Span) {
+	tt.nextId++
+	span := &testSpan{info: info, traceId: fmt.Sprintf("trace%d", tt.nextId), spanId: fmt.Sprintf("span%d", tt.nextId)}
+	if parent, ok := ctx.Value(testSpanKey{}).(*testSpan); ok {
+		span.traceId = parent.traceId
+		span.parent = parent.spanId
+	}
+	tt.spans = append(tt.spans, span)
+	return context.WithValue(ctx, testSpanKey{}, span), span
+}
+
+func TestTracing(t *testing.T) {
+	tracer := &testTracer{}
+	driver := NewTestDriver(EventSourceConfig{
+		GroupId:       "driver_group",
+		Topic:         "driver_topic",
+		NumPartitions: 4,
+		Tracer:        tracer,
+	}, NewIntStore, defaultTestHandler)
+	defer driver.Close()
+	es := driver.EventSource()
+
+	RegisterEventType(es, decodeIntStoreItem, func(ec *EventContext[intStore], item intStoreItem) ExecutionState {
+		if ec.Context().Value(testSpanKey{}) == nil {
+			t.Errorf("span not available from EventContext.Context()")
+		}
+		if item.Value < 0 {
+			return ec.Fail(errBadItem)
+		}
+		ec.Forward(NewRecord().
+			WithTopic("output").
+			// an existing traceparent, copied from the input for example, is replaced
+			WithHeader(TraceParentHeader, []byte("stale-stale")).
+			WithValue([]byte("out")))
+		return Complete
+	}, "traced")
+
+	driver.Pipe(1, intRecord("traced", 1, 10).WithHeader(TraceParentHeader, []byte("upstream-parent")))
+	driver.Pipe(1, intRecord("traced", 2, -20))
+	driver.Interject(1, func(ec *EventContext[intStore], _ time.Time) ExecutionState {
+		ec.Forward(NewRecord().WithTopic("output").WithValue([]byte("interjected")))
+		return Complete
+	})
+
+	if len(tracer.spans) != 3 {
+		t.Fatalf("incorrect span count. actual: %d, expected: %d", len(tracer.spans), 3)
+	}
+	processed, failed, interjected := tracer.spans[0], tracer.spans[1], tracer.spans[2]
+	expected := SpanInfo{
+		Name:           "process traced",
+		GroupId:        "driver_group",
+		EventType:      "traced",
+		TopicPartition: ntp(1, "driver_topic"),
+		Offset:         0,
+	}
+	if processed.info != expected {
+		t.Errorf("incorrect span info. actual: %+v, expected: %+v", processed.info, expected)
+	}
+	if processed.traceId != "upstream" || processed.parent != "parent" {
+		t.Errorf("span is not a child of the incoming trace context: %+v", processed)
+	}
+	if failed.parent != "" || failed.err != errBadItem {
+		t.Errorf("incorrect failed span: %+v", failed)
+	}
+	if interjected.info.Name != "interject" || interjected.info.Offset != -1 || interjected.err != nil {
+		t.Errorf("incorrect interjection span: %+v", interjected.info)
+	}
+	for _, span := range tracer.spans {
+		if !span.ended {
+			t.Errorf("span not ended: %+v", span.info)
+		}
+	}
+
+	output := driver.Output("output")
+	if len(output) != 2 {
+		t.Fatalf("incorrect output count. actual: %d, expected: %d", len(output), 2)
+	}
+	for i, span := range []*testSpan{processed, interjected} {
+		expected := span.traceId + "-" + span.spanId
+		if traceParent := string(output[i].HeaderValue(TraceParentHeader)); traceParent != expected {
+			t.Errorf("incorrect traceparent. actual: %s, expected: %s", traceParent, expected)
+		}
+		count := 0
+		for _, header := range output[i].Headers() {
+			if header.Key == TraceParentHeader {
+				count++
+			}
+		}
+		if count != 1 {
+			t.Errorf("incorrect traceparent header count. actual: %d, expected: %d", count, 1)
+		}
+	}
+}
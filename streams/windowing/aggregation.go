@@ -0,0 +1,187 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windowing
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams"
+	"github.com/aws/go-kafka-event-source/streams/sak"
+)
+
+var ErrMergerRequired = errors.New("a Merger is required for session windows")
+var ErrStoreRequired = errors.New("AggregationConfig.Store is required")
+var ErrAggregatorRequired = errors.New("AggregationConfig.Aggregator is required")
+
+// Adds `record` to `aggregate`, the current value for `key` in a window. For a new window, `aggregate` is the zero value of V.
+type Aggregator[V any] func(key string, aggregate V, record streams.IncomingRecord) V
+
+// Combines the aggregates of two session windows for `key` which have been bridged by a new record.
+type Merger[V any] func(key string, a, b V) V
+
+// Invoked once a window has closed, and will receive no more records. `closed` contains the final aggregate for the window.
+// The handler may Forward the final result, the window is deleted from the WindowStore once the handler returns.
+type WindowCloseHandler[T any, V any] func(ec *streams.EventContext[T], closed WindowedValue[V])
+
+type AggregationConfig[T any, V any] struct {
+	// Defines how records are grouped into windows. See [Tumbling], [Hopping] and [Session].
+	Windows Windows
+	// Returns the WindowStore holding the aggregates from the StateStore of your EventSource.
+	Store func(T) *WindowStore[V]
+	// Returns the key a record is aggregated by. If nil, the record key is used.
+	Key func(streams.IncomingRecord) string
+	// Required. Updates the aggregate for each window a record belongs to.
+	Aggregator Aggregator[V]
+	// Required for session windows.
+	Merger Merger[V]
+	// Optional. If nil, closed windows are deleted silently.
+	OnClose WindowCloseHandler[T, V]
+}
+
+/*
+Aggregation maintains windowed aggregates, keyed by the record timestamp (IncomingRecord.Timestamp), in a [WindowStore].
+All changes to the WindowStore are recorded via EventContext.RecordChange, so window state is restored when a partition is reassigned.
+
+Windows close once stream time (the latest record timestamp observed by the partition) passes the end of the window plus the grace period.
+Records for a closed window are dropped. Since stream time only advances as records arrive, windows on an idle partition will not close
+unless [Aggregation.CloseWindows] is scheduled as an interjection.
+
+	aggregation := sak.Must(windowing.NewAggregation(windowing.AggregationConfig[*windowing.WindowStore[int], int]{
+		Windows: windowing.Tumbling(time.Minute).WithGrace(10 * time.Second),
+		Store: func(ws *windowing.WindowStore[int]) *windowing.WindowStore[int] { return ws },
+		Aggregator: func(key string, count int, _ streams.IncomingRecord) int {
+			return count + 1
+		},
+		OnClose: func(ec *streams.EventContext[*windowing.WindowStore[int]], closed windowing.WindowedValue[int]) {
+			ec.Forward(streams.NewRecord().
+				WithTopic("counts").
+				WithKeyString(closed.Key).
+				WithValue([]byte(strconv.Itoa(closed.Value))).
+				WithTimestamp(closed.Window.End))
+		},
+	}))
+	es := sak.Must(streams.NewEventSource(config, func(tp streams.TopicPartition) *windowing.WindowStore[int] {
+		return windowing.NewWindowStore(tp, streams.IntCodec)
+	}, aggregation.Process))
+*/
+type Aggregation[T streams.StateStore, V any] struct {
+	config AggregationConfig[T, V]
+}
+
+func NewAggregation[T streams.StateStore, V any](config AggregationConfig[T, V]) (*Aggregation[T, V], error) {
+	if err := config.Windows.validate(); err != nil {
+		return nil, err
+	}
+	if config.Store == nil {
+		return nil, ErrStoreRequired
+	}
+	if config.Aggregator == nil {
+		return nil, ErrAggregatorRequired
+	}
+	if config.Windows.kind == session && config.Merger == nil {
+		return nil, ErrMergerRequired
+	}
+	if config.Key == nil {
+		config.Key = func(record streams.IncomingRecord) string {
+			return string(record.Key())
+		}
+	}
+	return &Aggregation[T, V]{config: config}, nil
+}
+
+// Adds `record` to each open window which contains it's timestamp, then closes any windows which have ended.
+// Suitable for use as an EventProcessor[T, streams.IncomingRecord], either as the default processor or from within a registered event type.
+func (a *Aggregation[T, V]) Process(ec *streams.EventContext[T], record streams.IncomingRecord) streams.ExecutionState {
+	ws := a.config.Store(ec.Store())
+	ts := record.Timestamp().UnixMilli()
+	ws.advance(ts)
+	key := a.config.Key(record)
+	if a.config.Windows.kind == session {
+		a.aggregateSession(ec, ws, key, ts, record)
+	} else {
+		a.aggregate(ec, ws, key, ts, record)
+	}
+	a.closeWindows(ec, ws)
+	return streams.Complete
+}
+
+func (a *Aggregation[T, V]) aggregate(ec *streams.EventContext[T], ws *WindowStore[V], key string, ts int64, record streams.IncomingRecord) {
+	closedThrough := a.config.Windows.closedThrough(ws.streamTime)
+	for _, window := range a.config.Windows.windowsFor(ts) {
+		end := window.End.UnixMilli()
+		if end <= closedThrough {
+			// late record
+			continue
+		}
+		value, _ := ws.Get(key, window)
+		ec.RecordChange(sak.Must(ws.put(&windowEntry[V]{
+			key:   key,
+			start: window.Start.UnixMilli(),
+			end:   end,
+			value: a.config.Aggregator(key, value, record),
+		})))
+	}
+}
+
+func (a *Aggregation[T, V]) aggregateSession(ec *streams.EventContext[T], ws *WindowStore[V], key string, ts int64, record streams.IncomingRecord) {
+	gap := a.config.Windows.gap.Milliseconds()
+	merged := &windowEntry[V]{key: key, start: ts, end: ts}
+	var overlapping []*windowEntry[V]
+	// sessions for key which start before ts+gap, and end after ts-gap
+	// closed sessions are deleted, so there are few sessions per key to scan
+	ws.fetch(key, math.MinInt64, ts+gap, func(entry *windowEntry[V]) {
+		if entry.end >= ts-gap {
+			overlapping = append(overlapping, entry)
+			merged.start = sak.Min(merged.start, entry.start)
+			merged.end = sak.Max(merged.end, entry.end)
+		}
+	})
+	if merged.end <= a.config.Windows.closedThrough(ws.streamTime) {
+		// late record, the session it belonged to has been closed
+		return
+	}
+	for i, entry := range overlapping {
+		if i == 0 {
+			merged.value = entry.value
+		} else {
+			merged.value = a.config.Merger(key, merged.value, entry.value)
+		}
+		// the change log key includes the window end, so replaced sessions must be deleted explicitly
+		ec.RecordChange(ws.delete(entry))
+	}
+	merged.value = a.config.Aggregator(key, merged.value, record)
+	ec.RecordChange(sak.Must(ws.put(merged)))
+}
+
+func (a *Aggregation[T, V]) closeWindows(ec *streams.EventContext[T], ws *WindowStore[V]) {
+	for _, entry := range ws.endedBy(a.config.Windows.closedThrough(ws.streamTime)) {
+		if a.config.OnClose != nil {
+			a.config.OnClose(ec, entry.windowedValue())
+		}
+		ec.RecordChange(ws.delete(entry))
+	}
+}
+
+// CloseWindows advances stream time to `t` (if it is later), then closes any windows which have ended.
+// Matches the Interjector signature, so it may be scheduled via EventSource.ScheduleInterjection to close windows on idle partitions.
+// Note that records with a timestamp earlier than the resulting stream time minus the grace period will be dropped.
+func (a *Aggregation[T, V]) CloseWindows(ec *streams.EventContext[T], t time.Time) streams.ExecutionState {
+	ws := a.config.Store(ec.Store())
+	ws.advance(t.UnixMilli())
+	a.closeWindows(ec, ws)
+	return streams.Complete
+}
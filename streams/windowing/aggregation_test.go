@@ -0,0 +1,204 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windowing
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams"
+)
+
+type countStore = *WindowStore[int]
+
+func newCountStore(tp streams.TopicPartition) countStore {
+	return NewWindowStore[int](tp, streams.IntCodec)
+}
+
+var epoch = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func countAggregation(t *testing.T, windows Windows) (*Aggregation[countStore, int], *streams.TestDriver[countStore]) {
+	aggregation, err := NewAggregation(AggregationConfig[countStore, int]{
+		Windows: windows,
+		Store:   func(ws countStore) *WindowStore[int] { return ws },
+		Aggregator: func(_ string, count int, _ streams.IncomingRecord) int {
+			return count + 1
+		},
+		Merger: func(_ string, a, b int) int {
+			return a + b
+		},
+		OnClose: func(ec *streams.EventContext[countStore], closed WindowedValue[int]) {
+			ec.Forward(streams.NewRecord().
+				WithTopic("counts").
+				WithKeyString(closed.Key).
+				WithValue([]byte(strconv.Itoa(closed.Value))).
+				WithHeader("start", []byte(strconv.FormatInt(closed.Window.Start.Sub(epoch).Milliseconds(), 10))).
+				WithHeader("end", []byte(strconv.FormatInt(closed.Window.End.Sub(epoch).Milliseconds(), 10))).
+				WithTimestamp(closed.Window.End))
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return aggregation, streams.NewTestDriver(streams.EventSourceConfig{
+		GroupId:       "windowing_group",
+		Topic:         "windowing_topic",
+		NumPartitions: 1,
+	}, newCountStore, aggregation.Process)
+}
+
+func keyAt(key string, offset time.Duration) *streams.Record {
+	return streams.NewRecord().WithKeyString(key).WithTimestamp(epoch.Add(offset))
+}
+
+type closedWindow struct {
+	key        string
+	start, end time.Duration
+	count      int
+}
+
+func replayChangeLog(t *testing.T, driver *streams.TestDriver[countStore], store *WindowStore[int]) {
+	t.Helper()
+	for _, record := range driver.ChangeLog(0) {
+		if err := store.ReceiveChange(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// verifies the windows forwarded by OnClose, then clears all output
+func verifyClosed(t *testing.T, driver *streams.TestDriver[countStore], expected ...closedWindow) {
+	t.Helper()
+	output := driver.Output("counts")
+	if len(output) != len(expected) {
+		t.Fatalf("incorrect closed window count. actual: %d, expected: %d", len(output), len(expected))
+	}
+	for i, record := range output {
+		start, _ := strconv.Atoi(string(record.HeaderValue("start")))
+		end, _ := strconv.Atoi(string(record.HeaderValue("end")))
+		count, _ := strconv.Atoi(string(record.Value()))
+		actual := closedWindow{
+			key:   string(record.Key()),
+			start: time.Duration(start) * time.Millisecond,
+			end:   time.Duration(end) * time.Millisecond,
+			count: count,
+		}
+		if actual != expected[i] {
+			t.Errorf("incorrect closed window. actual: %+v, expected: %+v", actual, expected[i])
+		}
+	}
+	driver.ClearOutput()
+}
+
+func TestTumblingWindows(t *testing.T) {
+	_, driver := countAggregation(t, Tumbling(time.Minute).WithGrace(10*time.Second))
+	defer driver.Close()
+	// the change log should reproduce the store
+	restored := newCountStore(streams.TopicPartition{})
+
+	driver.Pipe(0,
+		keyAt("a", 0),
+		keyAt("b", 20*time.Second),
+		keyAt("a", 30*time.Second),
+		keyAt("a", 65*time.Second),
+		// within the grace period
+		keyAt("a", 50*time.Second))
+	replayChangeLog(t, driver, restored)
+	verifyClosed(t, driver)
+	if count, ok := driver.Store(0).Get("a", Window{Start: epoch}); !ok || count != 3 {
+		t.Errorf("incorrect count. actual: %d, expected: %d", count, 3)
+	}
+
+	driver.Pipe(0,
+		keyAt("b", 70*time.Second),
+		// late
+		keyAt("a", 10*time.Second))
+	replayChangeLog(t, driver, restored)
+	verifyClosed(t, driver,
+		closedWindow{"a", 0, time.Minute, 3},
+		closedWindow{"b", 0, time.Minute, 1})
+
+	store := driver.Store(0)
+	if restored.Len() != store.Len() || restored.Len() != 2 {
+		t.Errorf("incorrect restored window count. actual: %d, expected: %d", restored.Len(), store.Len())
+	}
+	if !restored.StreamTime().Equal(store.StreamTime()) {
+		t.Errorf("incorrect restored stream time. actual: %v, expected: %v", restored.StreamTime(), store.StreamTime())
+	}
+	for _, key := range []string{"a", "b"} {
+		if count, ok := restored.Get(key, Window{Start: epoch.Add(time.Minute)}); !ok || count != 1 {
+			t.Errorf("incorrect restored count for %s. actual: %d, expected: %d", key, count, 1)
+		}
+	}
+}
+
+func TestHoppingWindows(t *testing.T) {
+	windows := Hopping(10*time.Second, 5*time.Second)
+	actual := windows.windowsFor(epoch.Add(7 * time.Second).UnixMilli())
+	expected := []Window{
+		newWindow(epoch.UnixMilli(), epoch.Add(10*time.Second).UnixMilli()),
+		newWindow(epoch.Add(5*time.Second).UnixMilli(), epoch.Add(15*time.Second).UnixMilli()),
+	}
+	if len(actual) != len(expected) || actual[0] != expected[0] || actual[1] != expected[1] {
+		t.Fatalf("incorrect windows. actual: %v, expected: %v", actual, expected)
+	}
+
+	_, driver := countAggregation(t, windows)
+	defer driver.Close()
+	driver.Pipe(0,
+		keyAt("a", 2*time.Second),
+		keyAt("a", 7*time.Second),
+		keyAt("a", 12*time.Second),
+		keyAt("a", 20*time.Second))
+	verifyClosed(t, driver,
+		closedWindow{"a", -5 * time.Second, 5 * time.Second, 1},
+		closedWindow{"a", 0, 10 * time.Second, 2},
+		closedWindow{"a", 5 * time.Second, 15 * time.Second, 2},
+		closedWindow{"a", 10 * time.Second, 20 * time.Second, 1})
+}
+
+func TestSessionWindows(t *testing.T) {
+	if _, err := NewAggregation(AggregationConfig[countStore, int]{
+		Windows:    Session(time.Second),
+		Store:      func(ws countStore) *WindowStore[int] { return ws },
+		Aggregator: func(string, int, streams.IncomingRecord) int { return 0 },
+	}); err != ErrMergerRequired {
+		t.Errorf("incorrect error. actual: %v, expected: %v", err, ErrMergerRequired)
+	}
+
+	aggregation, driver := countAggregation(t, Session(5*time.Second).WithGrace(time.Minute))
+	defer driver.Close()
+	driver.Pipe(0,
+		keyAt("a", 0),
+		keyAt("a", 3*time.Second),
+		keyAt("a", 20*time.Second),
+		keyAt("a", 12*time.Second))
+	if l := driver.Store(0).Len(); l != 3 {
+		t.Errorf("incorrect session count. actual: %d, expected: %d", l, 3)
+	}
+	// bridges the sessions at 12s and 20s
+	driver.Pipe(0, keyAt("a", 16*time.Second))
+	if l := driver.Store(0).Len(); l != 2 {
+		t.Errorf("incorrect session count. actual: %d, expected: %d", l, 2)
+	}
+	verifyClosed(t, driver)
+
+	// the driver clock is well past our records, so all sessions should close
+	driver.Interject(0, aggregation.CloseWindows)
+	verifyClosed(t, driver,
+		closedWindow{"a", 0, 3 * time.Second, 2},
+		closedWindow{"a", 12 * time.Second, 20 * time.Second, 3})
+}
@@ -0,0 +1,233 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windowing
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams"
+	"github.com/aws/go-kafka-event-source/streams/sak"
+)
+
+var ErrJoinTopicsRequired = errors.New("JoinConfig.LeftTopic and JoinConfig.RightTopic are required and must differ")
+var ErrJoinStoreRequired = errors.New("JoinConfig.Store is required")
+var ErrJoinerRequired = errors.New("JoinConfig.Joiner is required")
+var ErrInvalidJoinWindow = errors.New("invalid join window, window must be greater than zero, grace may not be negative")
+
+type JoinType int
+
+const (
+	// The Joiner is invoked only for pairs of matching records.
+	InnerJoin JoinType = iota
+	// As InnerJoin, but records from the left topic which never matched a right record are passed to the Joiner, with a nil Right, once they expire.
+	LeftJoin
+	// As LeftJoin, but unmatched records from either topic are passed to the Joiner once they expire.
+	OuterJoin
+)
+
+// The input to a Joiner. For a LeftJoin or OuterJoin, either Left or Right is nil if the record was never matched.
+type Joined[L any, R any] struct {
+	Key   string
+	Left  *L
+	Right *R
+	// The later of the joined record timestamps
+	Timestamp time.Time
+}
+
+// Invoked for each joined pair of records, or for an unmatched record which has expired. The Joiner may Forward the result.
+type Joiner[T any, L any, R any] func(ec *streams.EventContext[T], joined Joined[L, R])
+
+type JoinConfig[T any, L any, R any] struct {
+	// Required. Records from LeftTopic are decoded as L, records from RightTopic as R. Both topics must be consumed by the EventSource (see EventSourceConfig.Topics).
+	LeftTopic  string
+	RightTopic string
+	// Records with matching keys are joined if their timestamps differ by no more than Window.
+	Window time.Duration
+	// How long to wait for out of order records. Records older than stream time minus Window and Grace are dropped.
+	Grace time.Duration
+	// Defaults to InnerJoin.
+	Type JoinType
+	// Returns the JoinStore buffering both sides of the join from the StateStore of your EventSource.
+	Store func(T) *JoinStore[L, R]
+	// Returns the key records are joined by. If nil, the record key is used.
+	Key func(streams.IncomingRecord) string
+	// Required.
+	Joiner Joiner[T, L, R]
+	// Invoked if a record can not be decoded by the JoinStore codecs. Defaults to streams.DefaultDeserializationErrorHandler.
+	DeserializationErrorHandler streams.DeserializationErrorHandler
+}
+
+/*
+Join is a windowed stream-stream join between two co-partitioned topics consumed by the same EventSource.
+Each side is buffered in a [JoinStore] until it can no longer be matched, and all changes to the JoinStore are recorded via EventContext.RecordChange,
+so buffered records are restored when a partition is reassigned. The Joiner is invoked when a record arrives for which the other side has a buffered record
+with the same key, within Window of it's timestamp. Records from any other topic are ignored.
+
+Buffered records expire once stream time (the latest record timestamp observed by the partition) passes their timestamp plus Window and Grace.
+Since stream time only advances as records arrive, unmatched records of a LeftJoin or OuterJoin on an idle partition will not be emitted
+unless [Join.CloseWindows] is scheduled as an interjection.
+
+	join := sak.Must(windowing.NewJoin(windowing.JoinConfig[*windowing.JoinStore[Order, Payment], Order, Payment]{
+		LeftTopic:  "orders",
+		RightTopic: "payments",
+		Window:     10 * time.Minute,
+		Type:       windowing.LeftJoin,
+		Store: func(js *windowing.JoinStore[Order, Payment]) *windowing.JoinStore[Order, Payment] { return js },
+		Joiner: func(ec *streams.EventContext[*windowing.JoinStore[Order, Payment]], joined windowing.Joined[Order, Payment]) {
+			if joined.Right == nil {
+				// the order was not paid within 10 minutes
+			}
+			...
+		},
+	}))
+	config.Topics = []string{"orders", "payments"}
+	es := sak.Must(streams.NewEventSource(config, windowing.NewJsonJoinStore[Order, Payment], join.Process))
+*/
+type Join[T streams.StateStore, L any, R any] struct {
+	config JoinConfig[T, L, R]
+}
+
+func NewJoin[T streams.StateStore, L any, R any](config JoinConfig[T, L, R]) (*Join[T, L, R], error) {
+	if len(config.LeftTopic) == 0 || len(config.RightTopic) == 0 || config.LeftTopic == config.RightTopic {
+		return nil, ErrJoinTopicsRequired
+	}
+	if config.Window.Milliseconds() <= 0 || config.Grace < 0 {
+		return nil, ErrInvalidJoinWindow
+	}
+	if config.Store == nil {
+		return nil, ErrJoinStoreRequired
+	}
+	if config.Joiner == nil {
+		return nil, ErrJoinerRequired
+	}
+	if config.Key == nil {
+		config.Key = func(record streams.IncomingRecord) string {
+			return string(record.Key())
+		}
+	}
+	if config.DeserializationErrorHandler == nil {
+		config.DeserializationErrorHandler = streams.DefaultDeserializationErrorHandler
+	}
+	return &Join[T, L, R]{config: config}, nil
+}
+
+// Joins `record` against the buffered records of the other side, buffers it, then expires any buffered records which can no longer be matched.
+// Suitable for use as an EventProcessor[T, streams.IncomingRecord], either as the default processor or from within a registered event type.
+func (j *Join[T, L, R]) Process(ec *streams.EventContext[T], record streams.IncomingRecord) streams.ExecutionState {
+	js := j.config.Store(ec.Store())
+	state := streams.Complete
+	switch record.TopicPartition().Topic {
+	case j.config.LeftTopic:
+		state = bufferAndJoin(j, ec, js, js.left, js.right, record, func(left *bufferedRecord[L], right *bufferedRecord[R]) {
+			j.emit(ec, left, right)
+		})
+	case j.config.RightTopic:
+		state = bufferAndJoin(j, ec, js, js.right, js.left, record, func(right *bufferedRecord[R], left *bufferedRecord[L]) {
+			j.emit(ec, left, right)
+		})
+	default:
+		return streams.Complete
+	}
+	j.expire(ec, js)
+	return state
+}
+
+// joins a record against the `other` side of the join, then buffers it on `this` side
+func bufferAndJoin[T streams.StateStore, L any, R any, A any, B any](j *Join[T, L, R], ec *streams.EventContext[T], js *JoinStore[L, R],
+	this *joinBuffer[A], other *joinBuffer[B], record streams.IncomingRecord, emit func(*bufferedRecord[A], *bufferedRecord[B])) streams.ExecutionState {
+	ts := record.Timestamp().UnixMilli()
+	js.advance(ts)
+	if ts < j.expiredBefore(js) {
+		// late record, anything it could have been joined with has expired
+		return streams.Complete
+	}
+	value, err := this.codec.Decode(record.Value())
+	if err != nil {
+		if j.config.DeserializationErrorHandler(ec, record.RecordType(), err) == streams.Continue {
+			return streams.Complete
+		}
+		return streams.Incomplete
+	}
+	br := &bufferedRecord[A]{
+		key:       j.config.Key(record),
+		timestamp: ts,
+		offset:    record.Offset(),
+		value:     value,
+	}
+	window := j.config.Window.Milliseconds()
+	other.fetch(br.key, ts-window, ts+window, func(match *bufferedRecord[B]) {
+		br.matched = true
+		if !match.matched {
+			// record the match so an unmatched record is not emitted after a partition reassignment
+			match.matched = true
+			ec.RecordChange(sak.Must(other.toChangeLogEntry(match, false, js.streamTime)))
+		}
+		emit(br, match)
+	})
+	ec.RecordChange(sak.Must(this.put(br, js.streamTime)))
+	return streams.Complete
+}
+
+func (j *Join[T, L, R]) emit(ec *streams.EventContext[T], left *bufferedRecord[L], right *bufferedRecord[R]) {
+	var joined Joined[L, R]
+	ts := int64(math.MinInt64)
+	// copy the values so the Joiner can not modify the buffered records
+	if left != nil {
+		value := left.value
+		joined.Key, joined.Left, ts = left.key, &value, left.timestamp
+	}
+	if right != nil {
+		value := right.value
+		joined.Key, joined.Right, ts = right.key, &value, sak.Max(ts, right.timestamp)
+	}
+	joined.Timestamp = time.UnixMilli(ts)
+	j.config.Joiner(ec, joined)
+}
+
+// buffered records with a timestamp earlier than this can no longer be matched
+func (j *Join[T, L, R]) expiredBefore(js *JoinStore[L, R]) int64 {
+	if js.streamTime == math.MinInt64 {
+		return math.MinInt64
+	}
+	return js.streamTime - j.config.Grace.Milliseconds() - j.config.Window.Milliseconds()
+}
+
+func (j *Join[T, L, R]) expire(ec *streams.EventContext[T], js *JoinStore[L, R]) {
+	expiredBefore := j.expiredBefore(js)
+	for _, left := range js.left.olderThan(expiredBefore) {
+		if !left.matched && j.config.Type != InnerJoin {
+			j.emit(ec, left, nil)
+		}
+		ec.RecordChange(js.left.delete(left, js.streamTime))
+	}
+	for _, right := range js.right.olderThan(expiredBefore) {
+		if !right.matched && j.config.Type == OuterJoin {
+			j.emit(ec, nil, right)
+		}
+		ec.RecordChange(js.right.delete(right, js.streamTime))
+	}
+}
+
+// CloseWindows advances stream time to `t` (if it is later), then expires any buffered records which can no longer be matched.
+// Matches the Interjector signature, so it may be scheduled via EventSource.ScheduleInterjection to emit unmatched records on idle partitions.
+// Note that records with a timestamp earlier than the resulting stream time minus Window and Grace will be dropped.
+func (j *Join[T, L, R]) CloseWindows(ec *streams.EventContext[T], t time.Time) streams.ExecutionState {
+	js := j.config.Store(ec.Store())
+	js.advance(t.UnixMilli())
+	j.expire(ec, js)
+	return streams.Complete
+}
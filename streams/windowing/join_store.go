@@ -0,0 +1,242 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windowing
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams"
+	"github.com/aws/go-kafka-event-source/streams/sak"
+	"github.com/google/btree"
+)
+
+// buffered records which have been joined at least once carry this header, so unmatched records can be emitted after a partition reassignment
+const matchedHeader = "gkes_join_matched"
+
+// change log keys are prefixed by the join side, the record timestamp and the record offset
+const joinKeyPrefixSize = 17
+
+const (
+	leftSide  byte = 'L'
+	rightSide byte = 'R'
+)
+
+type bufferedRecord[V any] struct {
+	key       string
+	timestamp int64
+	offset    int64
+	matched   bool
+	value     V
+}
+
+func bufferedLess[V any](a, b *bufferedRecord[V]) bool {
+	if a.key != b.key {
+		return a.key < b.key
+	}
+	if a.timestamp != b.timestamp {
+		return a.timestamp < b.timestamp
+	}
+	return a.offset < b.offset
+}
+
+func bufferedTimeLess[V any](a, b *bufferedRecord[V]) bool {
+	if a.timestamp != b.timestamp {
+		return a.timestamp < b.timestamp
+	}
+	return bufferedLess(a, b)
+}
+
+// holds the records for one side of a join
+type joinBuffer[V any] struct {
+	side    byte
+	records *btree.BTreeG[*bufferedRecord[V]]
+	byTime  *btree.BTreeG[*bufferedRecord[V]]
+	codec   streams.Codec[V]
+}
+
+func newJoinBuffer[V any](side byte, codec streams.Codec[V]) *joinBuffer[V] {
+	return &joinBuffer[V]{
+		side:    side,
+		records: btree.NewG(64, bufferedLess[V]),
+		byTime:  btree.NewG(64, bufferedTimeLess[V]),
+		codec:   codec,
+	}
+}
+
+func (jb *joinBuffer[V]) changeLogKey(br *bufferedRecord[V]) []byte {
+	key := make([]byte, joinKeyPrefixSize, joinKeyPrefixSize+len(br.key))
+	key[0] = jb.side
+	binary.BigEndian.PutUint64(key[1:], uint64(br.timestamp))
+	binary.BigEndian.PutUint64(key[9:], uint64(br.offset))
+	return append(key, br.key...)
+}
+
+// calls `f` for each record for `key` with a timestamp within [from, to], in ascending order
+func (jb *joinBuffer[V]) fetch(key string, from, to int64, f func(*bufferedRecord[V])) {
+	jb.records.AscendRange(&bufferedRecord[V]{key: key, timestamp: from, offset: math.MinInt64},
+		&bufferedRecord[V]{key: key, timestamp: to, offset: math.MaxInt64},
+		func(br *bufferedRecord[V]) bool {
+			f(br)
+			return true
+		})
+}
+
+func (jb *joinBuffer[V]) insert(br *bufferedRecord[V]) {
+	if old, ok := jb.records.ReplaceOrInsert(br); ok {
+		jb.byTime.Delete(old)
+	}
+	jb.byTime.ReplaceOrInsert(br)
+}
+
+func (jb *joinBuffer[V]) remove(br *bufferedRecord[V]) {
+	if old, ok := jb.records.Delete(br); ok {
+		jb.byTime.Delete(old)
+	}
+}
+
+// returns all records with a timestamp earlier than `ts`, in timestamp order
+func (jb *joinBuffer[V]) olderThan(ts int64) []*bufferedRecord[V] {
+	records := []*bufferedRecord[V]{}
+	jb.byTime.Ascend(func(br *bufferedRecord[V]) bool {
+		if br.timestamp >= ts {
+			return false
+		}
+		records = append(records, br)
+		return true
+	})
+	return records
+}
+
+func (jb *joinBuffer[V]) toChangeLogEntry(br *bufferedRecord[V], tombstone bool, streamTime int64) (streams.ChangeLogEntry, error) {
+	var cle streams.ChangeLogEntry
+	if tombstone {
+		cle = streams.NewChangeLogEntry()
+	} else {
+		var err error
+		if cle, err = streams.CreateChangeLogEntry(br.value, jb.codec); err != nil {
+			return cle, err
+		}
+		if br.matched {
+			cle = cle.WithHeader(matchedHeader, []byte{1})
+		}
+	}
+	return cle.WithKey(jb.changeLogKey(br)).
+		WithHeader(streamTimeHeader, []byte(strconv.FormatInt(streamTime, 10))), nil
+}
+
+func (jb *joinBuffer[V]) put(br *bufferedRecord[V], streamTime int64) (streams.ChangeLogEntry, error) {
+	jb.insert(br)
+	return jb.toChangeLogEntry(br, false, streamTime)
+}
+
+func (jb *joinBuffer[V]) delete(br *bufferedRecord[V], streamTime int64) streams.ChangeLogEntry {
+	jb.remove(br)
+	// an empty value can not fail to encode
+	cle, _ := jb.toChangeLogEntry(br, true, streamTime)
+	return cle
+}
+
+func (jb *joinBuffer[V]) receiveChange(record streams.IncomingRecord) error {
+	key := record.Key()
+	br := &bufferedRecord[V]{
+		key:       string(key[joinKeyPrefixSize:]),
+		timestamp: int64(binary.BigEndian.Uint64(key[1:])),
+		offset:    int64(binary.BigEndian.Uint64(key[9:])),
+		matched:   len(record.HeaderValue(matchedHeader)) > 0,
+	}
+	if len(record.Value()) == 0 {
+		jb.remove(br)
+		return nil
+	}
+	var err error
+	if br.value, err = jb.codec.Decode(record.Value()); err != nil {
+		return err
+	}
+	jb.insert(br)
+	return nil
+}
+
+/*
+JoinStore is a StateStore which buffers the records of both sides of a [Join], per key and timestamp, along with the stream time of the partition.
+Buffered records are kept until they can no longer be joined, at which point they are removed from the store.
+
+As with a [WindowStore], a JoinStore may be used directly as the StateStore for an EventSource, or embedded in another StateStore.
+In the latter case, ReceiveChange and Revoked must be delegated to the JoinStore and the StateStore should not record any other change log entries.
+*/
+type JoinStore[L any, R any] struct {
+	left           *joinBuffer[L]
+	right          *joinBuffer[R]
+	streamTime     int64
+	topicPartition streams.TopicPartition
+}
+
+func NewJsonJoinStore[L any, R any](tp streams.TopicPartition) *JoinStore[L, R] {
+	return NewJoinStore[L, R](tp, streams.JsonCodec[L]{}, streams.JsonCodec[R]{})
+}
+
+// Creates a JoinStore. `leftCodec` and `rightCodec` are used both to decode the values of incoming records and to record buffered values to the change log.
+func NewJoinStore[L any, R any](tp streams.TopicPartition, leftCodec streams.Codec[L], rightCodec streams.Codec[R]) *JoinStore[L, R] {
+	return &JoinStore[L, R]{
+		left:           newJoinBuffer(leftSide, leftCodec),
+		right:          newJoinBuffer(rightSide, rightCodec),
+		streamTime:     math.MinInt64,
+		topicPartition: tp,
+	}
+}
+
+// The latest record timestamp observed by this partition. Returns the zero time if no records have been observed.
+func (js *JoinStore[L, R]) StreamTime() time.Time {
+	if js.streamTime == math.MinInt64 {
+		return time.Time{}
+	}
+	return time.UnixMilli(js.streamTime)
+}
+
+// The number of buffered records for the left and right side of the join.
+func (js *JoinStore[L, R]) Len() (left, right int) {
+	return js.left.records.Len(), js.right.records.Len()
+}
+
+func (js *JoinStore[L, R]) advance(ts int64) {
+	js.streamTime = sak.Max(js.streamTime, ts)
+}
+
+func (js *JoinStore[L, R]) ReceiveChange(record streams.IncomingRecord) error {
+	key := record.Key()
+	if len(key) < joinKeyPrefixSize {
+		return fmt.Errorf("invalid join store key: %v", key)
+	}
+	if st, err := strconv.ParseInt(string(record.HeaderValue(streamTimeHeader)), 10, 64); err == nil {
+		js.advance(st)
+	}
+	switch key[0] {
+	case leftSide:
+		return js.left.receiveChange(record)
+	case rightSide:
+		return js.right.receiveChange(record)
+	}
+	return fmt.Errorf("invalid join store key: %v", key)
+}
+
+func (js *JoinStore[L, R]) Revoked() {
+	js.left.records.Clear(false)
+	js.left.byTime.Clear(false)
+	js.right.records.Clear(false)
+	js.right.byTime.Clear(false)
+}
@@ -0,0 +1,162 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windowing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams"
+)
+
+type pairStore = *JoinStore[string, string]
+
+func newPairStore(tp streams.TopicPartition) pairStore {
+	return NewJoinStore[string, string](tp, streams.StringCodec, streams.StringCodec)
+}
+
+func orNone(s *string) string {
+	if s == nil {
+		return "-"
+	}
+	return *s
+}
+
+func pairJoin(t *testing.T, joinType JoinType) (*Join[pairStore, string, string], *streams.TestDriver[pairStore]) {
+	join, err := NewJoin(JoinConfig[pairStore, string, string]{
+		LeftTopic:  "orders",
+		RightTopic: "payments",
+		Window:     10 * time.Second,
+		Grace:      5 * time.Second,
+		Type:       joinType,
+		Store:      func(js pairStore) *JoinStore[string, string] { return js },
+		Joiner: func(ec *streams.EventContext[pairStore], joined Joined[string, string]) {
+			ec.Forward(streams.NewRecord().
+				WithTopic("joined").
+				WithKeyString(joined.Key).
+				WithValue([]byte(orNone(joined.Left) + "|" + orNone(joined.Right))).
+				WithTimestamp(joined.Timestamp))
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return join, streams.NewTestDriver(streams.EventSourceConfig{
+		GroupId:       "join_group",
+		Topics:        []string{"orders", "payments"},
+		NumPartitions: 1,
+	}, newPairStore, join.Process)
+}
+
+func valueAt(key, value string, offset time.Duration) *streams.Record {
+	return keyAt(key, offset).WithValue([]byte(value))
+}
+
+// verifies the records forwarded by the Joiner, then clears all output
+func verifyJoined(t *testing.T, driver *streams.TestDriver[pairStore], expected ...string) {
+	t.Helper()
+	output := driver.Output("joined")
+	if len(output) != len(expected) {
+		t.Fatalf("incorrect joined count. actual: %d, expected: %d", len(output), len(expected))
+	}
+	for i, record := range output {
+		if actual := string(record.Key()) + ":" + string(record.Value()); actual != expected[i] {
+			t.Errorf("incorrect join. actual: %s, expected: %s", actual, expected[i])
+		}
+	}
+	driver.ClearOutput()
+}
+
+func TestInnerJoin(t *testing.T) {
+	if _, err := NewJoin(JoinConfig[pairStore, string, string]{LeftTopic: "orders", RightTopic: "orders"}); err != ErrJoinTopicsRequired {
+		t.Errorf("incorrect error. actual: %v, expected: %v", err, ErrJoinTopicsRequired)
+	}
+	join, driver := pairJoin(t, InnerJoin)
+	defer driver.Close()
+	driver.PipeTopic("orders", 0,
+		valueAt("a", "o1", 0),
+		valueAt("b", "o2", time.Second))
+	driver.PipeTopic("payments", 0,
+		valueAt("a", "p1", 5*time.Second),
+		// out of the window
+		valueAt("b", "p2", 12*time.Second))
+	// matches both payments for a
+	driver.PipeTopic("orders", 0, valueAt("a", "o3", 8*time.Second))
+	verifyJoined(t, driver, "a:o1|p1", "a:o3|p1")
+
+	if left, right := driver.Store(0).Len(); left != 3 || right != 2 {
+		t.Errorf("incorrect buffer size. actual: %d/%d, expected: %d/%d", left, right, 3, 2)
+	}
+	// expires everything, inner joins do not emit unmatched records
+	driver.Interject(0, join.CloseWindows)
+	verifyJoined(t, driver)
+	if left, right := driver.Store(0).Len(); left != 0 || right != 0 {
+		t.Errorf("incorrect buffer size. actual: %d/%d, expected: %d/%d", left, right, 0, 0)
+	}
+}
+
+func TestLeftJoin(t *testing.T) {
+	_, driver := pairJoin(t, LeftJoin)
+	defer driver.Close()
+	driver.PipeTopic("orders", 0,
+		valueAt("a", "o1", 0),
+		valueAt("b", "o2", time.Second))
+	driver.PipeTopic("payments", 0,
+		valueAt("a", "p1", 2*time.Second),
+		valueAt("c", "p2", 3*time.Second))
+	verifyJoined(t, driver, "a:o1|p1")
+
+	// stream time passes 2s + window + grace, expiring o1 and o2
+	driver.PipeTopic("orders", 0, valueAt("d", "o3", 17*time.Second))
+	verifyJoined(t, driver, "b:o2|-")
+	// late, o2 has already expired
+	driver.PipeTopic("payments", 0, valueAt("b", "p3", time.Second))
+	verifyJoined(t, driver)
+}
+
+func TestOuterJoin(t *testing.T) {
+	join, driver := pairJoin(t, OuterJoin)
+	defer driver.Close()
+	// the change log should reproduce the store
+	restored := newPairStore(streams.TopicPartition{})
+
+	driver.PipeTopic("orders", 0,
+		valueAt("a", "o1", 0),
+		valueAt("b", "o2", 0))
+	driver.PipeTopic("payments", 0,
+		valueAt("a", "p1", time.Second),
+		valueAt("c", "p2", 2*time.Second))
+	for _, record := range driver.ChangeLog(0) {
+		if err := restored.ReceiveChange(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	verifyJoined(t, driver, "a:o1|p1")
+	if left, right := restored.Len(); left != 2 || right != 2 {
+		t.Errorf("incorrect restored buffer size. actual: %d/%d, expected: %d/%d", left, right, 2, 2)
+	}
+	if !restored.StreamTime().Equal(driver.Store(0).StreamTime()) {
+		t.Errorf("incorrect restored stream time. actual: %v, expected: %v", restored.StreamTime(), driver.Store(0).StreamTime())
+	}
+	if br, ok := restored.left.records.Get(&bufferedRecord[string]{key: "b", timestamp: epoch.UnixMilli(), offset: 1}); !ok || br.matched {
+		t.Errorf("incorrect restored record for b: %+v", br)
+	}
+	if br, ok := restored.right.records.Get(&bufferedRecord[string]{key: "a", timestamp: epoch.Add(time.Second).UnixMilli()}); !ok || !br.matched {
+		t.Errorf("incorrect restored record for a: %+v", br)
+	}
+
+	driver.Interject(0, join.CloseWindows)
+	verifyJoined(t, driver, "b:o2|-", "c:-|p2")
+}
@@ -0,0 +1,237 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windowing
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/aws/go-kafka-event-source/streams"
+	"github.com/aws/go-kafka-event-source/streams/sak"
+	"github.com/google/btree"
+)
+
+// change log entries carry the stream time at which they were written, so stream time survives a partition reassignment
+const streamTimeHeader = "gkes_stream_time"
+
+// change log keys are prefixed by the window start and end
+const windowKeyPrefixSize = 16
+
+// A WindowedValue is the aggregate for a single key and window.
+type WindowedValue[V any] struct {
+	Key    string
+	Window Window
+	Value  V
+}
+
+type windowEntry[V any] struct {
+	key   string
+	start int64
+	end   int64
+	value V
+}
+
+func (we *windowEntry[V]) windowedValue() WindowedValue[V] {
+	return WindowedValue[V]{
+		Key:    we.key,
+		Window: newWindow(we.start, we.end),
+		Value:  we.value,
+	}
+}
+
+func (we *windowEntry[V]) changeLogKey() []byte {
+	key := make([]byte, windowKeyPrefixSize, windowKeyPrefixSize+len(we.key))
+	binary.BigEndian.PutUint64(key, uint64(we.start))
+	binary.BigEndian.PutUint64(key[8:], uint64(we.end))
+	return append(key, we.key...)
+}
+
+func parseChangeLogKey(key []byte) (k string, start, end int64, err error) {
+	if len(key) < windowKeyPrefixSize {
+		return "", 0, 0, fmt.Errorf("invalid window store key: %v", key)
+	}
+	start = int64(binary.BigEndian.Uint64(key))
+	end = int64(binary.BigEndian.Uint64(key[8:]))
+	return string(key[windowKeyPrefixSize:]), start, end, nil
+}
+
+func entryLess[V any](a, b *windowEntry[V]) bool {
+	if a.key != b.key {
+		return a.key < b.key
+	}
+	return a.start < b.start
+}
+
+func entryEndLess[V any](a, b *windowEntry[V]) bool {
+	if a.end != b.end {
+		return a.end < b.end
+	}
+	return entryLess(a, b)
+}
+
+/*
+WindowStore is a StateStore which holds the aggregates of an [Aggregation], per key and window, along with the stream time of the partition.
+Stream time is the latest record timestamp observed by the partition, and is used to determine when a window closes.
+
+A WindowStore may be used directly as the StateStore for an EventSource, or embedded in another StateStore. In the latter case,
+ReceiveChange and Revoked must be delegated to the WindowStore and the StateStore should not record any other change log entries.
+*/
+type WindowStore[V any] struct {
+	entries        *btree.BTreeG[*windowEntry[V]]
+	byEnd          *btree.BTreeG[*windowEntry[V]]
+	codec          streams.Codec[V]
+	streamTime     int64
+	topicPartition streams.TopicPartition
+}
+
+func NewJsonWindowStore[V any](tp streams.TopicPartition) *WindowStore[V] {
+	return NewWindowStore[V](tp, streams.JsonCodec[V]{})
+}
+
+func NewWindowStore[V any](tp streams.TopicPartition, codec streams.Codec[V]) *WindowStore[V] {
+	return &WindowStore[V]{
+		entries:        btree.NewG(64, entryLess[V]),
+		byEnd:          btree.NewG(64, entryEndLess[V]),
+		codec:          codec,
+		streamTime:     math.MinInt64,
+		topicPartition: tp,
+	}
+}
+
+// Returns the aggregate for `key` in `window`.
+func (ws *WindowStore[V]) Get(key string, window Window) (val V, ok bool) {
+	var entry *windowEntry[V]
+	if entry, ok = ws.entries.Get(&windowEntry[V]{key: key, start: window.Start.UnixMilli()}); ok {
+		val = entry.value
+	}
+	return
+}
+
+// Returns the open windows for `key` which start within [from, to], in ascending order.
+func (ws *WindowStore[V]) Fetch(key string, from, to time.Time) []WindowedValue[V] {
+	values := []WindowedValue[V]{}
+	ws.fetch(key, from.UnixMilli(), to.UnixMilli(), func(entry *windowEntry[V]) {
+		values = append(values, entry.windowedValue())
+	})
+	return values
+}
+
+func (ws *WindowStore[V]) fetch(key string, from, to int64, f func(*windowEntry[V])) {
+	ws.entries.AscendRange(&windowEntry[V]{key: key, start: from}, &windowEntry[V]{key: key, start: to + 1},
+		func(entry *windowEntry[V]) bool {
+			f(entry)
+			return true
+		})
+}
+
+// The latest record timestamp observed by this partition. Returns the zero time if no records have been observed.
+func (ws *WindowStore[V]) StreamTime() time.Time {
+	if ws.streamTime == math.MinInt64 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ws.streamTime)
+}
+
+// The number of open windows.
+func (ws *WindowStore[V]) Len() int {
+	return ws.entries.Len()
+}
+
+func (ws *WindowStore[V]) advance(ts int64) {
+	ws.streamTime = sak.Max(ws.streamTime, ts)
+}
+
+func (ws *WindowStore[V]) toChangeLogEntry(entry *windowEntry[V], tombstone bool) (streams.ChangeLogEntry, error) {
+	var cle streams.ChangeLogEntry
+	if tombstone {
+		cle = streams.NewChangeLogEntry()
+	} else {
+		var err error
+		if cle, err = streams.CreateChangeLogEntry(entry.value, ws.codec); err != nil {
+			return cle, err
+		}
+	}
+	return cle.WithKey(entry.changeLogKey()).
+		WithHeader(streamTimeHeader, []byte(strconv.FormatInt(ws.streamTime, 10))), nil
+}
+
+func (ws *WindowStore[V]) insert(entry *windowEntry[V]) {
+	if old, ok := ws.entries.ReplaceOrInsert(entry); ok {
+		ws.byEnd.Delete(old)
+	}
+	ws.byEnd.ReplaceOrInsert(entry)
+}
+
+func (ws *WindowStore[V]) remove(entry *windowEntry[V]) {
+	if old, ok := ws.entries.Delete(entry); ok {
+		ws.byEnd.Delete(old)
+	}
+}
+
+func (ws *WindowStore[V]) put(entry *windowEntry[V]) (streams.ChangeLogEntry, error) {
+	ws.insert(entry)
+	return ws.toChangeLogEntry(entry, false)
+}
+
+func (ws *WindowStore[V]) delete(entry *windowEntry[V]) streams.ChangeLogEntry {
+	ws.remove(entry)
+	// an empty value can not fail to encode
+	cle, _ := ws.toChangeLogEntry(entry, true)
+	return cle
+}
+
+// returns all windows which end at or before `end`, in order of their end time
+func (ws *WindowStore[V]) endedBy(end int64) []*windowEntry[V] {
+	entries := []*windowEntry[V]{}
+	ws.byEnd.Ascend(func(entry *windowEntry[V]) bool {
+		if entry.end > end {
+			return false
+		}
+		entries = append(entries, entry)
+		return true
+	})
+	return entries
+}
+
+func (ws *WindowStore[V]) ReceiveChange(record streams.IncomingRecord) error {
+	key, start, end, err := parseChangeLogKey(record.Key())
+	if err != nil {
+		return err
+	}
+	if st, err := strconv.ParseInt(string(record.HeaderValue(streamTimeHeader)), 10, 64); err == nil {
+		ws.advance(st)
+	}
+	entry := &windowEntry[V]{key: key, start: start, end: end}
+	if len(record.Value()) == 0 {
+		// a session window may have been replaced by a longer session with the same start
+		if existing, ok := ws.entries.Get(entry); ok && existing.end == end {
+			ws.remove(existing)
+		}
+		return nil
+	}
+	if entry.value, err = ws.codec.Decode(record.Value()); err != nil {
+		return err
+	}
+	ws.insert(entry)
+	return nil
+}
+
+func (ws *WindowStore[V]) Revoked() {
+	ws.entries.Clear(false)
+	ws.byEnd.Clear(false)
+}
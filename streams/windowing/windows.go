@@ -0,0 +1,110 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windowing
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrInvalidWindows = errors.New("invalid windows, size, advance and gap must be greater than zero, grace may not be negative")
+
+type windowKind int
+
+const (
+	tumbling windowKind = iota
+	hopping
+	session
+)
+
+// Window is the time interval of a windowed aggregate. For tumbling and hopping windows, the interval is half-open: [Start, End).
+// For session windows, Start and End are the timestamps of the first and last records of the session.
+type Window struct {
+	Start time.Time
+	End   time.Time
+}
+
+func newWindow(start, end int64) Window {
+	return Window{Start: time.UnixMilli(start), End: time.UnixMilli(end)}
+}
+
+// Windows defines how records are grouped into windows, and how long a window accepts late records.
+// Create with [Tumbling], [Hopping] or [Session].
+type Windows struct {
+	kind    windowKind
+	size    time.Duration
+	advance time.Duration
+	gap     time.Duration
+	grace   time.Duration
+}
+
+// Fixed size, non-overlapping windows. Each record belongs to exactly one window.
+func Tumbling(size time.Duration) Windows {
+	return Windows{kind: tumbling, size: size, advance: size}
+}
+
+// Fixed size windows which start every `advance`. When `advance` is less than `size`, windows overlap and a record may belong to more than one window.
+func Hopping(size, advance time.Duration) Windows {
+	return Windows{kind: hopping, size: size, advance: advance}
+}
+
+// Windows per key which are extended by every record received within `gap` of the session. When a record bridges two sessions, they are merged.
+func Session(gap time.Duration) Windows {
+	return Windows{kind: session, gap: gap}
+}
+
+// Returns a copy of Windows which accepts records up to `grace` after a window has ended (in stream time). Defaults to zero.
+func (w Windows) WithGrace(grace time.Duration) Windows {
+	w.grace = grace
+	return w
+}
+
+func (w Windows) validate() error {
+	if w.grace < 0 {
+		return ErrInvalidWindows
+	}
+	if w.kind == session {
+		if w.gap.Milliseconds() <= 0 {
+			return ErrInvalidWindows
+		}
+		return nil
+	}
+	if w.size.Milliseconds() <= 0 || w.advance.Milliseconds() <= 0 || w.advance > w.size {
+		return ErrInvalidWindows
+	}
+	return nil
+}
+
+// the latest window end (in unix milliseconds) which is closed at `streamTime`
+func (w Windows) closedThrough(streamTime int64) int64 {
+	closed := streamTime - w.grace.Milliseconds()
+	if w.kind == session {
+		closed -= w.gap.Milliseconds()
+	}
+	return closed
+}
+
+// returns the tumbling or hopping windows which contain `ts`, in ascending order
+func (w Windows) windowsFor(ts int64) []Window {
+	size, advance := w.size.Milliseconds(), w.advance.Milliseconds()
+	windows := make([]Window, 0, size/advance)
+	for start := ts - ts%advance; start > ts-size && start >= 0; start -= advance {
+		windows = append(windows, newWindow(start, start+size))
+	}
+	for i, j := 0, len(windows)-1; i < j; i, j = i+1, j-1 {
+		windows[i], windows[j] = windows[j], windows[i]
+	}
+	return windows
+}
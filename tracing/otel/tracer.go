@@ -0,0 +1,95 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package otel provides an OpenTelemetry implementation of [github.com/aws/go-kafka-event-source/streams.Tracer].
+
+	eventSource, err := streams.NewEventSource(streams.EventSourceConfig{
+		...
+		Tracer: otel.NewTracer(nil, nil), // uses the global TracerProvider and W3C trace context propagation
+	}, ...)
+
+Spans are of kind Consumer and carry the following attributes: messaging.system, messaging.destination.name,
+messaging.kafka.consumer.group, messaging.kafka.destination.partition and, for events, messaging.kafka.message.offset and gkes.event_type.
+*/
+package otel
+
+import (
+	"context"
+
+	"github.com/aws/go-kafka-event-source/streams"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The instrumentation scope name of spans started by a Tracer.
+const ScopeName = "github.com/aws/go-kafka-event-source/streams"
+
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// Creates a Tracer. If `provider` is nil, the global TracerProvider is used. If `propagator` is nil, W3C trace context propagation is used.
+func NewTracer(provider trace.TracerProvider, propagator propagation.TextMapPropagator) *Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	if propagator == nil {
+		propagator = propagation.TraceContext{}
+	}
+	return &Tracer{
+		tracer:     provider.Tracer(ScopeName),
+		propagator: propagator,
+	}
+}
+
+func (t *Tracer) Extract(ctx context.Context, carrier streams.TraceCarrier) context.Context {
+	return t.propagator.Extract(ctx, carrier)
+}
+
+func (t *Tracer) Inject(ctx context.Context, carrier streams.TraceCarrier) {
+	t.propagator.Inject(ctx, carrier)
+}
+
+func (t *Tracer) Start(ctx context.Context, info streams.SpanInfo) (context.Context, streams.Span) {
+	attributes := []attribute.KeyValue{
+		attribute.String("messaging.system", "kafka"),
+		attribute.String("messaging.destination.name", info.TopicPartition.Topic),
+		attribute.String("messaging.kafka.consumer.group", info.GroupId),
+		attribute.Int("messaging.kafka.destination.partition", int(info.TopicPartition.Partition)),
+	}
+	if info.Offset >= 0 {
+		attributes = append(attributes,
+			attribute.Int64("messaging.kafka.message.offset", info.Offset),
+			attribute.String("gkes.event_type", info.EventType))
+	}
+	ctx, span := t.tracer.Start(ctx, info.Name, trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(attributes...))
+	return ctx, otelSpan{span}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s otelSpan) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/go-kafka-event-source/streams"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type noopStore struct{}
+
+func (noopStore) ReceiveChange(streams.IncomingRecord) error { return nil }
+func (noopStore) Revoked()                                   {}
+
+func newNoopStore(streams.TopicPartition) noopStore { return noopStore{} }
+
+func TestTracer(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer provider.Shutdown(context.Background())
+
+	errFailed := errors.New("failed")
+	driver := streams.NewTestDriver(streams.EventSourceConfig{
+		GroupId:       "driver_group",
+		Topic:         "driver_topic",
+		NumPartitions: 2,
+		Tracer:        NewTracer(provider, nil),
+	}, newNoopStore, func(ec *streams.EventContext[noopStore], record streams.IncomingRecord) streams.ExecutionState {
+		if string(record.Value()) == "fail" {
+			return ec.Fail(errFailed)
+		}
+		ec.Forward(streams.NewRecord().WithTopic("output").WithValue(record.Value()))
+		return streams.Complete
+	})
+	defer driver.Close()
+
+	// an upstream producer's span
+	upstream, upstreamSpan := provider.Tracer("upstream").Start(context.Background(), "produce")
+	upstreamSpan.End()
+	input := streams.NewRecord().WithValue([]byte("ok")).WithRecordType("traced")
+	propagation.TraceContext{}.Inject(upstream, recordHeaders{input})
+	driver.Pipe(1, input)
+	driver.Pipe(1, streams.NewRecord().WithValue([]byte("fail")))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("incorrect span count. actual: %d, expected: %d", len(spans), 3)
+	}
+	processed, failed := spans[1], spans[2]
+	if processed.Name != "process traced" || processed.SpanKind != trace.SpanKindConsumer {
+		t.Errorf("incorrect span: %s, %v", processed.Name, processed.SpanKind)
+	}
+	if processed.Parent.SpanID() != upstreamSpan.SpanContext().SpanID() || processed.SpanContext.TraceID() != upstreamSpan.SpanContext().TraceID() {
+		t.Errorf("span is not a child of the upstream span")
+	}
+	if failed.Status.Code != codes.Error || failed.Status.Description != errFailed.Error() {
+		t.Errorf("incorrect failed span status: %+v", failed.Status)
+	}
+
+	output := driver.Output("output")
+	if len(output) != 1 {
+		t.Fatalf("incorrect output count. actual: %d, expected: %d", len(output), 1)
+	}
+	forwarded := trace.SpanContextFromContext(propagation.TraceContext{}.Extract(context.Background(), incomingHeaders{output[0]}))
+	if forwarded.SpanID() != processed.SpanContext.SpanID() || forwarded.TraceID() != processed.SpanContext.TraceID() {
+		t.Errorf("processing span was not injected into forwarded record")
+	}
+}
+
+type recordHeaders struct {
+	record *streams.Record
+}
+
+func (h recordHeaders) Get(string) string { return "" }
+func (h recordHeaders) Keys() []string    { return nil }
+func (h recordHeaders) Set(key, value string) {
+	h.record.WithHeader(key, []byte(value))
+}
+
+type incomingHeaders struct {
+	record streams.IncomingRecord
+}
+
+func (h incomingHeaders) Get(key string) string { return string(h.record.HeaderValue(key)) }
+func (h incomingHeaders) Keys() []string        { return nil }
+func (h incomingHeaders) Set(string, string)    {}